@@ -21,53 +21,37 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"regexp"
 	"runtime/debug"
-	"strconv"
 	"strings"
 
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud/metadata"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/config"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/credentials"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/metrics"
 	robotclient "github.com/syself/hetzner-cloud-controller-manager/internal/robot/client"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/robot/client/cache"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/robot/ratelimit"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes/scheme"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/tools/record"
 	cloudprovider "k8s.io/cloud-provider"
 	"k8s.io/klog/v2"
 )
 
 const (
-	hcloudTokenENVVar    = "HCLOUD_TOKEN"
-	hcloudEndpointENVVar = "HCLOUD_ENDPOINT"
-	hcloudNetworkENVVar  = "HCLOUD_NETWORK"
-	hcloudDebugENVVar    = "HCLOUD_DEBUG"
-	robotDebugENVVar     = "ROBOT_DEBUG"
-	robotEndpointENVVar  = "ROBOT_ENDPOINT"
-
-	// Only as reference - is used in hcops package.
-	// Default is 5 minutes.
-	RateLimitWaitTimeRobot = "RATE_LIMIT_WAIT_TIME_ROBOT"
-
-	// Disable the "master/server is attached to the network" check against the metadata service.
-	hcloudNetworkDisableAttachedCheckENVVar  = "HCLOUD_NETWORK_DISABLE_ATTACHED_CHECK"
-	hcloudNetworkRoutesEnabledENVVar         = "HCLOUD_NETWORK_ROUTES_ENABLED"
-	hcloudInstancesAddressFamily             = "HCLOUD_INSTANCES_ADDRESS_FAMILY"
-	hcloudLoadBalancersEnabledENVVar         = "HCLOUD_LOAD_BALANCERS_ENABLED"
-	hcloudLoadBalancersLocation              = "HCLOUD_LOAD_BALANCERS_LOCATION"
-	hcloudLoadBalancersNetworkZone           = "HCLOUD_LOAD_BALANCERS_NETWORK_ZONE"
-	hcloudLoadBalancersDisablePrivateIngress = "HCLOUD_LOAD_BALANCERS_DISABLE_PRIVATE_INGRESS"
-	hcloudLoadBalancersUsePrivateIP          = "HCLOUD_LOAD_BALANCERS_USE_PRIVATE_IP"
-	hcloudLoadBalancersDisableIPv6           = "HCLOUD_LOAD_BALANCERS_DISABLE_IPV6"
-	hcloudMetricsEnabledENVVar               = "HCLOUD_METRICS_ENABLED"
-	hcloudMetricsAddress                     = ":8233"
-	providerName                             = "hcloud"
-	hostNamePrefixRobot                      = "bm-"
+	hcloudTokenENVVar   = "HCLOUD_TOKEN"
+	providerName        = "hcloud"
+	hostNamePrefixRobot = "bm-"
 )
 
 var errMissingRobotCredentials = errors.New("missing robot credentials - cannot connect to robot API")
@@ -87,8 +71,12 @@ type cloud struct {
 	robotClient  robotclient.Client
 	instances    *instances
 	routes       *routes
+	routeGC      *routeGC
 	loadBalancer *loadBalancers
 	networkID    int64
+	cfg          config.HCCMConfiguration
+
+	eventBroadcaster record.EventBroadcaster
 }
 
 type LoggingTransport struct {
@@ -110,7 +98,7 @@ func (lt *LoggingTransport) RoundTrip(req *http.Request) (resp *http.Response, e
 	return resp, nil
 }
 
-func newHcloudClient(rootDir string) (*hcloud.Client, error) {
+func newHcloudClient(rootDir string, cfg config.HCCMConfiguration) (*hcloud.Client, error) {
 	credentialsDir := credentials.GetDirectory(rootDir)
 	token, err := credentials.GetInitialHcloudCredentialsFromDirectory(credentialsDir)
 	if err != nil {
@@ -131,48 +119,53 @@ func newHcloudClient(rootDir string) (*hcloud.Client, error) {
 	}
 
 	// start metrics server if enabled (enabled by default)
-	if os.Getenv(hcloudMetricsEnabledENVVar) != "false" {
-		go metrics.Serve(hcloudMetricsAddress)
+	if cfg.Metrics.Enabled {
+		go metrics.Serve(cfg.Metrics.Address)
 
 		opts = append(opts, hcloud.WithInstrumentation(metrics.GetRegistry()))
 	}
 
-	if os.Getenv(hcloudDebugENVVar) == "true" {
+	if cfg.HCloudClient.Debug {
 		opts = append(opts, hcloud.WithDebugWriter(os.Stderr))
 	}
-	if endpoint := os.Getenv(hcloudEndpointENVVar); endpoint != "" {
-		opts = append(opts, hcloud.WithEndpoint(endpoint))
+	if cfg.HCloudClient.Endpoint != "" {
+		opts = append(opts, hcloud.WithEndpoint(cfg.HCloudClient.Endpoint))
 	}
 	client := hcloud.NewClient(opts...)
 	return client, nil
 }
 
-func newCloud(_ io.Reader) (cloudprovider.Interface, error) {
+func newCloud(configReader io.Reader) (cloudprovider.Interface, error) {
 	const op = "hcloud/newCloud"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
+	cfg, err := config.Read(configReader)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
 	rootDir, err := os.Getwd()
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
-	hcloudClient, err := newHcloudClient(rootDir)
+	hcloudClient, err := newHcloudClient(rootDir, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	metadataClient := metadata.NewClient()
 
-	var httpClient *http.Client
-	if os.Getenv(robotDebugENVVar) == "true" {
-		httpClient = &http.Client{
-			Transport: &LoggingTransport{
-				roundTripper: http.DefaultTransport,
-			},
+	var transport http.RoundTripper = http.DefaultTransport
+	if cfg.Robot.Debug {
+		transport = &LoggingTransport{
+			roundTripper: transport,
 		}
-	} else {
-		httpClient = http.DefaultClient
+	}
+	robotLimiter := ratelimit.NewLimiter(ratelimit.DefaultBudgets, cfg.Robot.RateLimitWaitTime.Duration)
+	httpClient := &http.Client{
+		Transport: robotLimiter.RoundTripper(transport),
 	}
 
-	robotClient, err := cache.NewCachedRobotClient(rootDir, httpClient, os.Getenv(robotEndpointENVVar))
+	robotClient, err := cache.NewCachedRobotClient(rootDir, httpClient, cfg.Robot.Endpoint, cfg.Robot.CacheTimeout.Duration)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -182,7 +175,7 @@ func newCloud(_ io.Reader) (cloudprovider.Interface, error) {
 	}
 
 	var networkID int64
-	if v, ok := os.LookupEnv(hcloudNetworkENVVar); ok {
+	if v := cfg.Network.NameOrID; v != "" {
 		n, _, err := hcloudClient.Network.Get(context.Background(), v)
 		if err != nil {
 			return nil, fmt.Errorf("%s: %w", op, err)
@@ -191,12 +184,13 @@ func newCloud(_ io.Reader) (cloudprovider.Interface, error) {
 			return nil, fmt.Errorf("%s: Network %s not found", op, v)
 		}
 		networkID = n.ID
-
-		networkDisableAttachedCheck, err := getEnvBool(hcloudNetworkDisableAttachedCheckENVVar)
-		if err != nil {
-			return nil, fmt.Errorf("%s: checking if server is in Network not possible: %w", op, err)
+		for _, subnet := range n.Subnets {
+			if subnet.Type == hcloud.NetworkSubnetTypeVSwitch {
+				klog.InfoS("Network is coupled to a Robot vSwitch", "network", n.Name, "vSwitchID", subnet.VSwitchID, "ipRange", subnet.IPRange)
+			}
 		}
-		if !networkDisableAttachedCheck {
+
+		if !cfg.Network.DisableAttachedCheck {
 			e, err := serverIsAttachedToNetwork(metadataClient, networkID)
 			if err != nil {
 				return nil, fmt.Errorf("%s: checking if server is in Network not possible: %w", op, err)
@@ -207,7 +201,7 @@ func newCloud(_ io.Reader) (cloudprovider.Interface, error) {
 		}
 	}
 	if networkID == 0 {
-		klog.Infof("%s: %s empty", op, hcloudNetworkENVVar)
+		klog.Infof("%s: no network configured", op)
 	}
 
 	// Validate that the provided token works, and we have network connectivity to the Hetzner Cloud API
@@ -216,20 +210,17 @@ func newCloud(_ io.Reader) (cloudprovider.Interface, error) {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	lbOpsDefaults, lbDisablePrivateIngress, lbDisableIPv6, err := loadBalancerDefaultsFromEnv()
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
+	lbOpsDefaults := loadBalancerDefaultsFromConfig(cfg.LoadBalancer)
+	lbClassDefaults := loadBalancerClassDefaultsFromConfig(cfg.LoadBalancer)
 
 	klog.Infof("Hetzner Cloud k8s cloud controller %s started\n", ProviderVersion())
 
-	lbOpsDefaults.DisableIPv6 = lbDisableIPv6
-
 	eventBroadcaster := record.NewBroadcaster()
 	lbRecorder := eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "hetzner-ccm-loadbalancer"})
 
 	lbOps := &hcops.LoadBalancerOps{
 		LBClient:      &hcloudClient.LoadBalancer,
+		ServerClient:  &hcloudClient.Server,
 		CertOps:       &hcops.CertificateOps{CertClient: &hcloudClient.Certificate},
 		ActionClient:  &hcloudClient.Action,
 		NetworkClient: &hcloudClient.Network,
@@ -237,15 +228,39 @@ func newCloud(_ io.Reader) (cloudprovider.Interface, error) {
 		NetworkID:     networkID,
 		Recorder:      lbRecorder,
 		Defaults:      lbOpsDefaults,
+		ClassDefaults: lbClassDefaults,
+		TargetWorkers: cfg.LoadBalancer.TargetWorkers,
 	}
 
-	loadBalancers := newLoadBalancers(lbOps, &hcloudClient.Action, lbDisablePrivateIngress, lbDisableIPv6)
-	if os.Getenv(hcloudLoadBalancersEnabledENVVar) == "false" {
+	loadBalancers := newLoadBalancers(
+		lbOps, &hcloudClient.Action, lbRecorder,
+		cfg.LoadBalancer.DisablePrivateIngress, cfg.LoadBalancer.DisableIPv6, cfg.LoadBalancer.DryRun,
+	)
+	loadBalancers.class = cfg.LoadBalancer.Class
+	loadBalancers.classDefaults = lbClassDefaults
+	if !cfg.LoadBalancer.Enabled {
 		loadBalancers = nil
 	}
-	instancesAddressFamily, err := addressFamilyFromEnv()
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+	instancesAddressFamily := addressFamilyFromConfig(cfg.Instance.AddressFamily)
+
+	var (
+		routes  *routes
+		routeGC *routeGC
+	)
+	if networkID > 0 && cfg.Route.Enabled {
+		routes, err = newRoutes(hcloudClient, networkID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if cfg.Route.ClusterCIDR != "" {
+			_, routes.clusterCIDR, err = net.ParseCIDR(cfg.Route.ClusterCIDR)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", op, err)
+			}
+		}
+		if cfg.Route.GC.Enabled {
+			routeGC = newRouteGC(routes, cfg.Route, eventBroadcaster)
+		}
 	}
 
 	credentialsDir := credentials.GetDirectory(rootDir)
@@ -258,17 +273,125 @@ func newCloud(_ io.Reader) (cloudprovider.Interface, error) {
 		}
 	}
 
+	instances := newInstances(hcloudClient, robotClient, instancesAddressFamily, networkID, cfg.Robot.ProviderIDFormat)
+	instances.recorder = eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "hetzner-ccm-instances"})
+
 	return &cloud{
 		hcloudClient: hcloudClient,
 		robotClient:  robotClient,
-		instances:    newInstances(hcloudClient, robotClient, instancesAddressFamily, networkID),
+		instances:    instances,
 		loadBalancer: loadBalancers,
-		routes:       nil,
+		routes:       routes,
+		routeGC:      routeGC,
 		networkID:    networkID,
+		cfg:          cfg,
+
+		eventBroadcaster: eventBroadcaster,
 	}, nil
 }
 
-func (c *cloud) Initialize(_ cloudprovider.ControllerClientBuilder, _ <-chan struct{}) {
+func (c *cloud) Initialize(clientBuilder cloudprovider.ControllerClientBuilder, stop <-chan struct{}) {
+	client := clientBuilder.ClientOrDie("hcloud-cloud-controller-manager")
+	c.eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: client.CoreV1().Events("")})
+	if c.loadBalancer != nil {
+		c.loadBalancer.serviceClient = client.CoreV1()
+	}
+
+	if c.routes == nil && c.loadBalancer == nil {
+		return
+	}
+
+	informerFactory := informers.NewSharedInformerFactory(client, 0)
+	if c.routes != nil {
+		// Routes to Robot servers are mapped back to their nodes by the
+		// InternalIP of the nodes.
+		c.routes.nodeLister = informerFactory.Core().V1().Nodes().Lister()
+	}
+
+	var (
+		localTargets          *localTargets
+		secretCertificates    *secretCertificates
+		robotFailoverIPs      *robotFailoverIPs
+		floatingIPs           *floatingIPs
+		loadBalancerClasses   *loadBalancerClasses
+		secretInformerFactory informers.SharedInformerFactory
+	)
+	if c.loadBalancer != nil {
+		var err error
+		localTargets, err = newLocalTargets(c.loadBalancer, informerFactory)
+		if err != nil {
+			klog.ErrorS(err, "targets of Load Balancers of Services with externalTrafficPolicy Local are not updated")
+		}
+		c.loadBalancer.serviceLister = informerFactory.Core().V1().Services().Lister()
+
+		// Failover IPs are routed with the Robot API.
+		if robotClient, ok := c.robotClient.(robotclient.FailoverClient); ok {
+			robotFailoverIPs, err = newRobotFailoverIPs(robotClient, c.loadBalancer.recorder, client.CoreV1(), informerFactory)
+			if err != nil {
+				klog.ErrorS(err, "failover IPs are not rerouted when nodes fail")
+			}
+			c.loadBalancer.robotFailoverIPs = robotFailoverIPs
+		}
+
+		fipOps := &hcops.FloatingIPOps{FloatingIPClient: &c.hcloudClient.FloatingIP, ActionClient: &c.hcloudClient.Action}
+		floatingIPs, err = newFloatingIPs(fipOps, c.loadBalancer.recorder, client.CoreV1(), informerFactory)
+		if err != nil {
+			klog.ErrorS(err, "floating IPs are not reassigned when nodes fail")
+		}
+		c.loadBalancer.floatingIPs = floatingIPs
+
+		// The service controller ignores Services with a loadBalancerClass.
+		if c.loadBalancer.class != "" || len(c.loadBalancer.classDefaults) > 0 {
+			loadBalancerClasses, err = newLoadBalancerClasses(c.loadBalancer, client.CoreV1(), informerFactory)
+			if err != nil {
+				klog.ErrorS(err, "load balancers of services with a load balancer class are not reconciled")
+			}
+		}
+
+		if lbOps, ok := c.loadBalancer.lbOps.(*hcops.LoadBalancerOps); ok {
+			// Health checks may be derived from the readiness probes of the
+			// pods of a Service. Only the pods of these Services are listed,
+			// so the pods of the cluster are not watched.
+			lbOps.PodClient = client.CoreV1()
+
+			// Certificates are uploaded from TLS Secrets. Other Secrets are
+			// not watched.
+			secretInformerFactory = informers.NewSharedInformerFactoryWithOptions(client, 0,
+				informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+					opts.FieldSelector = fields.OneTermEqualSelector("type", string(corev1.SecretTypeTLS)).String()
+				}))
+			secretCertificates, err = newSecretCertificates(c.loadBalancer, lbOps, informerFactory, secretInformerFactory)
+			if err != nil {
+				klog.ErrorS(err, "certificates of TLS Secrets are not replaced when the Secrets change")
+			}
+		}
+	}
+
+	informerFactory.Start(stop)
+	informerFactory.WaitForCacheSync(stop)
+	if secretInformerFactory != nil {
+		secretInformerFactory.Start(stop)
+		secretInformerFactory.WaitForCacheSync(stop)
+	}
+
+	if localTargets != nil {
+		go localTargets.run(stop)
+	}
+	if secretCertificates != nil {
+		go secretCertificates.run(stop)
+	}
+	if robotFailoverIPs != nil {
+		go robotFailoverIPs.run(stop)
+	}
+	if floatingIPs != nil {
+		go floatingIPs.run(stop)
+	}
+	if loadBalancerClasses != nil {
+		go loadBalancerClasses.run(stop)
+	}
+	if c.routeGC != nil {
+		go c.routeGC.run(stop)
+	}
 }
 
 func (c *cloud) Instances() (cloudprovider.Instances, bool) {
@@ -297,15 +420,10 @@ func (c *cloud) Clusters() (cloudprovider.Clusters, bool) {
 }
 
 func (c *cloud) Routes() (cloudprovider.Routes, bool) {
-	if c.networkID > 0 && os.Getenv(hcloudNetworkRoutesEnabledENVVar) != "false" {
-		r, err := newRoutes(c.hcloudClient, c.networkID)
-		if err != nil {
-			klog.ErrorS(err, "create routes provider", "networkID", c.networkID)
-			return nil, false
-		}
-		return r, true
+	if c.routes == nil {
+		return nil, false // If no network is configured, disable the routes part
 	}
-	return nil, false // If no network is configured, disable the routes part
+	return c.routes, true
 }
 
 func (c *cloud) ProviderName() string {
@@ -316,33 +434,48 @@ func (c *cloud) HasClusterID() bool {
 	return false
 }
 
-func loadBalancerDefaultsFromEnv() (hcops.LoadBalancerDefaults, bool, bool, error) {
-	defaults := hcops.LoadBalancerDefaults{
-		Location:    os.Getenv(hcloudLoadBalancersLocation),
-		NetworkZone: os.Getenv(hcloudLoadBalancersNetworkZone),
-	}
-
-	if defaults.Location != "" && defaults.NetworkZone != "" {
-		return defaults, false, false, errors.New(
-			"HCLOUD_LOAD_BALANCERS_LOCATION/HCLOUD_LOAD_BALANCERS_NETWORK_ZONE: Only one of these can be set")
-	}
-
-	disablePrivateIngress, err := getEnvBool(hcloudLoadBalancersDisablePrivateIngress)
-	if err != nil {
-		return defaults, false, false, err
+// loadBalancerDefaultsFromConfig returns the cluster-wide defaults used by
+// hcops.LoadBalancerOps.
+func loadBalancerDefaultsFromConfig(cfg config.LoadBalancerConfiguration) hcops.LoadBalancerDefaults {
+	return hcops.LoadBalancerDefaults{
+		Location:              cfg.Location,
+		NetworkZone:           cfg.NetworkZone,
+		UsePrivateIP:          cfg.UsePrivateIP,
+		DisableIPv6:           cfg.DisableIPv6,
+		DisablePrivateIngress: cfg.DisablePrivateIngress,
 	}
+}
 
-	disableIPv6, err := getEnvBool(hcloudLoadBalancersDisableIPv6)
-	if err != nil {
-		return defaults, false, false, err
+// loadBalancerClassDefaultsFromConfig returns the defaults of the further
+// loadBalancerClasses used by hcops.LoadBalancerOps. Values not set in the
+// profile of a class are taken from the cluster-wide defaults.
+func loadBalancerClassDefaultsFromConfig(cfg config.LoadBalancerConfiguration) map[string]hcops.LoadBalancerDefaults {
+	if len(cfg.Classes) == 0 {
+		return nil
 	}
 
-	defaults.UsePrivateIP, err = getEnvBool(hcloudLoadBalancersUsePrivateIP)
-	if err != nil {
-		return defaults, false, false, err
+	classDefaults := make(map[string]hcops.LoadBalancerDefaults, len(cfg.Classes))
+	for name, class := range cfg.Classes {
+		defaults := loadBalancerDefaultsFromConfig(cfg)
+		if class.Location != "" || class.NetworkZone != "" {
+			defaults.Location = class.Location
+			defaults.NetworkZone = class.NetworkZone
+		}
+		if class.DisablePrivateIngress != nil {
+			defaults.DisablePrivateIngress = *class.DisablePrivateIngress
+		}
+		if class.UsePrivateIP != nil {
+			defaults.UsePrivateIP = *class.UsePrivateIP
+		}
+		if class.DisableIPv6 != nil {
+			defaults.DisableIPv6 = *class.DisableIPv6
+		}
+		if class.DisablePublicNetwork != nil {
+			defaults.DisablePublicNetwork = *class.DisablePublicNetwork
+		}
+		classDefaults[name] = defaults
 	}
-
-	return defaults, disablePrivateIngress, disableIPv6, nil
+	return classDefaults
 }
 
 // serverIsAttachedToNetwork checks if the server where the master is running on is attached to the configured private network
@@ -359,41 +492,17 @@ func serverIsAttachedToNetwork(metadataClient *metadata.Client, networkID int64)
 	return strings.Contains(serverPrivateNetworks, fmt.Sprintf("network_id: %d\n", networkID)), nil
 }
 
-// addressFamilyFromEnv returns the address family for the instance address from the environment
-// variable. Returns AddressFamilyIPv4 if unset.
-func addressFamilyFromEnv() (addressFamily, error) {
-	family, ok := os.LookupEnv(hcloudInstancesAddressFamily)
-	if !ok {
-		return AddressFamilyIPv4, nil
-	}
-
-	switch strings.ToLower(family) {
-	case "ipv6":
-		return AddressFamilyIPv6, nil
-	case "ipv4":
-		return AddressFamilyIPv4, nil
-	case "dualstack":
-		return AddressFamilyDualStack, nil
+// addressFamilyFromConfig returns the address family for the instance address. The value has already been
+// validated by [config.HCCMConfiguration.Validate]. Returns AddressFamilyIPv4 for unknown values.
+func addressFamilyFromConfig(family string) addressFamily {
+	switch family {
+	case config.AddressFamilyIPv6:
+		return AddressFamilyIPv6
+	case config.AddressFamilyDualStack:
+		return AddressFamilyDualStack
 	default:
-		return -1, fmt.Errorf(
-			"%v: Invalid value, expected one of: ipv4,ipv6,dualstack", hcloudInstancesAddressFamily)
-	}
-}
-
-// getEnvBool returns the boolean parsed from the environment variable with the given key and a potential error
-// parsing the var. Returns false if the env var is unset.
-func getEnvBool(key string) (bool, error) {
-	v, ok := os.LookupEnv(key)
-	if !ok {
-		return false, nil
+		return AddressFamilyIPv4
 	}
-
-	b, err := strconv.ParseBool(v)
-	if err != nil {
-		return false, fmt.Errorf("%s: %v", key, err)
-	}
-
-	return b, nil
 }
 
 func init() {
@@ -0,0 +1,237 @@
+package hcloud
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/annotation"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/metrics"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/providerid"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/informers"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+)
+
+// floatingIPClass is the loadBalancerClass of Services exposed by a Hetzner
+// Cloud Floating IP. The service controller ignores Services with a
+// loadBalancerClass, so floatingIPs also reports their status.
+const floatingIPClass = "hetzner.cloud/floating-ip"
+
+// Reasons of the events recorded on Services exposed by a Floating IP.
+const (
+	eventFloatingIPAssigned = "FloatingIPAssigned"
+	eventFloatingIPNoTarget = "FloatingIPNoTarget"
+)
+
+// floatingIPs assigns the Floating IPs of Services to cloud nodes running a
+// ready endpoint of the Services.
+//
+// The IPs are reassigned if their node becomes NotReady or no longer runs a
+// ready endpoint.
+type floatingIPs struct {
+	fipOps              *hcops.FloatingIPOps
+	serviceLister       corelisters.ServiceLister
+	nodeLister          corelisters.NodeLister
+	endpointSliceLister discoverylisters.EndpointSliceLister
+	recorder            record.EventRecorder
+
+	// serviceClient is used to report the status of Services with the
+	// floatingIPClass. The status of the other Services is reported by the
+	// service controller.
+	serviceClient corev1client.ServicesGetter
+
+	queue *serviceIPQueue
+
+	// mu serializes the assignment by the service controller and by the
+	// queue.
+	mu sync.Mutex
+}
+
+func newFloatingIPs(
+	fipOps *hcops.FloatingIPOps, recorder record.EventRecorder,
+	serviceClient corev1client.ServicesGetter, informerFactory informers.SharedInformerFactory,
+) (*floatingIPs, error) {
+	const op = "hcloud/newFloatingIPs"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	f := &floatingIPs{
+		fipOps:              fipOps,
+		serviceLister:       informerFactory.Core().V1().Services().Lister(),
+		nodeLister:          informerFactory.Core().V1().Nodes().Lister(),
+		endpointSliceLister: informerFactory.Discovery().V1().EndpointSlices().Lister(),
+		recorder:            recorder,
+		serviceClient:       serviceClient,
+	}
+
+	queue, err := newServiceIPQueue("floating-ips", usesFloatingIP, f.reconcile, informerFactory)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	f.queue = queue
+	return f, nil
+}
+
+// usesFloatingIP reports whether svc is exposed by a Floating IP, either by
+// its loadBalancerClass or by the LBFloatingIP annotation.
+func usesFloatingIP(svc *corev1.Service) bool {
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return false
+	}
+	if class := svc.Spec.LoadBalancerClass; class != nil {
+		return *class == floatingIPClass
+	}
+	_, ok := annotation.LBFloatingIP.StringFromService(svc)
+	return ok
+}
+
+// floatingIPRef returns the Floating IP of svc: the LBFloatingIP annotation
+// or the loadBalancerIP of svc.
+func floatingIPRef(svc *corev1.Service) (string, error) {
+	ref, ok := annotation.LBFloatingIP.StringFromService(svc)
+	if !ok {
+		ref = svc.Spec.LoadBalancerIP
+	}
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return "", fmt.Errorf("no Floating IP: set %s or spec.loadBalancerIP", annotation.LBFloatingIP)
+	}
+	return ref, nil
+}
+
+// floatingIPStatus returns the Load Balancer status of a Service exposed by
+// fip. The address of an IPv6 Floating IP is the address ref if it is one,
+// and the first address of its network otherwise.
+func floatingIPStatus(fip *hcloud.FloatingIP, ref string) *corev1.LoadBalancerStatus {
+	ip := fip.IP
+	if fip.Type == hcloud.FloatingIPTypeIPv6 {
+		if refIP := net.ParseIP(ref); refIP != nil {
+			ip = refIP
+		} else {
+			ip = slices.Clone(fip.IP)
+			ip[len(ip)-1] |= 1
+		}
+	}
+	return &corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: ip.String()}}}
+}
+
+// run processes the queue until stop is closed.
+func (f *floatingIPs) run(stop <-chan struct{}) {
+	f.queue.run(stop)
+}
+
+// reconcile assigns the Floating IP of the Service key.
+func (f *floatingIPs) reconcile(ctx context.Context, key string) error {
+	const op = "hcloud/floatingIPs.reconcile"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	svc, err := f.serviceLister.Services(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !usesFloatingIP(svc) {
+		return nil
+	}
+
+	status, err := f.assign(ctx, svc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := reportServiceIPStatus(ctx, f.serviceClient, svc, status); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// get returns the Load Balancer status of svc without assigning its Floating
+// IP.
+func (f *floatingIPs) get(ctx context.Context, svc *corev1.Service) (*corev1.LoadBalancerStatus, error) {
+	const op = "hcloud/floatingIPs.get"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	ref, err := floatingIPRef(svc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	fip, err := f.fipOps.GetFloatingIP(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return floatingIPStatus(fip, ref), nil
+}
+
+// assign assigns the Floating IP of svc to a Ready cloud node running a ready
+// endpoint of svc, unless it is already assigned to such a node.
+//
+// If there is no such node, the assignment is left unchanged.
+func (f *floatingIPs) assign(ctx context.Context, svc *corev1.Service) (*corev1.LoadBalancerStatus, error) {
+	const op = "hcloud/floatingIPs.assign"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	ref, err := floatingIPRef(svc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	targets, err := serviceIPTargetNodes(f.nodeLister, f.endpointSliceLister, svc, true)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	fip, err := f.fipOps.GetFloatingIP(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	status := floatingIPStatus(fip, ref)
+
+	var (
+		target   *corev1.Node
+		targetID int64
+	)
+	for _, node := range targets {
+		id, _, err := providerid.ToServerID(node.Spec.ProviderID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if fip.Server != nil && fip.Server.ID == id {
+			return status, nil
+		}
+		if target == nil {
+			target, targetID = node, id
+		}
+	}
+	if target == nil {
+		klog.InfoS("no target for Floating IP", "op", op, "service", svc.Name, "floatingIP", fip.Name)
+		f.recorder.Eventf(svc, corev1.EventTypeWarning, eventFloatingIPNoTarget,
+			"No Ready cloud node runs a ready endpoint, Floating IP %s is not reassigned", fip.Name)
+		return status, nil
+	}
+
+	klog.InfoS("assign Floating IP", "op", op, "service", svc.Name, "floatingIP", fip.Name, "node", target.Name)
+	if err := f.fipOps.Assign(ctx, fip, targetID); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	f.recorder.Eventf(svc, corev1.EventTypeNormal, eventFloatingIPAssigned,
+		"Assigned Floating IP %s to node %s", fip.Name, target.Name)
+	return status, nil
+}
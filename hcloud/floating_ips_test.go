@@ -0,0 +1,164 @@
+package hcloud
+
+import (
+	"net"
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/annotation"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/mocks"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestUsesFloatingIP(t *testing.T) {
+	tests := []struct {
+		name     string
+		svc      *corev1.Service
+		expected bool
+	}{
+		{
+			name: "annotation",
+			svc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{string(annotation.LBFloatingIP): "ingress"},
+				},
+				Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			},
+			expected: true,
+		},
+		{
+			name: "load balancer class",
+			svc: &corev1.Service{
+				Spec: corev1.ServiceSpec{
+					Type:              corev1.ServiceTypeLoadBalancer,
+					LoadBalancerClass: hcloud.Ptr(floatingIPClass),
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "annotation with other load balancer class",
+			svc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{string(annotation.LBFloatingIP): "ingress"},
+				},
+				Spec: corev1.ServiceSpec{
+					Type:              corev1.ServiceTypeLoadBalancer,
+					LoadBalancerClass: hcloud.Ptr("metallb"),
+				},
+			},
+		},
+		{
+			name: "Hetzner Cloud Load Balancer",
+			svc:  &corev1.Service{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, usesFloatingIP(tt.svc))
+		})
+	}
+}
+
+func TestFloatingIPStatus(t *testing.T) {
+	_, ipv6Net, err := net.ParseCIDR("2001:db8:1::/64")
+	require.NoError(t, err)
+	ipv4 := &hcloud.FloatingIP{IP: net.ParseIP("1.2.3.4"), Type: hcloud.FloatingIPTypeIPv4}
+	ipv6 := &hcloud.FloatingIP{IP: ipv6Net.IP, Network: ipv6Net, Type: hcloud.FloatingIPTypeIPv6}
+
+	assert.Equal(t, "1.2.3.4", floatingIPStatus(ipv4, "ingress").Ingress[0].IP)
+	assert.Equal(t, "2001:db8:1::1", floatingIPStatus(ipv6, "ingress-v6").Ingress[0].IP)
+	assert.Equal(t, "2001:db8:1::42", floatingIPStatus(ipv6, "2001:db8:1::42").Ingress[0].IP)
+	assert.Equal(t, "2001:db8:1::", ipv6.IP.String(), "IP of the Floating IP must not be modified")
+}
+
+func TestFloatingIPs_Reconcile(t *testing.T) {
+	tests := []struct {
+		name          string
+		nodes         []runtime.Object
+		assignedTo    int64
+		expAssignedTo int64
+		expEvent      string
+	}{
+		{
+			name: "keep assignment to ready node with endpoint",
+			nodes: []runtime.Object{
+				newTestCloudNode("node1", 1, true),
+				newTestCloudNode("node2", 2, true),
+			},
+			assignedTo: 2,
+		},
+		{
+			name: "reassign from NotReady node",
+			nodes: []runtime.Object{
+				newTestCloudNode("node1", 1, true),
+				newTestCloudNode("node2", 2, false),
+			},
+			assignedTo:    2,
+			expAssignedTo: 1,
+			expEvent:      "Normal FloatingIPAssigned Assigned Floating IP ingress to node node1",
+		},
+		{
+			name: "assign unassigned IP",
+			nodes: []runtime.Object{
+				newTestCloudNode("node2", 2, true),
+				newTestRobotNode("node1", 1, true),
+			},
+			expAssignedTo: 2,
+			expEvent:      "Normal FloatingIPAssigned Assigned Floating IP ingress to node node2",
+		},
+		{
+			name: "no target",
+			nodes: []runtime.Object{
+				newTestCloudNode("node1", 1, false),
+				newTestCloudNode("node3", 3, true),
+			},
+			assignedTo: 3,
+			expEvent:   "Warning FloatingIPNoTarget No Ready cloud node runs a ready endpoint, Floating IP ingress is not reassigned",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := newServiceIPTest(t, floatingIPClass, tt.nodes...)
+			ctx := st.ctx
+
+			fip := &hcloud.FloatingIP{ID: 10, Name: "ingress", IP: net.ParseIP("1.2.3.4"), Type: hcloud.FloatingIPTypeIPv4}
+			if tt.assignedTo != 0 {
+				fip.Server = &hcloud.Server{ID: tt.assignedTo}
+			}
+
+			fipClient := &mocks.FloatingIPClient{}
+			fipClient.Test(t)
+			fipClient.On("All", ctx).Return([]*hcloud.FloatingIP{fip}, nil)
+			actionClient := &mocks.ActionClient{}
+			actionClient.Test(t)
+			if tt.expAssignedTo != 0 {
+				action := &hcloud.Action{ID: 20}
+				fipClient.On("Assign", ctx, fip, &hcloud.Server{ID: tt.expAssignedTo}).Return(action, nil, nil)
+				actionClient.MockWatchProgress(ctx, action, nil)
+			}
+
+			f := &floatingIPs{
+				fipOps:              &hcops.FloatingIPOps{FloatingIPClient: fipClient, ActionClient: actionClient},
+				serviceLister:       st.serviceLister,
+				nodeLister:          st.nodeLister,
+				endpointSliceLister: st.endpointSliceLister,
+				recorder:            st.recorder,
+				serviceClient:       st.client.CoreV1(),
+			}
+
+			require.NoError(t, f.reconcile(ctx, "default/web"))
+			fipClient.AssertExpectations(t)
+			actionClient.AssertExpectations(t)
+
+			st.assertReconciled(t, tt.expEvent)
+		})
+	}
+}
@@ -19,13 +19,17 @@ package hcloud
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/config"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/metrics"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/providerid"
 	robotclient "github.com/syself/hetzner-cloud-controller-manager/internal/robot/client"
 	"github.com/syself/hrobot-go/models"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 	cloudprovider "k8s.io/cloud-provider"
 	"k8s.io/klog/v2"
 )
@@ -39,16 +43,40 @@ const (
 )
 
 type instances struct {
-	client        *hcloud.Client
-	robotClient   robotclient.Client
-	addressFamily addressFamily
-	networkID     int64
+	client                *hcloud.Client
+	robotClient           robotclient.Client
+	addressFamily         addressFamily
+	networkID             int64
+	robotProviderIDFormat string
+	vSwitchSubnets        *vSwitchSubnetCache
+
+	// recorder records the events of Robot nodes. It is set once the
+	// controller is created.
+	recorder record.EventRecorder
 }
 
+// nodeLabelNetwork selects the network of the InternalIP of a node. The value
+// is the name or ID of a Hetzner Cloud Network. Without the label, the network
+// of the controller (HCLOUD_NETWORK) is used.
+const nodeLabelNetwork = "instance.hetzner.cloud/network"
+
 var errServerNotFound = fmt.Errorf("server not found")
 
-func newInstances(client *hcloud.Client, robotClient robotclient.Client, addressFamily addressFamily, networkID int64) *instances {
-	return &instances{client, robotClient, addressFamily, networkID}
+func newInstances(
+	client *hcloud.Client,
+	robotClient robotclient.Client,
+	addressFamily addressFamily,
+	networkID int64,
+	robotProviderIDFormat string,
+) *instances {
+	return &instances{
+		client:                client,
+		robotClient:           robotClient,
+		addressFamily:         addressFamily,
+		networkID:             networkID,
+		robotProviderIDFormat: robotProviderIDFormat,
+		vSwitchSubnets:        newVSwitchSubnetCache(client),
+	}
 }
 
 // lookupServer attempts to locate the corresponding hcloud.Server or models.Server (robot server) for a given v1.Node.
@@ -76,6 +104,7 @@ func (i *instances) lookupServer(
 			}
 			bmServer, err = getRobotServerByID(i.robotClient, int(serverID), node)
 			if err != nil {
+				hcops.HandleRateLimitExceededError(i.recorder, err, node)
 				return nil, nil, false, fmt.Errorf("failed to get robot server \"%d\": %w", serverID, err)
 			}
 		}
@@ -86,12 +115,27 @@ func (i *instances) lookupServer(
 			if err != nil {
 				return nil, nil, false, fmt.Errorf("failed to get hcloud server %q: %w", string(node.Name), err)
 			}
+
+			// Robot nodes named like the nodes of the upstream
+			// hcloud-cloud-controller-manager have no "bm-" prefix.
+			if hcloudServer == nil && i.robotClient != nil &&
+				i.robotProviderIDFormat == config.RobotProviderIDFormatHRobot {
+				bmServer, err = getRobotServerByName(i.robotClient, node)
+				if err != nil {
+					hcops.HandleRateLimitExceededError(i.recorder, err, node)
+					return nil, nil, false, fmt.Errorf("failed to get robot server %q: %w", string(node.Name), err)
+				}
+				if bmServer != nil {
+					isHCloudServer = false
+				}
+			}
 		} else {
 			if i.robotClient == nil {
 				return nil, nil, false, errMissingRobotCredentials
 			}
 			bmServer, err = getRobotServerByName(i.robotClient, node)
 			if err != nil {
+				hcops.HandleRateLimitExceededError(i.recorder, err, node)
 				return nil, nil, false, fmt.Errorf("failed to get robot server %q: %w", string(node.Name), err)
 			}
 		}
@@ -143,6 +187,11 @@ func (i *instances) InstanceMetadata(ctx context.Context, node *corev1.Node) (me
 		return nil, err
 	}
 
+	networkID, err := i.nodeNetworkID(ctx, node)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
 	if isHCloudServer {
 		if hcloudServer == nil {
 			return nil, fmt.Errorf("failed to get instance metadata: no matching hcloud server found for node '%s': %w",
@@ -151,7 +200,7 @@ func (i *instances) InstanceMetadata(ctx context.Context, node *corev1.Node) (me
 		return &cloudprovider.InstanceMetadata{
 			ProviderID:    providerid.FromCloudServerID(hcloudServer.ID),
 			InstanceType:  hcloudServer.ServerType.Name,
-			NodeAddresses: hcloudNodeAddresses(i.addressFamily, i.networkID, hcloudServer),
+			NodeAddresses: hcloudNodeAddresses(i.addressFamily, networkID, hcloudServer),
 			Zone:          hcloudServer.Datacenter.Name,
 			Region:        hcloudServer.Datacenter.Location.Name,
 		}, nil
@@ -160,15 +209,62 @@ func (i *instances) InstanceMetadata(ctx context.Context, node *corev1.Node) (me
 		return nil, fmt.Errorf("failed to get instance metadata: no matching bare metal server found for node '%s': %w",
 			node.Name, errServerNotFound)
 	}
+	addresses := robotNodeAddresses(i.addressFamily, bmServer)
+	if networkID > 0 {
+		subnets, err := i.vSwitchSubnets.get(ctx, networkID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if ip := hcops.RobotVSwitchIP(node, subnets); ip != "" {
+			addresses = append(addresses, corev1.NodeAddress{Type: corev1.NodeInternalIP, Address: ip})
+		}
+	}
 	return &cloudprovider.InstanceMetadata{
-		ProviderID:    providerid.LegacyFromRobotServerNumber(bmServer.ServerNumber),
+		ProviderID:    i.robotProviderID(node, bmServer),
 		InstanceType:  getInstanceTypeOfRobotServer(bmServer),
-		NodeAddresses: robotNodeAddresses(i.addressFamily, bmServer),
+		NodeAddresses: addresses,
 		Zone:          getZoneOfRobotServer(bmServer),
 		Region:        getRegionOfRobotServer(bmServer),
 	}, nil
 }
 
+// nodeNetworkID returns the ID of the network of the InternalIP of node. It is
+// selected by the node label nodeLabelNetwork and defaults to the network of
+// the controller. Zero means no network.
+func (i *instances) nodeNetworkID(ctx context.Context, node *corev1.Node) (int64, error) {
+	const op = "hcloud/instancesv2.nodeNetworkID"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	nameOrID, ok := node.Labels[nodeLabelNetwork]
+	if !ok {
+		return i.networkID, nil
+	}
+	if id, err := strconv.ParseInt(nameOrID, 10, 64); err == nil {
+		return id, nil
+	}
+	network, _, err := i.client.Network.Get(ctx, nameOrID)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if network == nil {
+		return 0, fmt.Errorf("%s: network %q of label %s not found", op, nameOrID, nodeLabelNetwork)
+	}
+	return network.ID, nil
+}
+
+// robotProviderID returns the provider ID of a Robot node. The provider ID of
+// a node can not be changed once it is set, so existing nodes keep their
+// provider ID and only new nodes get one in the configured format.
+func (i *instances) robotProviderID(node *corev1.Node, server *models.Server) string {
+	if node.Spec.ProviderID != "" {
+		return node.Spec.ProviderID
+	}
+	if i.robotProviderIDFormat == config.RobotProviderIDFormatHRobot {
+		return providerid.FromRobotServerNumber(server.ServerNumber)
+	}
+	return providerid.LegacyFromRobotServerNumber(server.ServerNumber)
+}
+
 func hcloudNodeAddresses(addressFamily addressFamily, networkID int64, server *hcloud.Server) []corev1.NodeAddress {
 	var addresses []corev1.NodeAddress
 	addresses = append(
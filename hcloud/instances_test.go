@@ -26,6 +26,7 @@ import (
 
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/config"
 	"github.com/syself/hrobot-go/models"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -88,7 +89,7 @@ func TestInstances_InstanceExists(t *testing.T) {
 		})
 	})
 
-	instances := newInstances(env.Client, env.RobotClient, AddressFamilyIPv4, 0)
+	instances := newInstances(env.Client, env.RobotClient, AddressFamilyIPv4, 0, config.RobotProviderIDFormatLegacy)
 
 	tests := []struct {
 		name     string
@@ -206,7 +207,7 @@ func TestInstances_InstanceShutdown(t *testing.T) {
 		})
 	})
 
-	instances := newInstances(env.Client, env.RobotClient, AddressFamilyIPv4, 0)
+	instances := newInstances(env.Client, env.RobotClient, AddressFamilyIPv4, 0, config.RobotProviderIDFormatLegacy)
 
 	tests := []struct {
 		name     string
@@ -272,7 +273,7 @@ func TestInstances_InstanceMetadata(t *testing.T) {
 		})
 	})
 
-	instances := newInstances(env.Client, env.RobotClient, AddressFamilyIPv4, 0)
+	instances := newInstances(env.Client, env.RobotClient, AddressFamilyIPv4, 0, config.RobotProviderIDFormatLegacy)
 
 	metadata, err := instances.InstanceMetadata(context.TODO(), &corev1.Node{
 		Spec: corev1.NodeSpec{ProviderID: "hcloud://1"},
@@ -297,6 +298,78 @@ func TestInstances_InstanceMetadata(t *testing.T) {
 	}
 }
 
+func TestInstances_InstanceMetadataNodeNetwork(t *testing.T) {
+	env := newTestEnv()
+	defer env.Teardown()
+	env.Mux.HandleFunc("/servers/1", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(schema.ServerGetResponse{
+			Server: schema.Server{
+				ID:         1,
+				Name:       "foobar",
+				ServerType: schema.ServerType{Name: "asdf11"},
+				Datacenter: schema.Datacenter{Name: "Test DC", Location: schema.Location{Name: "Test Location"}},
+				PublicNet: schema.ServerPublicNet{
+					IPv4: schema.ServerPublicNetIPv4{IP: "203.0.113.7"},
+				},
+				PrivateNet: []schema.ServerPrivateNet{
+					{Network: 1, IP: "10.0.0.2"},
+					{Network: 2, IP: "10.1.0.2"},
+				},
+			},
+		})
+	})
+	env.Mux.HandleFunc("/networks", func(w http.ResponseWriter, r *http.Request) {
+		var networks []schema.Network
+		if r.URL.Query().Get("name") == "tenant-b" {
+			networks = append(networks, schema.Network{ID: 2, Name: "tenant-b", IPRange: "10.1.0.0/16"})
+		}
+		json.NewEncoder(w).Encode(schema.NetworkListResponse{Networks: networks})
+	})
+
+	instances := newInstances(env.Client, env.RobotClient, AddressFamilyIPv4, 1, config.RobotProviderIDFormatLegacy)
+
+	tests := []struct {
+		name       string
+		labels     map[string]string
+		internalIP string
+		err        string
+	}{
+		{name: "default network", internalIP: "10.0.0.2"},
+		{name: "network by ID", labels: map[string]string{nodeLabelNetwork: "2"}, internalIP: "10.1.0.2"},
+		{name: "network by name", labels: map[string]string{nodeLabelNetwork: "tenant-b"}, internalIP: "10.1.0.2"},
+		{
+			name:   "unknown network",
+			labels: map[string]string{nodeLabelNetwork: "tenant-c"},
+			err:    `hcloud/instancesv2.InstanceMetadata: hcloud/instancesv2.nodeNetworkID: network "tenant-c" of label instance.hetzner.cloud/network not found`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metadata, err := instances.InstanceMetadata(context.TODO(), &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{Labels: tt.labels},
+				Spec:       corev1.NodeSpec{ProviderID: "hcloud://1"},
+			})
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Fatalf("Expected error %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			expectedAddresses := []corev1.NodeAddress{
+				{Type: corev1.NodeHostName, Address: "foobar"},
+				{Type: corev1.NodeExternalIP, Address: "203.0.113.7"},
+				{Type: corev1.NodeInternalIP, Address: tt.internalIP},
+			}
+			if !reflect.DeepEqual(metadata.NodeAddresses, expectedAddresses) {
+				t.Fatalf("Expected addresses %+v but got %+v", expectedAddresses, metadata.NodeAddresses)
+			}
+		})
+	}
+}
+
 func TestInstances_InstanceMetadataRobotServer(t *testing.T) {
 	env := newTestEnv()
 	defer env.Teardown()
@@ -313,7 +386,7 @@ func TestInstances_InstanceMetadataRobotServer(t *testing.T) {
 		})
 	})
 
-	instances := newInstances(env.Client, env.RobotClient, AddressFamilyIPv4, 0)
+	instances := newInstances(env.Client, env.RobotClient, AddressFamilyIPv4, 0, config.RobotProviderIDFormatLegacy)
 
 	metadata, err := instances.InstanceMetadata(context.TODO(), &corev1.Node{
 		ObjectMeta: metav1.ObjectMeta{
@@ -341,6 +414,193 @@ func TestInstances_InstanceMetadataRobotServer(t *testing.T) {
 	}
 }
 
+func TestInstances_InstanceMetadataRobotServerVSwitch(t *testing.T) {
+	env := newTestEnv()
+	defer env.Teardown()
+	var networkRequests int
+	env.Mux.HandleFunc("/networks/4711", func(w http.ResponseWriter, _ *http.Request) {
+		networkRequests++
+		json.NewEncoder(w).Encode(schema.NetworkGetResponse{
+			Network: schema.Network{
+				ID:      4711,
+				IPRange: "10.0.0.0/8",
+				Subnets: []schema.NetworkSubnet{
+					{Type: "cloud", IPRange: "10.0.0.0/24", NetworkZone: "eu-central"},
+					{Type: "vswitch", IPRange: "10.0.1.0/24", NetworkZone: "eu-central", VSwitchID: 42},
+				},
+			},
+		})
+	})
+	env.Mux.HandleFunc("/robot/server/321", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(models.ServerResponse{
+			Server: models.Server{
+				ServerIP:      "123.123.123.123",
+				ServerIPv6Net: "2a01:f48:111:4221::",
+				ServerNumber:  321,
+				Product:       "bm-product 1",
+				Name:          "bm-server1",
+				Dc:            "NBG1-DC1",
+			},
+		})
+	})
+
+	instances := newInstances(env.Client, env.RobotClient, AddressFamilyIPv4, 4711, config.RobotProviderIDFormatLegacy)
+
+	metadata, err := instances.InstanceMetadata(context.TODO(), &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "bm-server1",
+			Annotations: map[string]string{"alpha.kubernetes.io/provided-node-ip": "10.0.1.7"},
+		},
+		Spec: corev1.NodeSpec{ProviderID: "hcloud://bm-321"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expectedAddresses := []corev1.NodeAddress{
+		{Type: corev1.NodeHostName, Address: "bm-server1"},
+		{Type: corev1.NodeExternalIP, Address: "123.123.123.123"},
+		{Type: corev1.NodeInternalIP, Address: "10.0.1.7"},
+	}
+	if !reflect.DeepEqual(metadata.NodeAddresses, expectedAddresses) {
+		t.Fatalf("Expected addresses %+v but got %+v", expectedAddresses, metadata.NodeAddresses)
+	}
+
+	// The vSwitch subnets are cached.
+	_, err = instances.InstanceMetadata(context.TODO(), &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "bm-server1"},
+		Spec:       corev1.NodeSpec{ProviderID: "hcloud://bm-321"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if networkRequests != 1 {
+		t.Fatalf("Expected 1 network request but got %d", networkRequests)
+	}
+}
+
+func TestInstances_InstanceMetadataRobotServerProviderIDFormat(t *testing.T) {
+	env := newTestEnv()
+	defer env.Teardown()
+	robotServer := models.Server{
+		ServerIP:      "123.123.123.123",
+		ServerIPv6Net: "2a01:f48:111:4221::",
+		ServerNumber:  321,
+		Product:       "bm-product 1",
+		Name:          "bm-server1",
+		Dc:            "NBG1-DC1",
+	}
+	env.Mux.HandleFunc("/robot/server", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode([]models.ServerResponse{{Server: robotServer}})
+	})
+	env.Mux.HandleFunc("/robot/server/321", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(models.ServerResponse{Server: robotServer})
+	})
+
+	tests := []struct {
+		name       string
+		format     string
+		providerID string
+		expected   string
+	}{
+		{
+			name:     "new node, legacy format",
+			format:   config.RobotProviderIDFormatLegacy,
+			expected: "hcloud://bm-321",
+		},
+		{
+			name:     "new node, hrobot format",
+			format:   config.RobotProviderIDFormatHRobot,
+			expected: "hrobot://321",
+		},
+		{
+			name:       "existing legacy node keeps its provider ID",
+			format:     config.RobotProviderIDFormatHRobot,
+			providerID: "hcloud://bm-321",
+			expected:   "hcloud://bm-321",
+		},
+		{
+			name:       "existing hrobot node keeps its provider ID",
+			format:     config.RobotProviderIDFormatLegacy,
+			providerID: "hrobot://321",
+			expected:   "hrobot://321",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			instances := newInstances(env.Client, env.RobotClient, AddressFamilyIPv4, 0, test.format)
+
+			metadata, err := instances.InstanceMetadata(context.TODO(), &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "bm-server1",
+				},
+				Spec: corev1.NodeSpec{ProviderID: test.providerID},
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if metadata.ProviderID != test.expected {
+				t.Fatalf("Expected provider ID %q but got %q", test.expected, metadata.ProviderID)
+			}
+		})
+	}
+}
+
+func TestInstances_InstanceMetadataRobotServerWithoutPrefix(t *testing.T) {
+	env := newTestEnv()
+	defer env.Teardown()
+	robotServer := models.Server{
+		ServerIP:      "123.123.123.123",
+		ServerIPv6Net: "2a01:f48:111:4221::",
+		ServerNumber:  321,
+		Product:       "bm-product 1",
+		Name:          "server1",
+		Dc:            "NBG1-DC1",
+	}
+	env.Mux.HandleFunc("/servers", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(schema.ServerListResponse{})
+	})
+	env.Mux.HandleFunc("/robot/server", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode([]models.ServerResponse{{Server: robotServer}})
+	})
+	env.Mux.HandleFunc("/robot/server/321", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(models.ServerResponse{Server: robotServer})
+	})
+
+	tests := []struct {
+		name       string
+		providerID string
+	}{
+		{
+			name:       "provider ID",
+			providerID: "hrobot://321",
+		},
+		{
+			name: "no provider ID",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			instances := newInstances(env.Client, env.RobotClient, AddressFamilyIPv4, 0, config.RobotProviderIDFormatHRobot)
+
+			metadata, err := instances.InstanceMetadata(context.TODO(), &corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: "server1",
+				},
+				Spec: corev1.NodeSpec{ProviderID: test.providerID},
+			})
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if metadata.ProviderID != "hrobot://321" {
+				t.Fatalf("Expected provider ID %q but got %q", "hrobot://321", metadata.ProviderID)
+			}
+		})
+	}
+}
+
 func TestNodeAddresses(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -0,0 +1,240 @@
+package hcloud
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/syself/hetzner-cloud-controller-manager/internal/metrics"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	servicehelper "k8s.io/cloud-provider/service/helpers"
+	"k8s.io/klog/v2"
+)
+
+// loadBalancerClassFinalizer keeps Services with a loadBalancerClass of the
+// controller until their Load Balancer is deleted. The service controller
+// only manages the finalizer of Services without loadBalancerClass.
+const loadBalancerClassFinalizer = "load-balancer.hetzner.cloud/cleanup"
+
+// Reasons of the events recorded on Services with a loadBalancerClass of the
+// controller. The service controller records the same events on Services
+// without loadBalancerClass.
+const (
+	eventEnsuredLoadBalancer    = "EnsuredLoadBalancer"
+	eventDeletedLoadBalancer    = "DeletedLoadBalancer"
+	eventSyncLoadBalancerFailed = "SyncLoadBalancerFailed"
+)
+
+// loadBalancerClasses creates, updates and deletes the Load Balancers of
+// Services with a loadBalancerClass of the controller, and reports their
+// status.
+//
+// The service controller ignores Services with a loadBalancerClass, so
+// loadBalancerClasses takes its place for them.
+type loadBalancerClasses struct {
+	loadBalancers *loadBalancers
+	serviceLister corelisters.ServiceLister
+	nodeLister    corelisters.NodeLister
+	serviceClient corev1client.CoreV1Interface
+
+	// queue holds the keys of the Services whose Load Balancer may have to
+	// be reconciled.
+	queue *serviceQueue
+}
+
+func newLoadBalancerClasses(
+	loadBalancers *loadBalancers, serviceClient corev1client.CoreV1Interface,
+	informerFactory informers.SharedInformerFactory,
+) (*loadBalancerClasses, error) {
+	const op = "hcloud/newLoadBalancerClasses"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	services := informerFactory.Core().V1().Services()
+	nodes := informerFactory.Core().V1().Nodes()
+
+	lc := &loadBalancerClasses{
+		loadBalancers: loadBalancers,
+		serviceLister: services.Lister(),
+		nodeLister:    nodes.Lister(),
+		serviceClient: serviceClient,
+	}
+	lc.queue = newServiceQueue("load-balancer-classes", lc.reconcile)
+
+	_, err := services.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    lc.enqueueService,
+		UpdateFunc: func(_, obj interface{}) { lc.enqueueService(obj) },
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	_, err = nodes.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(_ interface{}) { lc.enqueueAll() },
+		UpdateFunc: lc.nodeUpdated,
+		DeleteFunc: func(_ interface{}) { lc.enqueueAll() },
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return lc, nil
+}
+
+// wantsLoadBalancer reports whether svc wants a Load Balancer of one of the
+// loadBalancerClasses of the controller.
+func (lc *loadBalancerClasses) wantsLoadBalancer(svc *corev1.Service) bool {
+	return svc.Spec.Type == corev1.ServiceTypeLoadBalancer &&
+		svc.Spec.LoadBalancerClass != nil &&
+		lc.loadBalancers.managesClass(svc)
+}
+
+// enqueueService adds the Service obj to the queue, if it wants a Load
+// Balancer of the controller or still has one.
+func (lc *loadBalancerClasses) enqueueService(obj interface{}) {
+	svc, ok := obj.(*corev1.Service)
+	if !ok {
+		return
+	}
+	if !lc.wantsLoadBalancer(svc) && !slices.Contains(svc.Finalizers, loadBalancerClassFinalizer) {
+		return
+	}
+	lc.queue.Add(svc.Namespace + "/" + svc.Name)
+}
+
+// nodeUpdated adds all Services to the queue, if the node changed in a way
+// which may change the targets of their Load Balancers.
+func (lc *loadBalancerClasses) nodeUpdated(oldObj, newObj interface{}) {
+	oldNode, ok := oldObj.(*corev1.Node)
+	if !ok {
+		return
+	}
+	node, ok := newObj.(*corev1.Node)
+	if !ok {
+		return
+	}
+	if nodeReady(oldNode) == nodeReady(node) &&
+		oldNode.Spec.ProviderID == node.Spec.ProviderID &&
+		maps.Equal(oldNode.Labels, node.Labels) {
+		return
+	}
+	lc.enqueueAll()
+}
+
+// enqueueAll adds all Services with a Load Balancer of the controller to the
+// queue.
+func (lc *loadBalancerClasses) enqueueAll() {
+	services, err := lc.serviceLister.List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "list services")
+		return
+	}
+	for _, svc := range services {
+		lc.enqueueService(svc)
+	}
+}
+
+// run processes the queue until stop is closed.
+func (lc *loadBalancerClasses) run(stop <-chan struct{}) {
+	lc.queue.run(stop)
+}
+
+// reconcile ensures the Load Balancer of the Service key, or deletes it if
+// the Service no longer wants it.
+func (lc *loadBalancerClasses) reconcile(ctx context.Context, key string) error {
+	const op = "hcloud/loadBalancerClasses.reconcile"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	svc, err := lc.serviceLister.Services(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if !lc.wantsLoadBalancer(svc) || svc.DeletionTimestamp != nil {
+		if err := lc.delete(ctx, svc); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	}
+	if err := lc.ensure(ctx, svc); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// ensure creates or updates the Load Balancer of svc and reports its status.
+func (lc *loadBalancerClasses) ensure(ctx context.Context, svc *corev1.Service) error {
+	// The finalizer is added before the Load Balancer is created, so that
+	// the Service can not be deleted without deleting the Load Balancer.
+	if !slices.Contains(svc.Finalizers, loadBalancerClassFinalizer) {
+		updated := svc.DeepCopy()
+		updated.Finalizers = append(updated.Finalizers, loadBalancerClassFinalizer)
+		patched, err := servicehelper.PatchService(lc.serviceClient, svc, updated)
+		if err != nil {
+			return fmt.Errorf("add finalizer: %w", err)
+		}
+		svc = patched
+	}
+
+	nodes, err := lc.nodeLister.List(labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	// EnsureLoadBalancer annotates the Service it is passed. The service
+	// controller does not persist these annotations, so they are not
+	// persisted here either. The cluster name is not used by loadBalancers.
+	status, err := lc.loadBalancers.EnsureLoadBalancer(ctx, "", svc.DeepCopy(), lbCandidateNodes(nodes))
+	if err != nil {
+		lc.loadBalancers.recorder.Eventf(svc, corev1.EventTypeWarning, eventSyncLoadBalancerFailed,
+			"Error syncing load balancer: %v", err)
+		return err
+	}
+	if servicehelper.LoadBalancerStatusEqual(&svc.Status.LoadBalancer, status) {
+		return nil
+	}
+
+	updated := svc.DeepCopy()
+	updated.Status.LoadBalancer = *status
+	if _, err := servicehelper.PatchService(lc.serviceClient, svc, updated); err != nil {
+		return fmt.Errorf("report status: %w", err)
+	}
+	lc.loadBalancers.recorder.Event(svc, corev1.EventTypeNormal, eventEnsuredLoadBalancer, "Ensured load balancer")
+	return nil
+}
+
+// delete deletes the Load Balancer of svc, if it has the
+// loadBalancerClassFinalizer, and removes the finalizer.
+func (lc *loadBalancerClasses) delete(ctx context.Context, svc *corev1.Service) error {
+	if !slices.Contains(svc.Finalizers, loadBalancerClassFinalizer) {
+		return nil
+	}
+
+	if err := lc.loadBalancers.EnsureLoadBalancerDeleted(ctx, "", svc); err != nil {
+		lc.loadBalancers.recorder.Eventf(svc, corev1.EventTypeWarning, eventSyncLoadBalancerFailed,
+			"Error deleting load balancer: %v", err)
+		return err
+	}
+
+	updated := svc.DeepCopy()
+	updated.Finalizers = slices.DeleteFunc(updated.Finalizers, func(f string) bool {
+		return f == loadBalancerClassFinalizer
+	})
+	updated.Status.LoadBalancer = corev1.LoadBalancerStatus{}
+	if _, err := servicehelper.PatchService(lc.serviceClient, svc, updated); err != nil {
+		return fmt.Errorf("remove finalizer: %w", err)
+	}
+	lc.loadBalancers.recorder.Event(svc, corev1.EventTypeNormal, eventDeletedLoadBalancer, "Deleted load balancer")
+	return nil
+}
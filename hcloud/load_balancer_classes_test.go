@@ -0,0 +1,183 @@
+package hcloud
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/record"
+	cloudprovider "k8s.io/cloud-provider"
+)
+
+func TestLoadBalancers_ManagesClass(t *testing.T) {
+	classDefaults := map[string]hcops.LoadBalancerDefaults{"hetzner.cloud/internal": {}}
+
+	tests := []struct {
+		name     string
+		ownClass string
+		svcClass *string
+		expected bool
+	}{
+		{
+			name:     "no class",
+			expected: true,
+		},
+		{
+			name:     "other class",
+			svcClass: hcloud.Ptr("metallb"),
+		},
+		{
+			name:     "further class",
+			svcClass: hcloud.Ptr("hetzner.cloud/internal"),
+			expected: true,
+		},
+		{
+			name:     "no class with own class",
+			ownClass: "hetzner.cloud/public",
+		},
+		{
+			name:     "own class",
+			ownClass: "hetzner.cloud/public",
+			svcClass: hcloud.Ptr("hetzner.cloud/public"),
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &loadBalancers{class: tt.ownClass, classDefaults: classDefaults}
+			svc := &corev1.Service{Spec: corev1.ServiceSpec{LoadBalancerClass: tt.svcClass}}
+			assert.Equal(t, tt.expected, l.managesClass(svc))
+		})
+	}
+}
+
+func TestLoadBalancers_EnsureLoadBalancer_OtherClass(t *testing.T) {
+	l := &loadBalancers{class: "hetzner.cloud/public"}
+	svc := &corev1.Service{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer}}
+
+	_, err := l.EnsureLoadBalancer(context.Background(), "test-cluster", svc, nil)
+	assert.ErrorIs(t, err, cloudprovider.ImplementedElsewhere)
+	err = l.UpdateLoadBalancer(context.Background(), "test-cluster", svc, nil)
+	assert.ErrorIs(t, err, cloudprovider.ImplementedElsewhere)
+}
+
+func TestLoadBalancerClasses_Reconcile(t *testing.T) {
+	lb := &hcloud.LoadBalancer{
+		ID:               1,
+		LoadBalancerType: &hcloud.LoadBalancerType{Name: "lb11"},
+		Location:         &hcloud.Location{Name: "nbg1", NetworkZone: hcloud.NetworkZoneEUCentral},
+		PublicNet: hcloud.LoadBalancerPublicNet{
+			Enabled: false,
+			IPv4:    hcloud.LoadBalancerPublicNetIPv4{IP: net.ParseIP("1.2.3.4")},
+		},
+		PrivateNet: []hcloud.LoadBalancerPrivateNet{{IP: net.ParseIP("10.0.0.5")}},
+	}
+
+	tests := []struct {
+		name          string
+		class         string
+		finalizers    []string
+		deleted       bool
+		mock          func(lbOps *hcops.MockLoadBalancerOps)
+		expFinalizers []string
+		expIngress    []string
+		expEvent      string
+	}{
+		{
+			name:  "ensure load balancer of internal class",
+			class: "hetzner.cloud/internal",
+			mock: func(lbOps *hcops.MockLoadBalancerOps) {
+				lbOps.On("GetByK8SServiceUID", mock.Anything, mock.Anything).Return(lb, nil)
+				lbOps.On("ReconcileHCLB", mock.Anything, lb, mock.Anything).Return(false, nil)
+				lbOps.On("ReconcileHCLBTargets", mock.Anything, lb, mock.Anything, mock.Anything).Return(false, nil)
+				lbOps.On("ReconcileHCLBServices", mock.Anything, lb, mock.Anything).Return(false, nil)
+			},
+			expFinalizers: []string{loadBalancerClassFinalizer},
+			expIngress:    []string{"10.0.0.5"},
+			expEvent:      "Normal EnsuredLoadBalancer Ensured load balancer",
+		},
+		{
+			name:       "delete load balancer of deleted service",
+			class:      "hetzner.cloud/internal",
+			finalizers: []string{loadBalancerClassFinalizer},
+			deleted:    true,
+			mock: func(lbOps *hcops.MockLoadBalancerOps) {
+				lbOps.On("GetByK8SServiceUID", mock.Anything, mock.Anything).Return(lb, nil)
+				lbOps.On("Delete", mock.Anything, lb).Return(nil)
+				lbOps.On("DeleteCertificates", mock.Anything, mock.Anything).Return(nil)
+			},
+			expEvent: "Normal DeletedLoadBalancer Deleted load balancer",
+		},
+		{
+			name:  "ignore service of other class",
+			class: "metallb",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:       "web",
+					Namespace:  "default",
+					UID:        "web-uid",
+					Finalizers: tt.finalizers,
+				},
+				Spec: corev1.ServiceSpec{
+					Type:              corev1.ServiceTypeLoadBalancer,
+					LoadBalancerClass: &tt.class,
+				},
+			}
+			if tt.deleted {
+				svc.DeletionTimestamp = &metav1.Time{}
+			}
+
+			lbOps := &hcops.MockLoadBalancerOps{}
+			lbOps.Test(t)
+			if tt.mock != nil {
+				tt.mock(lbOps)
+			}
+
+			recorder := record.NewFakeRecorder(10)
+			client := fake.NewClientset(svc.DeepCopy())
+			l := newLoadBalancers(lbOps, nil, recorder, false, false, false)
+			l.classDefaults = map[string]hcops.LoadBalancerDefaults{
+				"hetzner.cloud/internal": {DisablePublicNetwork: true},
+			}
+			lc := &loadBalancerClasses{
+				loadBalancers: l,
+				serviceLister: corelisters.NewServiceLister(newTestIndexer(t, svc)),
+				nodeLister:    corelisters.NewNodeLister(newTestIndexer(t)),
+				serviceClient: client.CoreV1(),
+			}
+
+			require.NoError(t, lc.reconcile(ctx, "default/web"))
+			lbOps.AssertExpectations(t)
+
+			if tt.expEvent != "" {
+				assert.Equal(t, tt.expEvent, <-recorder.Events)
+			}
+			assert.Empty(t, recorder.Events)
+
+			updated, err := client.CoreV1().Services("default").Get(ctx, "web", metav1.GetOptions{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.expFinalizers, updated.Finalizers)
+			var ingress []string
+			for _, ing := range updated.Status.LoadBalancer.Ingress {
+				ingress = append(ingress, ing.IP)
+			}
+			assert.Equal(t, tt.expIngress, ingress)
+		})
+	}
+}
@@ -2,16 +2,30 @@ package hcloud
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/annotation"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/metrics"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/record"
 	cloudprovider "k8s.io/cloud-provider"
+	"k8s.io/cloud-provider/api"
 	"k8s.io/klog/v2"
 )
 
@@ -23,27 +37,133 @@ type LoadBalancerOps interface {
 	GetByK8SServiceUID(ctx context.Context, svc *corev1.Service) (*hcloud.LoadBalancer, error)
 	Create(ctx context.Context, lbName string, service *corev1.Service) (*hcloud.LoadBalancer, error)
 	Delete(ctx context.Context, lb *hcloud.LoadBalancer) error
+	DeleteCertificates(ctx context.Context, uid types.UID) error
 	ReconcileHCLB(ctx context.Context, lb *hcloud.LoadBalancer, svc *corev1.Service) (bool, error)
 	ReconcileHCLBTargets(ctx context.Context, lb *hcloud.LoadBalancer, svc *corev1.Service, nodes []*corev1.Node) (bool, error)
 	ReconcileHCLBServices(ctx context.Context, lb *hcloud.LoadBalancer, svc *corev1.Service) (bool, error)
+	PlanHCLB(
+		ctx context.Context, lbName string, lb *hcloud.LoadBalancer, svc *corev1.Service, nodes []*corev1.Node,
+	) (*hcops.LoadBalancerPlan, error)
 }
 
+// conditionLoadBalancerReady is the type of the Service condition reporting
+// the result of the last reconciliation of its Load Balancer.
+const conditionLoadBalancerReady = "HCloudLoadBalancerReady"
+
+// eventPlannedChanges is the reason of the event listing the changes planned
+// in dry-run mode.
+const eventPlannedChanges = "PlannedChanges"
+
+// eventLoadBalancerAdopted is the reason of the event recorded when a Service
+// adopts the Load Balancer referenced by its ID annotation.
+const eventLoadBalancerAdopted = "LoadBalancerAdopted"
+
+// managedCertificateRetryInterval is the interval in which a Service is
+// reconciled again while the managed certificate for its changed domains is
+// not issued yet.
+const managedCertificateRetryInterval = 30 * time.Second
+
+// labelAdoptable marks a Load Balancer without owner, or whose owner is not in
+// the cluster, as adoptable by the Service referencing it in its ID
+// annotation. Without it, such Load Balancers are not adopted, as they may
+// have been created for other purposes or belong to another cluster.
+const labelAdoptable = "hcloud-ccm/adoptable"
+
 type loadBalancers struct {
 	lbOps                        LoadBalancerOps
 	ac                           hcops.HCloudActionClient // Deprecated: should only be referenced by hcops types
 	disablePrivateIngressDefault bool
 	disableIPv6Default           bool
+	dryRunDefault                bool
+
+	// recorder records the planned changes in dry-run mode and the adoption
+	// of Load Balancers.
+	recorder record.EventRecorder
+
+	// serviceClient is used to report the conditionLoadBalancerReady
+	// condition. It is set once the controller is initialized, the condition
+	// is not reported without it.
+	serviceClient corev1client.ServicesGetter
+
+	// endpointSliceLister is used to find the Nodes with ready endpoints of
+	// Services with externalTrafficPolicy Local. It is set once the
+	// controller is initialized. Without it, all Nodes are targets.
+	endpointSliceLister discoverylisters.EndpointSliceLister
+
+	// serviceLister is used to check whether the owner of a Load Balancer
+	// adopted by ID still exists. It is set once the controller is
+	// initialized. Without it, only Load Balancers without owner are adopted.
+	serviceLister corelisters.ServiceLister
+
+	// targetsMu serializes the reconciliation of targets by the service
+	// controller and by localTargets.
+	targetsMu sync.Mutex
+	// appliedTargets holds the names of the nodes last applied as targets
+	// of the Load Balancers, keyed by the UID of their Service. Protected by
+	// targetsMu.
+	appliedTargets map[types.UID]string
+
+	// servicesMu serializes the reconciliation of services by the service
+	// controller and by secretCertificates.
+	servicesMu sync.Mutex
+
+	// robotFailoverIPs routes the failover IPs of Services with the
+	// LBRobotFailoverIP annotation. It is set once the controller is
+	// initialized, if Robot credentials are configured.
+	robotFailoverIPs *robotFailoverIPs
+
+	// floatingIPs assigns the Floating IPs of Services with the LBFloatingIP
+	// annotation. It is set once the controller is initialized.
+	floatingIPs *floatingIPs
+
+	// class is the loadBalancerClass of the Services whose Load Balancers are
+	// created with the cluster-wide defaults. If set, Services without a
+	// loadBalancerClass are left to other implementations.
+	class string
+
+	// classDefaults are the defaults of the further loadBalancerClasses
+	// whose Load Balancers are created by the controller.
+	classDefaults map[string]hcops.LoadBalancerDefaults
 }
 
-func newLoadBalancers(lbOps LoadBalancerOps, ac hcops.HCloudActionClient, disablePrivateIngressDefault, disableIPv6Default bool) *loadBalancers {
+func newLoadBalancers(
+	lbOps LoadBalancerOps, ac hcops.HCloudActionClient, recorder record.EventRecorder,
+	disablePrivateIngressDefault, disableIPv6Default, dryRunDefault bool,
+) *loadBalancers {
 	return &loadBalancers{
 		lbOps:                        lbOps,
 		ac:                           ac,
+		recorder:                     recorder,
 		disablePrivateIngressDefault: disablePrivateIngressDefault,
 		disableIPv6Default:           disableIPv6Default,
+		dryRunDefault:                dryRunDefault,
 	}
 }
 
+// managesClass reports whether the Load Balancer of svc is created by the
+// controller, according to the loadBalancerClass of svc.
+func (l *loadBalancers) managesClass(svc *corev1.Service) bool {
+	class := svc.Spec.LoadBalancerClass
+	if class == nil {
+		return l.class == ""
+	}
+	if *class == l.class {
+		return true
+	}
+	_, ok := l.classDefaults[*class]
+	return ok
+}
+
+// getClassDefaults returns the defaults of the loadBalancerClass of svc, if
+// it is one of the further classes of the controller.
+func (l *loadBalancers) getClassDefaults(svc *corev1.Service) (hcops.LoadBalancerDefaults, bool) {
+	if svc.Spec.LoadBalancerClass == nil {
+		return hcops.LoadBalancerDefaults{}, false
+	}
+	defaults, ok := l.classDefaults[*svc.Spec.LoadBalancerClass]
+	return defaults, ok
+}
+
 func matchNodeSelector(svc *corev1.Service, nodes []*corev1.Node) ([]*corev1.Node, error) {
 	var (
 		err           error
@@ -67,12 +187,135 @@ func matchNodeSelector(svc *corev1.Service, nodes []*corev1.Node) ([]*corev1.Nod
 	return selectedNodes, nil
 }
 
+// selectNodes returns the nodes which are targets of the Load Balancer of
+// svc: the nodes matching the node selector and, if svc has the
+// externalTrafficPolicy Local, run a serving endpoint of svc.
+//
+// If no node runs a serving endpoint of such a Service, keep is true and the
+// current targets are kept. The endpoints are gone only for a moment during a
+// rollout or a reschedule, and the health check of the healthCheckNodePort
+// takes the nodes out of rotation anyway.
+func (l *loadBalancers) selectNodes(svc *corev1.Service, nodes []*corev1.Node) ([]*corev1.Node, bool, error) {
+	selectedNodes, err := matchNodeSelector(svc, nodes)
+	if err != nil {
+		return nil, false, err
+	}
+	if svc.Spec.ExternalTrafficPolicy != corev1.ServiceExternalTrafficPolicyLocal || l.endpointSliceLister == nil {
+		return selectedNodes, false, nil
+	}
+
+	endpointNodes, err := nodesWithEndpoints(l.endpointSliceLister, svc, true)
+	if err != nil {
+		return nil, false, err
+	}
+	if len(endpointNodes) == 0 {
+		return nil, true, nil
+	}
+	return slices.DeleteFunc(selectedNodes, func(n *corev1.Node) bool {
+		return !endpointNodes[n.Name]
+	}), false, nil
+}
+
+// nodesWithEndpoints returns the names of the nodes running a ready endpoint
+// of svc. If serving is true, terminating endpoints which still serve
+// requests count as well.
+func nodesWithEndpoints(
+	lister discoverylisters.EndpointSliceLister, svc *corev1.Service, serving bool,
+) (map[string]bool, error) {
+	selector := labels.SelectorFromSet(labels.Set{discoveryv1.LabelServiceName: svc.Name})
+	endpointSlices, err := lister.EndpointSlices(svc.Namespace).List(selector)
+	if err != nil {
+		return nil, fmt.Errorf("list endpoint slices: %w", err)
+	}
+
+	nodeNames := make(map[string]bool)
+	for _, slice := range endpointSlices {
+		for _, ep := range slice.Endpoints {
+			if ep.NodeName == nil {
+				continue
+			}
+			// An unknown readiness is interpreted as ready.
+			ready := ep.Conditions.Ready == nil || *ep.Conditions.Ready
+			if serving && ep.Conditions.Serving != nil {
+				ready = *ep.Conditions.Serving
+			}
+			if ready {
+				nodeNames[*ep.NodeName] = true
+			}
+		}
+	}
+	return nodeNames, nil
+}
+
+// reconcileTargets makes nodes the targets of lb, unless keep is true. The
+// nodes are recorded as the targets of the Load Balancer of svc, see
+// targetsApplied.
+func (l *loadBalancers) reconcileTargets(
+	ctx context.Context, lb *hcloud.LoadBalancer, svc *corev1.Service, nodes []*corev1.Node, keep bool,
+) (bool, error) {
+	if keep {
+		return false, nil
+	}
+
+	l.targetsMu.Lock()
+	defer l.targetsMu.Unlock()
+	changed, err := l.lbOps.ReconcileHCLBTargets(ctx, lb, svc, nodes)
+	if err != nil {
+		delete(l.appliedTargets, svc.UID)
+		return changed, err
+	}
+	if l.appliedTargets == nil {
+		l.appliedTargets = make(map[types.UID]string)
+	}
+	l.appliedTargets[svc.UID] = nodeNamesKey(nodes)
+	return changed, nil
+}
+
+// targetsApplied reports whether nodes were the targets last applied to the
+// Load Balancer of svc.
+func (l *loadBalancers) targetsApplied(svc *corev1.Service, nodes []*corev1.Node) bool {
+	l.targetsMu.Lock()
+	defer l.targetsMu.Unlock()
+	applied, ok := l.appliedTargets[svc.UID]
+	return ok && applied == nodeNamesKey(nodes)
+}
+
+func nodeNamesKey(nodes []*corev1.Node) string {
+	names := make([]string, len(nodes))
+	for i, n := range nodes {
+		names[i] = n.Name
+	}
+	slices.Sort(names)
+	return strings.Join(names, ",")
+}
+
 func (l *loadBalancers) GetLoadBalancer(
 	ctx context.Context, _ string, service *corev1.Service,
 ) (status *corev1.LoadBalancerStatus, exists bool, err error) {
 	const op = "hcloud/loadBalancers.GetLoadBalancer"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
+	if usesRobotFailoverIP(service) {
+		ip, err := robotFailoverIP(service)
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", op, err)
+		}
+		return &corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: ip}}}, true, nil
+	}
+	if usesFloatingIP(service) {
+		if l.floatingIPs == nil {
+			return nil, false, fmt.Errorf("%s: Floating IPs are not initialized", op)
+		}
+		status, err := l.floatingIPs.get(ctx, service)
+		if errors.Is(err, hcops.ErrNotFound) {
+			return nil, false, nil
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("%s: %w", op, err)
+		}
+		return status, true, nil
+	}
+
 	lb, err := l.lbOps.GetByK8SServiceUID(ctx, service)
 	if err != nil {
 		if errors.Is(err, hcops.ErrNotFound) {
@@ -97,18 +340,53 @@ func (l *loadBalancers) GetLoadBalancerName(_ context.Context, _ string, service
 
 func (l *loadBalancers) EnsureLoadBalancer(
 	ctx context.Context, clusterName string, service *corev1.Service, nodes []*corev1.Node,
-) (*corev1.LoadBalancerStatus, error) {
+) (_ *corev1.LoadBalancerStatus, err error) {
 	const op = "hcloud/loadBalancers.EnsureLoadBalancer"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
+	if usesRobotFailoverIP(service) {
+		status, err := l.routeRobotFailoverIP(service)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := l.deleteReplacedLoadBalancer(ctx, service, status); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return status, nil
+	}
+	if usesFloatingIP(service) {
+		status, err := l.assignFloatingIP(ctx, service)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := l.deleteReplacedLoadBalancer(ctx, service, status); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return status, nil
+	}
+	if !l.managesClass(service) {
+		return nil, cloudprovider.ImplementedElsewhere
+	}
+
 	var (
 		reload        bool
 		lb            *hcloud.LoadBalancer
-		err           error
 		selectedNodes []*corev1.Node
 	)
 
-	selectedNodes, err = matchNodeSelector(service, nodes)
+	dryRun, err := l.getDryRun(service)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		if dryRun && err == nil {
+			l.reportStatus(ctx, service, dryRunCondition())
+			return
+		}
+		l.reportStatus(ctx, service, readyCondition(err))
+	}()
+
+	selectedNodes, keepTargets, err := l.selectNodes(service, nodes)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -124,6 +402,24 @@ func (l *loadBalancers) EnsureLoadBalancer(
 		return nil, fmt.Errorf("%s: %v", op, err)
 	}
 
+	// Try the load balancer's ID annotation if we were not able to find it
+	// using the service UID. This allows to move Services to other namespaces
+	// or clusters without losing the IPs of their load balancers.
+	var (
+		adopted       bool
+		previousOwner string
+	)
+	if errors.Is(err, hcops.ErrNotFound) {
+		lb, err = l.getByIDAnnotation(ctx, service)
+		if err != nil && !errors.Is(err, hcops.ErrNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if err == nil {
+			adopted = true
+			previousOwner = lb.Labels[hcops.LabelServiceUID]
+		}
+	}
+
 	// Try the load balancer's name if we were not able to find it using the
 	// service UID. This is required for two reasons:
 	//
@@ -140,6 +436,17 @@ func (l *loadBalancers) EnsureLoadBalancer(
 		}
 	}
 
+	if dryRun {
+		if errors.Is(err, hcops.ErrNotFound) {
+			lb = nil
+		}
+		if err := l.recordPlan(ctx, lbName, lb, service, selectedNodes, keepTargets); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		// Nothing was changed, so the status stays the same.
+		return service.Status.LoadBalancer.DeepCopy(), nil
+	}
+
 	// If we were still not able to find the load balancer we create it.
 	if errors.Is(err, hcops.ErrNotFound) {
 		lb, err = l.lbOps.Create(ctx, lbName, service)
@@ -154,13 +461,25 @@ func (l *loadBalancers) EnsureLoadBalancer(
 	}
 	reload = reload || lbChanged
 
+	if adopted && previousOwner != "" {
+		l.recorder.Eventf(service, corev1.EventTypeNormal, eventLoadBalancerAdopted,
+			"Took over Load Balancer %d from Service with UID %s", lb.ID, previousOwner)
+	} else if adopted {
+		l.recorder.Eventf(service, corev1.EventTypeNormal, eventLoadBalancerAdopted, "Adopted Load Balancer %d", lb.ID)
+	}
+
+	l.servicesMu.Lock()
 	servicesChanged, err := l.lbOps.ReconcileHCLBServices(ctx, lb, service)
-	if err != nil {
+	l.servicesMu.Unlock()
+	// The Load Balancer is reconciled completely while the certificate is
+	// pending. The Service is requeued afterwards.
+	certPending := errors.Is(err, hcops.ErrCertificatePending)
+	if err != nil && !certPending {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 	reload = reload || servicesChanged
 
-	targetsChanged, err := l.lbOps.ReconcileHCLBTargets(ctx, lb, service, selectedNodes)
+	targetsChanged, err := l.reconcileTargets(ctx, lb, service, selectedNodes, keepTargets)
 	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
@@ -177,9 +496,214 @@ func (l *loadBalancers) EnsureLoadBalancer(
 	if err := annotation.LBToService(service, lb); err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
+	if certPending {
+		return nil, api.NewRetryError(
+			fmt.Sprintf("%s: managed certificate for the changed domains is not issued yet", op),
+			managedCertificateRetryInterval)
+	}
 	return l.getLBStatus(lb, service, op)
 }
 
+// reconciledLoadBalancer returns the Load Balancer of svc to the controllers
+// which reconcile parts of it outside of the service controller. It returns
+// nil if the Load Balancer does not exist, as it is only created by the
+// service controller or by loadBalancerClasses, and in dry-run mode, as only
+// the service controller records the planned changes.
+func (l *loadBalancers) reconciledLoadBalancer(ctx context.Context, svc *corev1.Service) (*hcloud.LoadBalancer, error) {
+	dryRun, err := l.getDryRun(svc)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return nil, nil
+	}
+	lb, err := l.lbOps.GetByK8SServiceUID(ctx, svc)
+	if errors.Is(err, hcops.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return lb, nil
+}
+
+// getByIDAnnotation returns the Load Balancer referenced by the ID annotation
+// of svc. It returns a wrapped hcops.ErrNotFound if the annotation is not set
+// or the Load Balancer does not exist.
+//
+// Load Balancers owned by another Service of the cluster are not adopted.
+// Load Balancers without owner, or owned by a Service which is not in the
+// cluster, are only adopted if they have the labelAdoptable. The owner may
+// have been moved to another namespace or cluster, or it may still run in
+// another cluster.
+func (l *loadBalancers) getByIDAnnotation(ctx context.Context, svc *corev1.Service) (*hcloud.LoadBalancer, error) {
+	const op = "hcloud/loadBalancers.getByIDAnnotation"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	id, err := annotation.LBID.IntFromService(svc)
+	if errors.Is(err, annotation.ErrNotSet) {
+		return nil, fmt.Errorf("%s: %w", op, hcops.ErrNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	lb, err := l.lbOps.GetByID(ctx, int64(id))
+	if errors.Is(err, hcops.ErrNotFound) {
+		klog.InfoS("Load Balancer of ID annotation not found", "op", op, "service", svc.Name, "loadBalancerID", id)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	owner := lb.Labels[hcops.LabelServiceUID]
+	if owner == string(svc.UID) {
+		return lb, nil
+	}
+	if owner == "" {
+		if _, ok := lb.Labels[labelAdoptable]; !ok {
+			return nil, fmt.Errorf("%s: Load Balancer %d has no owner and is not labeled %s", op, lb.ID, labelAdoptable)
+		}
+		return lb, nil
+	}
+	if l.serviceLister == nil {
+		return nil, fmt.Errorf("%s: Load Balancer %d is owned by Service with UID %s", op, lb.ID, owner)
+	}
+	services, err := l.serviceLister.List(labels.Everything())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	for _, s := range services {
+		if string(s.UID) == owner {
+			return nil, fmt.Errorf("%s: Load Balancer %d is owned by Service %s/%s", op, lb.ID, s.Namespace, s.Name)
+		}
+	}
+	// The owner may still exist in another cluster, which would keep
+	// reconciling the Load Balancer.
+	if _, ok := lb.Labels[labelAdoptable]; !ok {
+		return nil, fmt.Errorf("%s: Load Balancer %d is owned by Service with UID %s, which is not in this cluster, and is not labeled %s",
+			op, lb.ID, owner, labelAdoptable)
+	}
+	return lb, nil
+}
+
+// recordPlan records the changes required to reconcile lb with svc as event
+// on svc. lb is nil if the Load Balancer lbName does not exist yet.
+func (l *loadBalancers) recordPlan(
+	ctx context.Context, lbName string, lb *hcloud.LoadBalancer, svc *corev1.Service, nodes []*corev1.Node, keepTargets bool,
+) error {
+	const op = "hcloud/loadBalancers.recordPlan"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	if keepTargets {
+		nodes = nil
+	}
+	plan, err := l.lbOps.PlanHCLB(ctx, lbName, lb, svc, nodes)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if plan.Empty() {
+		l.recorder.Event(svc, corev1.EventTypeNormal, eventPlannedChanges, "Dry run: no changes planned")
+		return nil
+	}
+	klog.InfoS("planned Load Balancer changes", "op", op, "service", svc.Name, "plan", plan.String())
+	l.recorder.Eventf(svc, corev1.EventTypeNormal, eventPlannedChanges, "Dry run: %s", plan)
+	return nil
+}
+
+func (l *loadBalancers) getDryRun(svc *corev1.Service) (bool, error) {
+	dryRun, err := annotation.LBDryRun.BoolFromService(svc)
+	if err == nil {
+		return dryRun, nil
+	}
+	if errors.Is(err, annotation.ErrNotSet) {
+		return l.dryRunDefault, nil
+	}
+	return false, err
+}
+
+// readyCondition returns the conditionLoadBalancerReady condition for the
+// result err of reconciling a Load Balancer.
+func readyCondition(err error) metav1.Condition {
+	if err != nil {
+		return metav1.Condition{
+			Type:    conditionLoadBalancerReady,
+			Status:  metav1.ConditionFalse,
+			Reason:  conditionReason(err),
+			Message: err.Error(),
+		}
+	}
+	return metav1.Condition{
+		Type:    conditionLoadBalancerReady,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Reconciled",
+		Message: "Load Balancer is reconciled",
+	}
+}
+
+// dryRunCondition returns the conditionLoadBalancerReady condition of a Load
+// Balancer in dry-run mode.
+func dryRunCondition() metav1.Condition {
+	return metav1.Condition{
+		Type:    conditionLoadBalancerReady,
+		Status:  metav1.ConditionUnknown,
+		Reason:  "DryRun",
+		Message: "Load Balancer is not reconciled in dry-run mode, the planned changes are recorded as events",
+	}
+}
+
+// reportStatus sets the conditionLoadBalancerReady condition of svc to cond.
+func (l *loadBalancers) reportStatus(ctx context.Context, svc *corev1.Service, cond metav1.Condition) {
+	const op = "hcloud/loadBalancers.reportStatus"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	if l.serviceClient == nil {
+		return
+	}
+	cond.ObservedGeneration = svc.Generation
+
+	conditions := slices.Clone(svc.Status.Conditions)
+	if !meta.SetStatusCondition(&conditions, cond) {
+		return
+	}
+	// Only the condition is patched, it is merged with the other conditions by
+	// its type.
+	patch, err := json.Marshal(map[string]any{
+		"status": map[string]any{
+			"conditions": []metav1.Condition{*meta.FindStatusCondition(conditions, conditionLoadBalancerReady)},
+		},
+	})
+	if err != nil {
+		klog.ErrorS(err, "marshal condition patch", "op", op, "service", svc.Name)
+		return
+	}
+	_, err = l.serviceClient.Services(svc.Namespace).Patch(ctx, svc.Name, types.StrategicMergePatchType, patch, metav1.PatchOptions{}, "status")
+	if err != nil {
+		klog.ErrorS(err, "patch condition of service", "op", op, "service", svc.Name, "condition", conditionLoadBalancerReady)
+		return
+	}
+	svc.Status.Conditions = conditions
+}
+
+// conditionReason returns the reason of the failed conditionLoadBalancerReady
+// condition. Errors of the Hetzner Cloud API are reported by their code, for
+// example "resource_limit_exceeded" as ResourceLimitExceeded.
+func conditionReason(err error) string {
+	var hcErr hcloud.Error
+	if !errors.As(err, &hcErr) || hcErr.Code == "" {
+		return "ReconcileFailed"
+	}
+	var reason strings.Builder
+	for _, word := range strings.Split(string(hcErr.Code), "_") {
+		if word == "" {
+			continue
+		}
+		reason.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return reason.String()
+}
+
 func (l *loadBalancers) getLBStatus(lb *hcloud.LoadBalancer, service *corev1.Service, op string) (*corev1.LoadBalancerStatus, error) {
 	// Start from upstream hcloud ccm (proxyProtocolEnabled)
 	// In upstream hcloud ccm, this code is in buildLoadBalancerStatusIngress()
@@ -206,8 +730,8 @@ func (l *loadBalancers) getLBStatus(lb *hcloud.LoadBalancer, service *corev1.Ser
 
 	var ingress []corev1.LoadBalancerIngress
 
-	disablePubNet, err := annotation.LBDisablePublicNetwork.BoolFromService(service)
-	if err != nil && !errors.Is(err, annotation.ErrNotSet) {
+	disablePubNet, err := l.getDisablePublicNetwork(service)
+	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -251,11 +775,26 @@ func (l *loadBalancers) getDisablePrivateIngress(svc *corev1.Service) (bool, err
 		return disable, nil
 	}
 	if errors.Is(err, annotation.ErrNotSet) {
+		if defaults, ok := l.getClassDefaults(svc); ok {
+			return defaults.DisablePrivateIngress, nil
+		}
 		return l.disablePrivateIngressDefault, nil
 	}
 	return false, err
 }
 
+func (l *loadBalancers) getDisablePublicNetwork(svc *corev1.Service) (bool, error) {
+	disable, err := annotation.LBDisablePublicNetwork.BoolFromService(svc)
+	if err == nil {
+		return disable, nil
+	}
+	if errors.Is(err, annotation.ErrNotSet) {
+		defaults, _ := l.getClassDefaults(svc)
+		return defaults.DisablePublicNetwork, nil
+	}
+	return false, err
+}
+
 func (l *loadBalancers) getProxyProtocolEnabled(svc *corev1.Service) (bool, error) {
 	enable, err := annotation.LBSvcProxyProtocol.BoolFromService(svc)
 	if err == nil {
@@ -273,6 +812,9 @@ func (l *loadBalancers) getDisableIPv6(svc *corev1.Service) (bool, error) {
 		return disable, nil
 	}
 	if errors.Is(err, annotation.ErrNotSet) {
+		if defaults, ok := l.getClassDefaults(svc); ok {
+			return defaults.DisableIPv6, nil
+		}
 		return l.disableIPv6Default, nil
 	}
 	return false, err
@@ -280,17 +822,55 @@ func (l *loadBalancers) getDisableIPv6(svc *corev1.Service) (bool, error) {
 
 func (l *loadBalancers) UpdateLoadBalancer(
 	ctx context.Context, clusterName string, svc *corev1.Service, nodes []*corev1.Node,
-) error {
+) (err error) {
 	const op = "hcloud/loadBalancers.UpdateLoadBalancer"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
+	if usesRobotFailoverIP(svc) {
+		status, err := l.routeRobotFailoverIP(svc)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if err := l.deleteReplacedLoadBalancer(ctx, svc, status); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	}
+	if usesFloatingIP(svc) {
+		status, err := l.assignFloatingIP(ctx, svc)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if err := l.deleteReplacedLoadBalancer(ctx, svc, status); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	}
+	if !l.managesClass(svc) {
+		return cloudprovider.ImplementedElsewhere
+	}
+
 	var (
 		lb            *hcloud.LoadBalancer
-		err           error
 		selectedNodes []*corev1.Node
 	)
 
-	selectedNodes, err = matchNodeSelector(svc, nodes)
+	dryRun, err := l.getDryRun(svc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	defer func() {
+		switch {
+		case lb == nil && err == nil:
+			// Nothing was reconciled if the Load Balancer does not exist.
+		case dryRun && err == nil:
+			l.reportStatus(ctx, svc, dryRunCondition())
+		default:
+			l.reportStatus(ctx, svc, readyCondition(err))
+		}
+	}()
+
+	selectedNodes, keepTargets, err := l.selectNodes(svc, nodes)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
@@ -315,14 +895,26 @@ func (l *loadBalancers) UpdateLoadBalancer(
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	if dryRun {
+		lbName := l.GetLoadBalancerName(ctx, clusterName, svc)
+		if err := l.recordPlan(ctx, lbName, lb, svc, selectedNodes, keepTargets); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	}
+
 	if _, err = l.lbOps.ReconcileHCLB(ctx, lb, svc); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if _, err = l.lbOps.ReconcileHCLBTargets(ctx, lb, svc, selectedNodes); err != nil {
+	_, err = l.reconcileTargets(ctx, lb, svc, selectedNodes, keepTargets)
+	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	if _, err = l.lbOps.ReconcileHCLBServices(ctx, lb, svc); err != nil {
+	// EnsureLoadBalancer requeues the Service while the managed certificate
+	// is pending.
+	_, err = l.lbOps.ReconcileHCLBServices(ctx, lb, svc)
+	if err != nil && !errors.Is(err, hcops.ErrCertificatePending) {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 	return nil
@@ -332,21 +924,53 @@ func (l *loadBalancers) EnsureLoadBalancerDeleted(ctx context.Context, _ string,
 	const op = "hcloud/loadBalancers.EnsureLoadBalancerDeleted"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
+	// Failover IPs and Floating IPs remain routed to their last node, but a
+	// Load Balancer the Service used before may still be left over.
+	if usesRobotFailoverIP(service) || usesFloatingIP(service) {
+		if err := l.deleteReplacedLoadBalancer(ctx, service, nil); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	}
+
 	loadBalancer, err := l.lbOps.GetByK8SServiceUID(ctx, service)
 	if errors.Is(err, hcops.ErrNotFound) {
-		return nil
+		return l.deleteCertificates(ctx, service)
 	}
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if loadBalancer.Protection.Delete {
-		klog.InfoS("ignored: load balancer deletion protected", "op", op, "loadBalancerID", loadBalancer.ID)
+	deleted, err := l.deleteLoadBalancer(ctx, loadBalancer)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !deleted {
 		return nil
 	}
 
-	klog.InfoS("delete Load Balancer", "op", op, "loadBalancerID", loadBalancer.ID)
-	err = l.lbOps.Delete(ctx, loadBalancer)
+	l.targetsMu.Lock()
+	delete(l.appliedTargets, service.UID)
+	l.targetsMu.Unlock()
+	return l.deleteCertificates(ctx, service)
+}
+
+// deleteReplacedLoadBalancer deletes the Load Balancer of service and its
+// certificates, if service used a Load Balancer before it was switched to a
+// Failover IP or Floating IP. Once service reports status, the Load Balancer
+// was deleted before the status was written, and the lookup is skipped. A nil
+// status always looks the Load Balancer up.
+func (l *loadBalancers) deleteReplacedLoadBalancer(
+	ctx context.Context, service *corev1.Service, status *corev1.LoadBalancerStatus,
+) error {
+	const op = "hcloud/loadBalancers.deleteReplacedLoadBalancer"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	if status != nil && equalIngress(service.Status.LoadBalancer.Ingress, status.Ingress) {
+		return nil
+	}
+
+	loadBalancer, err := l.lbOps.GetByK8SServiceUID(ctx, service)
 	if errors.Is(err, hcops.ErrNotFound) {
 		return nil
 	}
@@ -354,5 +978,77 @@ func (l *loadBalancers) EnsureLoadBalancerDeleted(ctx context.Context, _ string,
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	klog.InfoS("Load Balancer replaced by IP", "op", op, "service", service.Name, "loadBalancerID", loadBalancer.ID)
+	deleted, err := l.deleteLoadBalancer(ctx, loadBalancer)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !deleted {
+		return nil
+	}
+	if err := l.deleteCertificates(ctx, service); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// deleteLoadBalancer deletes loadBalancer, unless it is protected against
+// deletion. It reports whether loadBalancer is gone.
+func (l *loadBalancers) deleteLoadBalancer(ctx context.Context, loadBalancer *hcloud.LoadBalancer) (bool, error) {
+	const op = "hcloud/loadBalancers.deleteLoadBalancer"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	if loadBalancer.Protection.Delete {
+		klog.InfoS("ignored: load balancer deletion protected", "op", op, "loadBalancerID", loadBalancer.ID)
+		return false, nil
+	}
+
+	klog.InfoS("delete Load Balancer", "op", op, "loadBalancerID", loadBalancer.ID)
+	err := l.lbOps.Delete(ctx, loadBalancer)
+	if err != nil && !errors.Is(err, hcops.ErrNotFound) {
+		return false, err
+	}
+	return true, nil
+}
+
+// routeRobotFailoverIP routes the failover IP of svc to one of its nodes.
+func (l *loadBalancers) routeRobotFailoverIP(svc *corev1.Service) (*corev1.LoadBalancerStatus, error) {
+	const op = "hcloud/loadBalancers.routeRobotFailoverIP"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	if l.robotFailoverIPs == nil {
+		return nil, fmt.Errorf("%s: failover IPs require Robot credentials", op)
+	}
+	status, err := l.robotFailoverIPs.route(svc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return status, nil
+}
+
+// assignFloatingIP assigns the Floating IP of svc to one of its nodes.
+func (l *loadBalancers) assignFloatingIP(ctx context.Context, svc *corev1.Service) (*corev1.LoadBalancerStatus, error) {
+	const op = "hcloud/loadBalancers.assignFloatingIP"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	if l.floatingIPs == nil {
+		return nil, fmt.Errorf("%s: Floating IPs are not initialized", op)
+	}
+	status, err := l.floatingIPs.assign(ctx, svc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return status, nil
+}
+
+// deleteCertificates deletes the certificates the CCM created for service
+// once its Load Balancer is gone.
+func (l *loadBalancers) deleteCertificates(ctx context.Context, service *corev1.Service) error {
+	const op = "hcloud/loadBalancers.deleteCertificates"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	if err := l.lbOps.DeleteCertificates(ctx, service.UID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
 	return nil
 }
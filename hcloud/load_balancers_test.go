@@ -1,18 +1,29 @@
 package hcloud
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net"
 	"reflect"
 	"testing"
 
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/annotation"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/mocks"
+	"github.com/syself/hrobot-go/models"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/cloud-provider/api"
 )
 
 func newNodeSelectorNode(name string, labels map[string]string) *corev1.Node {
@@ -617,6 +628,54 @@ func TestLoadBalancer_EnsureLoadBalancer_UpdateLoadBalancer(t *testing.T) {
 				assert.NoError(t, err)
 			},
 		},
+		{
+			Name:       "requeue while managed certificate is pending",
+			ServiceUID: "10",
+			LB: &hcloud.LoadBalancer{
+				ID:               10,
+				LoadBalancerType: &hcloud.LoadBalancerType{Name: "lb11"},
+				Location:         &hcloud.Location{Name: "nbg1", NetworkZone: hcloud.NetworkZoneEUCentral},
+			},
+			Mock: func(_ *testing.T, tt *LoadBalancerTestCase) {
+				tt.LBOps.On("GetByK8SServiceUID", tt.Ctx, tt.Service).Return(tt.LB, nil)
+				tt.LBOps.On("ReconcileHCLB", tt.Ctx, tt.LB, tt.Service).Return(false, nil)
+				tt.LBOps.
+					On("ReconcileHCLBServices", tt.Ctx, tt.LB, tt.Service).
+					Return(false, fmt.Errorf("test: %w", hcops.ErrCertificatePending))
+				tt.LBOps.On("ReconcileHCLBTargets", tt.Ctx, tt.LB, tt.Service, tt.Nodes).Return(false, nil)
+			},
+			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
+				_, err := tt.LoadBalancers.EnsureLoadBalancer(tt.Ctx, tt.ClusterName, tt.Service, tt.Nodes)
+				var retryErr *api.RetryError
+				if assert.ErrorAs(t, err, &retryErr) {
+					assert.Equal(t, managedCertificateRetryInterval, retryErr.RetryAfter())
+				}
+				// The targets are reconciled while the certificate is pending.
+				tt.LBOps.AssertCalled(t, "ReconcileHCLBTargets", tt.Ctx, tt.LB, tt.Service, tt.Nodes)
+			},
+		},
+		{
+			Name:       "back off if managed certificate failed",
+			ServiceUID: "11",
+			LB: &hcloud.LoadBalancer{
+				ID:               11,
+				LoadBalancerType: &hcloud.LoadBalancerType{Name: "lb11"},
+				Location:         &hcloud.Location{Name: "nbg1", NetworkZone: hcloud.NetworkZoneEUCentral},
+			},
+			Mock: func(_ *testing.T, tt *LoadBalancerTestCase) {
+				tt.LBOps.On("GetByK8SServiceUID", tt.Ctx, tt.Service).Return(tt.LB, nil)
+				tt.LBOps.On("ReconcileHCLB", tt.Ctx, tt.LB, tt.Service).Return(false, nil)
+				tt.LBOps.
+					On("ReconcileHCLBServices", tt.Ctx, tt.LB, tt.Service).
+					Return(false, fmt.Errorf("test: %w", hcops.ErrCertificateFailed))
+			},
+			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
+				_, err := tt.LoadBalancers.EnsureLoadBalancer(tt.Ctx, tt.ClusterName, tt.Service, tt.Nodes)
+				assert.ErrorIs(t, err, hcops.ErrCertificateFailed)
+				var retryErr *api.RetryError
+				assert.False(t, errors.As(err, &retryErr))
+			},
+		},
 		{
 			Name:       "fall back to load balancer name",
 			ServiceUID: "5",
@@ -642,6 +701,134 @@ func TestLoadBalancer_EnsureLoadBalancer_UpdateLoadBalancer(t *testing.T) {
 				assert.NoError(t, err)
 			},
 		},
+		{
+			Name:       "adopt load balancer by ID",
+			ServiceUID: "6",
+			ServiceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBID: 6,
+			},
+			LB: &hcloud.LoadBalancer{
+				ID:               6,
+				Labels:           map[string]string{labelAdoptable: ""},
+				LoadBalancerType: &hcloud.LoadBalancerType{Name: "lb11"},
+				Location:         &hcloud.Location{Name: "nbg1", NetworkZone: hcloud.NetworkZoneEUCentral},
+			},
+			Mock: func(_ *testing.T, tt *LoadBalancerTestCase) {
+				tt.LBOps.On("GetByK8SServiceUID", tt.Ctx, tt.Service).Return(nil, hcops.ErrNotFound)
+				tt.LBOps.On("GetByID", tt.Ctx, tt.LB.ID).Return(tt.LB, nil)
+				tt.LBOps.On("ReconcileHCLB", tt.Ctx, tt.LB, tt.Service).Return(false, nil)
+				tt.LBOps.On("ReconcileHCLBTargets", tt.Ctx, tt.LB, tt.Service, tt.Nodes).Return(false, nil)
+				tt.LBOps.On("ReconcileHCLBServices", tt.Ctx, tt.LB, tt.Service).Return(false, nil)
+			},
+			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
+				_, err := tt.LoadBalancers.EnsureLoadBalancer(tt.Ctx, tt.ClusterName, tt.Service, tt.Nodes)
+				assert.NoError(t, err)
+				assert.Equal(t, "Normal LoadBalancerAdopted Adopted Load Balancer 6", <-tt.Recorder.Events)
+			},
+		},
+		{
+			Name:       "refuse to adopt load balancer without owner and label",
+			ServiceUID: "9",
+			ServiceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBID: 9,
+			},
+			LB: &hcloud.LoadBalancer{
+				ID:     9,
+				Labels: map[string]string{"env": "prod"},
+			},
+			Mock: func(_ *testing.T, tt *LoadBalancerTestCase) {
+				tt.LBOps.On("GetByK8SServiceUID", tt.Ctx, tt.Service).Return(nil, hcops.ErrNotFound)
+				tt.LBOps.On("GetByID", tt.Ctx, tt.LB.ID).Return(tt.LB, nil)
+			},
+			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
+				_, err := tt.LoadBalancers.EnsureLoadBalancer(tt.Ctx, tt.ClusterName, tt.Service, tt.Nodes)
+				assert.EqualError(t, err,
+					"hcloud/loadBalancers.EnsureLoadBalancer: hcloud/loadBalancers.getByIDAnnotation: "+
+						"Load Balancer 9 has no owner and is not labeled hcloud-ccm/adoptable")
+			},
+		},
+		{
+			Name:       "take over load balancer of deleted service",
+			ServiceUID: "7",
+			ServiceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBID: 7,
+			},
+			LB: &hcloud.LoadBalancer{
+				ID:               7,
+				Labels:           map[string]string{hcops.LabelServiceUID: "deleted", labelAdoptable: ""},
+				LoadBalancerType: &hcloud.LoadBalancerType{Name: "lb11"},
+				Location:         &hcloud.Location{Name: "nbg1", NetworkZone: hcloud.NetworkZoneEUCentral},
+			},
+			Mock: func(_ *testing.T, tt *LoadBalancerTestCase) {
+				tt.LBOps.On("GetByK8SServiceUID", tt.Ctx, tt.Service).Return(nil, hcops.ErrNotFound)
+				tt.LBOps.On("GetByID", tt.Ctx, tt.LB.ID).Return(tt.LB, nil)
+				tt.LBOps.On("ReconcileHCLB", tt.Ctx, tt.LB, tt.Service).Return(false, nil)
+				tt.LBOps.On("ReconcileHCLBTargets", tt.Ctx, tt.LB, tt.Service, tt.Nodes).Return(false, nil)
+				tt.LBOps.On("ReconcileHCLBServices", tt.Ctx, tt.LB, tt.Service).Return(false, nil)
+			},
+			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
+				tt.LoadBalancers.serviceLister = corelisters.NewServiceLister(newTestIndexer(t,
+					&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", UID: "other"}},
+				))
+
+				_, err := tt.LoadBalancers.EnsureLoadBalancer(tt.Ctx, tt.ClusterName, tt.Service, tt.Nodes)
+				assert.NoError(t, err)
+				assert.Equal(t,
+					"Normal LoadBalancerAdopted Took over Load Balancer 7 from Service with UID deleted",
+					<-tt.Recorder.Events)
+			},
+		},
+		{
+			Name:       "refuse to take over load balancer of service not in cluster",
+			ServiceUID: "10",
+			ServiceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBID: 10,
+			},
+			LB: &hcloud.LoadBalancer{
+				ID:     10,
+				Labels: map[string]string{hcops.LabelServiceUID: "remote"},
+			},
+			Mock: func(_ *testing.T, tt *LoadBalancerTestCase) {
+				tt.LBOps.On("GetByK8SServiceUID", tt.Ctx, tt.Service).Return(nil, hcops.ErrNotFound)
+				tt.LBOps.On("GetByID", tt.Ctx, tt.LB.ID).Return(tt.LB, nil)
+			},
+			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
+				tt.LoadBalancers.serviceLister = corelisters.NewServiceLister(newTestIndexer(t,
+					&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", UID: "other"}},
+				))
+
+				_, err := tt.LoadBalancers.EnsureLoadBalancer(tt.Ctx, tt.ClusterName, tt.Service, tt.Nodes)
+				assert.EqualError(t, err,
+					"hcloud/loadBalancers.EnsureLoadBalancer: hcloud/loadBalancers.getByIDAnnotation: "+
+						"Load Balancer 10 is owned by Service with UID remote, which is not in this cluster, "+
+						"and is not labeled hcloud-ccm/adoptable")
+			},
+		},
+		{
+			Name:       "refuse to adopt load balancer of other service",
+			ServiceUID: "8",
+			ServiceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBID: 8,
+			},
+			LB: &hcloud.LoadBalancer{
+				ID:     8,
+				Labels: map[string]string{hcops.LabelServiceUID: "other"},
+			},
+			Mock: func(_ *testing.T, tt *LoadBalancerTestCase) {
+				tt.LBOps.On("GetByK8SServiceUID", tt.Ctx, tt.Service).Return(nil, hcops.ErrNotFound)
+				tt.LBOps.On("GetByID", tt.Ctx, tt.LB.ID).Return(tt.LB, nil)
+			},
+			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
+				tt.LoadBalancers.serviceLister = corelisters.NewServiceLister(newTestIndexer(t,
+					&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", UID: "other"}},
+				))
+
+				_, err := tt.LoadBalancers.EnsureLoadBalancer(tt.Ctx, tt.ClusterName, tt.Service, tt.Nodes)
+				assert.EqualError(t, err,
+					"hcloud/loadBalancers.EnsureLoadBalancer: hcloud/loadBalancers.getByIDAnnotation: "+
+						"Load Balancer 8 is owned by Service default/other")
+			},
+		},
 	}
 
 	RunLoadBalancerTests(t, tests)
@@ -709,6 +896,237 @@ func TestLoadBalancer_UpdateLoadBalancer(t *testing.T) {
 				assert.NoError(t, err)
 			},
 		},
+		{
+			Name:          "dry run records plan",
+			ServiceUID:    "4",
+			DryRunDefault: true,
+			ServiceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBName: "test-lb",
+			},
+			LB: &hcloud.LoadBalancer{
+				ID:               4,
+				LoadBalancerType: &hcloud.LoadBalancerType{Name: "lb11"},
+			},
+			Mock: func(_ *testing.T, tt *LoadBalancerTestCase) {
+				plan := &hcops.LoadBalancerPlan{Changes: []hcops.LoadBalancerChange{
+					{Kind: hcops.ChangeType, From: "lb11", To: "lb21"},
+				}}
+				tt.LBOps.On("GetByK8SServiceUID", tt.Ctx, tt.Service).Return(tt.LB, nil)
+				tt.LBOps.On("PlanHCLB", tt.Ctx, "test-lb", tt.LB, tt.Service, tt.Nodes).Return(plan, nil)
+			},
+			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
+				err := tt.LoadBalancers.UpdateLoadBalancer(tt.Ctx, tt.ClusterName, tt.Service, tt.Nodes)
+				assert.NoError(t, err)
+				assert.Equal(t, `Normal PlannedChanges Dry run: ChangeType ("lb11" -> "lb21")`, <-tt.Recorder.Events)
+			},
+		},
+	}
+
+	RunLoadBalancerTests(t, tests)
+}
+
+func TestLoadBalancers_EnsureLoadBalancer_DryRun(t *testing.T) {
+	tests := []LoadBalancerTestCase{
+		{
+			Name:       "plan creation of Load Balancer",
+			ServiceUID: "1",
+			ServiceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBName:   "test-lb",
+				annotation.LBDryRun: true,
+			},
+			Mock: func(_ *testing.T, tt *LoadBalancerTestCase) {
+				plan := &hcops.LoadBalancerPlan{Changes: []hcops.LoadBalancerChange{
+					{Kind: hcops.ChangeCreateLoadBalancer, Subject: "test-lb"},
+				}}
+				tt.LBOps.On("GetByK8SServiceUID", tt.Ctx, tt.Service).Return(nil, hcops.ErrNotFound)
+				tt.LBOps.On("GetByName", tt.Ctx, "test-lb").Return(nil, hcops.ErrNotFound)
+				tt.LBOps.
+					On("PlanHCLB", tt.Ctx, "test-lb", (*hcloud.LoadBalancer)(nil), tt.Service, tt.Nodes).
+					Return(plan, nil)
+			},
+			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
+				status, err := tt.LoadBalancers.EnsureLoadBalancer(tt.Ctx, tt.ClusterName, tt.Service, tt.Nodes)
+				require.NoError(t, err)
+				assert.Equal(t, &corev1.LoadBalancerStatus{}, status)
+				assert.Equal(t, "Normal PlannedChanges Dry run: CreateLoadBalancer test-lb", <-tt.Recorder.Events)
+			},
+		},
+		{
+			Name:          "annotation overrides dry run default",
+			ServiceUID:    "2",
+			DryRunDefault: true,
+			ServiceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBDryRun: false,
+			},
+			LB: &hcloud.LoadBalancer{
+				ID:               2,
+				LoadBalancerType: &hcloud.LoadBalancerType{Name: "lb11"},
+				Location:         &hcloud.Location{Name: "nbg1", NetworkZone: hcloud.NetworkZoneEUCentral},
+				PublicNet: hcloud.LoadBalancerPublicNet{
+					Enabled: true,
+					IPv4:    hcloud.LoadBalancerPublicNetIPv4{IP: net.ParseIP("1.2.3.4")},
+					IPv6:    hcloud.LoadBalancerPublicNetIPv6{IP: net.ParseIP("fe80::1")},
+				},
+			},
+			Mock: func(_ *testing.T, tt *LoadBalancerTestCase) {
+				tt.LBOps.On("GetByK8SServiceUID", tt.Ctx, tt.Service).Return(tt.LB, nil)
+				tt.LBOps.On("ReconcileHCLB", tt.Ctx, tt.LB, tt.Service).Return(false, nil)
+				tt.LBOps.On("ReconcileHCLBServices", tt.Ctx, tt.LB, tt.Service).Return(false, nil)
+				tt.LBOps.On("ReconcileHCLBTargets", tt.Ctx, tt.LB, tt.Service, tt.Nodes).Return(false, nil)
+			},
+			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
+				_, err := tt.LoadBalancers.EnsureLoadBalancer(tt.Ctx, tt.ClusterName, tt.Service, tt.Nodes)
+				require.NoError(t, err)
+				assert.Empty(t, tt.Recorder.Events)
+			},
+		},
+	}
+
+	RunLoadBalancerTests(t, tests)
+}
+
+func TestLoadBalancers_DeleteLoadBalancerReplacedByIP(t *testing.T) {
+	newRobotFailoverIPs := func(t *testing.T) *robotFailoverIPs {
+		robotClient := &mocks.RobotClient{}
+		robotClient.Test(t)
+		robotClient.On("FailoverGet", "1.2.3.4").Return(&models.Failover{IP: "1.2.3.4"}, nil)
+		return &robotFailoverIPs{
+			robotClient:         robotClient,
+			nodeLister:          corelisters.NewNodeLister(newTestIndexer(t)),
+			endpointSliceLister: discoverylisters.NewEndpointSliceLister(newTestIndexer(t)),
+			recorder:            record.NewFakeRecorder(10),
+		}
+	}
+	newFloatingIPs := func(t *testing.T) *floatingIPs {
+		fip := &hcloud.FloatingIP{ID: 10, Name: "ingress", IP: net.ParseIP("1.2.3.4"), Type: hcloud.FloatingIPTypeIPv4}
+		fipClient := &mocks.FloatingIPClient{}
+		fipClient.Test(t)
+		fipClient.On("Get", mock.Anything, "ingress").Return(fip, nil, nil)
+		return &floatingIPs{
+			fipOps:              &hcops.FloatingIPOps{FloatingIPClient: fipClient},
+			nodeLister:          corelisters.NewNodeLister(newTestIndexer(t)),
+			endpointSliceLister: discoverylisters.NewEndpointSliceLister(newTestIndexer(t)),
+			recorder:            record.NewFakeRecorder(10),
+		}
+	}
+
+	tests := []LoadBalancerTestCase{
+		{
+			Name:       "delete load balancer when failover IP is added",
+			ServiceUID: "1",
+			ServiceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBRobotFailoverIP: "1.2.3.4",
+			},
+			LB: &hcloud.LoadBalancer{ID: 1},
+			Mock: func(_ *testing.T, tt *LoadBalancerTestCase) {
+				tt.Service.Spec.Type = corev1.ServiceTypeLoadBalancer
+				tt.LBOps.On("GetByK8SServiceUID", tt.Ctx, tt.Service).Return(tt.LB, nil)
+				tt.LBOps.On("Delete", tt.Ctx, tt.LB).Return(nil)
+				tt.LBOps.On("DeleteCertificates", tt.Ctx, tt.Service.UID).Return(nil)
+			},
+			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
+				tt.LoadBalancers.robotFailoverIPs = newRobotFailoverIPs(t)
+
+				status, err := tt.LoadBalancers.EnsureLoadBalancer(tt.Ctx, tt.ClusterName, tt.Service, tt.Nodes)
+				assert.NoError(t, err)
+				assert.Equal(t, []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}}, status.Ingress)
+			},
+		},
+		{
+			Name:       "delete load balancer when floating IP is added",
+			ServiceUID: "2",
+			ServiceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBFloatingIP: "ingress",
+			},
+			LB: &hcloud.LoadBalancer{ID: 2},
+			Mock: func(_ *testing.T, tt *LoadBalancerTestCase) {
+				tt.Service.Spec.Type = corev1.ServiceTypeLoadBalancer
+				tt.LBOps.On("GetByK8SServiceUID", tt.Ctx, tt.Service).Return(tt.LB, nil)
+				tt.LBOps.On("Delete", tt.Ctx, tt.LB).Return(nil)
+				tt.LBOps.On("DeleteCertificates", tt.Ctx, tt.Service.UID).Return(nil)
+			},
+			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
+				tt.LoadBalancers.floatingIPs = newFloatingIPs(t)
+
+				err := tt.LoadBalancers.UpdateLoadBalancer(tt.Ctx, tt.ClusterName, tt.Service, tt.Nodes)
+				assert.NoError(t, err)
+			},
+		},
+		{
+			Name:       "keep protected load balancer and its certificates",
+			ServiceUID: "3",
+			ServiceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBFloatingIP: "ingress",
+			},
+			LB: &hcloud.LoadBalancer{
+				ID:         3,
+				Protection: hcloud.LoadBalancerProtection{Delete: true},
+			},
+			Mock: func(_ *testing.T, tt *LoadBalancerTestCase) {
+				tt.Service.Spec.Type = corev1.ServiceTypeLoadBalancer
+				tt.LBOps.On("GetByK8SServiceUID", tt.Ctx, tt.Service).Return(tt.LB, nil)
+			},
+			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
+				tt.LoadBalancers.floatingIPs = newFloatingIPs(t)
+
+				_, err := tt.LoadBalancers.EnsureLoadBalancer(tt.Ctx, tt.ClusterName, tt.Service, tt.Nodes)
+				assert.NoError(t, err)
+			},
+		},
+		{
+			Name:       "no load balancer to delete",
+			ServiceUID: "4",
+			ServiceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBRobotFailoverIP: "1.2.3.4",
+			},
+			Mock: func(_ *testing.T, tt *LoadBalancerTestCase) {
+				tt.Service.Spec.Type = corev1.ServiceTypeLoadBalancer
+				tt.LBOps.On("GetByK8SServiceUID", tt.Ctx, tt.Service).Return(nil, hcops.ErrNotFound)
+			},
+			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
+				tt.LoadBalancers.robotFailoverIPs = newRobotFailoverIPs(t)
+
+				err := tt.LoadBalancers.UpdateLoadBalancer(tt.Ctx, tt.ClusterName, tt.Service, tt.Nodes)
+				assert.NoError(t, err)
+			},
+		},
+		{
+			Name:       "skip lookup once service reports IP",
+			ServiceUID: "5",
+			ServiceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBFloatingIP: "ingress",
+			},
+			Mock: func(_ *testing.T, tt *LoadBalancerTestCase) {
+				tt.Service.Spec.Type = corev1.ServiceTypeLoadBalancer
+				tt.Service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}}
+			},
+			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
+				tt.LoadBalancers.floatingIPs = newFloatingIPs(t)
+
+				err := tt.LoadBalancers.UpdateLoadBalancer(tt.Ctx, tt.ClusterName, tt.Service, tt.Nodes)
+				assert.NoError(t, err)
+				tt.LBOps.AssertNotCalled(t, "GetByK8SServiceUID", tt.Ctx, tt.Service)
+			},
+		},
+		{
+			Name:       "delete left over load balancer when service is deleted",
+			ServiceUID: "6",
+			ServiceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBRobotFailoverIP: "1.2.3.4",
+			},
+			LB: &hcloud.LoadBalancer{ID: 6},
+			Mock: func(_ *testing.T, tt *LoadBalancerTestCase) {
+				tt.Service.Spec.Type = corev1.ServiceTypeLoadBalancer
+				tt.Service.Status.LoadBalancer.Ingress = []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}}
+				tt.LBOps.On("GetByK8SServiceUID", tt.Ctx, tt.Service).Return(tt.LB, nil)
+				tt.LBOps.On("Delete", tt.Ctx, tt.LB).Return(nil)
+				tt.LBOps.On("DeleteCertificates", tt.Ctx, tt.Service.UID).Return(nil)
+			},
+			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
+				err := tt.LoadBalancers.EnsureLoadBalancerDeleted(tt.Ctx, tt.ClusterName, tt.Service)
+				assert.NoError(t, err)
+			},
+		},
 	}
 
 	RunLoadBalancerTests(t, tests)
@@ -730,6 +1148,9 @@ func TestLoadBalancers_EnsureLoadBalancerDeleted(t *testing.T) {
 				tt.LBOps.
 					On("Delete", tt.Ctx, tt.LB).
 					Return(nil)
+				tt.LBOps.
+					On("DeleteCertificates", tt.Ctx, tt.Service.UID).
+					Return(nil)
 			},
 			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
 				err := tt.LoadBalancers.EnsureLoadBalancerDeleted(tt.Ctx, tt.ClusterName, tt.Service)
@@ -743,6 +1164,9 @@ func TestLoadBalancers_EnsureLoadBalancerDeleted(t *testing.T) {
 				tt.LBOps.
 					On("GetByK8SServiceUID", tt.Ctx, tt.Service).
 					Return(nil, hcops.ErrNotFound)
+				tt.LBOps.
+					On("DeleteCertificates", tt.Ctx, tt.Service.UID).
+					Return(nil)
 			},
 			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
 				err := tt.LoadBalancers.EnsureLoadBalancerDeleted(tt.Ctx, tt.ClusterName, tt.Service)
@@ -763,6 +1187,9 @@ func TestLoadBalancers_EnsureLoadBalancerDeleted(t *testing.T) {
 				tt.LBOps.
 					On("Delete", tt.Ctx, tt.LB).
 					Return(hcops.ErrNotFound)
+				tt.LBOps.
+					On("DeleteCertificates", tt.Ctx, tt.Service.UID).
+					Return(nil)
 			},
 			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
 				err := tt.LoadBalancers.EnsureLoadBalancerDeleted(tt.Ctx, tt.ClusterName, tt.Service)
@@ -820,6 +1247,29 @@ func TestLoadBalancers_EnsureLoadBalancerDeleted(t *testing.T) {
 				assert.EqualError(t, err, "hcloud/loadBalancers.EnsureLoadBalancerDeleted: deletion error")
 			},
 		},
+		{
+			Name:       "certificate deletion fails",
+			ServiceUID: "7",
+			LB: &hcloud.LoadBalancer{
+				ID:   7,
+				Name: "delete me",
+			},
+			Mock: func(_ *testing.T, tt *LoadBalancerTestCase) {
+				tt.LBOps.
+					On("GetByK8SServiceUID", tt.Ctx, tt.Service).
+					Return(tt.LB, nil)
+				tt.LBOps.
+					On("Delete", tt.Ctx, tt.LB).
+					Return(nil)
+				tt.LBOps.
+					On("DeleteCertificates", tt.Ctx, tt.Service.UID).
+					Return(errors.New("certificate error"))
+			},
+			Perform: func(t *testing.T, tt *LoadBalancerTestCase) {
+				err := tt.LoadBalancers.EnsureLoadBalancerDeleted(tt.Ctx, tt.ClusterName, tt.Service)
+				assert.EqualError(t, err, "hcloud/loadBalancers.deleteCertificates: certificate error")
+			},
+		},
 	}
 
 	RunLoadBalancerTests(t, tests)
@@ -931,3 +1381,77 @@ func TestLoadBalancer_matchNodeSelector(t *testing.T) {
 		})
 	}
 }
+
+func TestLoadBalancers_ReportStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		conditions []metav1.Condition
+		expStatus  metav1.ConditionStatus
+		expReason  string
+		expPatched bool
+	}{
+		{
+			name:       "reconciled",
+			expStatus:  metav1.ConditionTrue,
+			expReason:  "Reconciled",
+			expPatched: true,
+		},
+		{
+			name:       "hcloud error",
+			err:        fmt.Errorf("op: %w", hcloud.Error{Code: hcloud.ErrorCodeResourceLimitExceeded, Message: "limit exceeded"}),
+			expStatus:  metav1.ConditionFalse,
+			expReason:  "ResourceLimitExceeded",
+			expPatched: true,
+		},
+		{
+			name:       "other error",
+			err:        errors.New("something failed"),
+			expStatus:  metav1.ConditionFalse,
+			expReason:  "ReconcileFailed",
+			expPatched: true,
+		},
+		{
+			name: "unchanged condition",
+			conditions: []metav1.Condition{{
+				Type:    conditionLoadBalancerReady,
+				Status:  metav1.ConditionTrue,
+				Reason:  "Reconciled",
+				Message: "Load Balancer is reconciled",
+			}},
+			expStatus: metav1.ConditionTrue,
+			expReason: "Reconciled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "svc", Namespace: "default"},
+				Status:     corev1.ServiceStatus{Conditions: tt.conditions},
+			}
+			client := fake.NewClientset(svc.DeepCopy())
+			l := &loadBalancers{serviceClient: client.CoreV1()}
+
+			l.reportStatus(context.Background(), svc, readyCondition(tt.err))
+
+			var patched bool
+			for _, action := range client.Actions() {
+				patched = patched || action.GetVerb() == "patch" && action.GetSubresource() == "status"
+			}
+			assert.Equal(t, tt.expPatched, patched)
+
+			cond := meta.FindStatusCondition(svc.Status.Conditions, conditionLoadBalancerReady)
+			require.NotNil(t, cond)
+			assert.Equal(t, tt.expStatus, cond.Status)
+			assert.Equal(t, tt.expReason, cond.Reason)
+
+			stored, err := client.CoreV1().Services("default").Get(context.Background(), "svc", metav1.GetOptions{})
+			require.NoError(t, err)
+			storedCond := meta.FindStatusCondition(stored.Status.Conditions, conditionLoadBalancerReady)
+			require.NotNil(t, storedCond)
+			assert.Equal(t, tt.expStatus, storedCond.Status)
+			assert.Equal(t, tt.expReason, storedCond.Reason)
+		})
+	}
+}
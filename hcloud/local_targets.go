@@ -0,0 +1,144 @@
+package hcloud
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/syself/hetzner-cloud-controller-manager/internal/metrics"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+// labelExcludeFromLB marks nodes which must not be targets of Load Balancers.
+// The service controller does not pass them to the Load Balancer.
+const labelExcludeFromLB = "node.kubernetes.io/exclude-from-external-load-balancers"
+
+// localTargets reconciles the targets of the Load Balancers of Services with
+// externalTrafficPolicy Local whenever their EndpointSlices change.
+//
+// Only the nodes running ready endpoints of such Services are targets. The
+// service controller reconciles the Load Balancers only if the Service or the
+// nodes change, not if the endpoints move to other nodes.
+type localTargets struct {
+	loadBalancers *loadBalancers
+	serviceLister corelisters.ServiceLister
+	nodeLister    corelisters.NodeLister
+
+	// queue holds the keys of the Services whose endpoints changed.
+	queue *serviceQueue
+}
+
+func newLocalTargets(loadBalancers *loadBalancers, informerFactory informers.SharedInformerFactory) (*localTargets, error) {
+	const op = "hcloud/newLocalTargets"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	endpointSlices := informerFactory.Discovery().V1().EndpointSlices()
+	loadBalancers.endpointSliceLister = endpointSlices.Lister()
+
+	lt := &localTargets{
+		loadBalancers: loadBalancers,
+		serviceLister: informerFactory.Core().V1().Services().Lister(),
+		nodeLister:    informerFactory.Core().V1().Nodes().Lister(),
+	}
+	lt.queue = newServiceQueue("local-targets", lt.reconcile)
+	_, err := endpointSlices.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    lt.enqueue,
+		UpdateFunc: func(_, obj interface{}) { lt.enqueue(obj) },
+		DeleteFunc: lt.enqueue,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return lt, nil
+}
+
+// enqueue adds the Service of the EndpointSlice obj to the queue.
+func (lt *localTargets) enqueue(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	slice, ok := obj.(*discoveryv1.EndpointSlice)
+	if !ok {
+		return
+	}
+	name := slice.Labels[discoveryv1.LabelServiceName]
+	if name == "" {
+		return
+	}
+	lt.queue.Add(slice.Namespace + "/" + name)
+}
+
+// run processes the queue until stop is closed.
+func (lt *localTargets) run(stop <-chan struct{}) {
+	lt.queue.run(stop)
+}
+
+// reconcile updates the targets of the Load Balancer of the Service key, if
+// it has the externalTrafficPolicy Local.
+func (lt *localTargets) reconcile(ctx context.Context, key string) error {
+	const op = "hcloud/localTargets.reconcile"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	svc, err := lt.serviceLister.Services(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer ||
+		svc.Spec.ExternalTrafficPolicy != corev1.ServiceExternalTrafficPolicyLocal ||
+		!lt.loadBalancers.managesClass(svc) {
+		return nil
+	}
+
+	nodes, err := lt.nodeLister.List(labels.Everything())
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	nodes = lbCandidateNodes(nodes)
+	selectedNodes, keepTargets, err := lt.loadBalancers.selectNodes(svc, nodes)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	// Most EndpointSlice changes do not move the endpoints to other nodes.
+	// The Load Balancer is only loaded if the targets change.
+	if keepTargets || lt.loadBalancers.targetsApplied(svc, selectedNodes) {
+		return nil
+	}
+
+	lb, err := lt.loadBalancers.reconciledLoadBalancer(ctx, svc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if lb == nil {
+		return nil
+	}
+
+	if _, err := lt.loadBalancers.reconcileTargets(ctx, lb, svc, selectedNodes, false); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// lbCandidateNodes returns the nodes which are neither excluded from Load
+// Balancers nor lack a provider ID, just like the service controller does.
+func lbCandidateNodes(nodes []*corev1.Node) []*corev1.Node {
+	selected := make([]*corev1.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if _, ok := n.Labels[labelExcludeFromLB]; ok || n.Spec.ProviderID == "" {
+			continue
+		}
+		selected = append(selected, n)
+	}
+	return selected
+}
@@ -0,0 +1,264 @@
+package hcloud
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestIndexer(t *testing.T, objs ...runtime.Object) cache.Indexer {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, obj := range objs {
+		require.NoError(t, indexer.Add(obj))
+	}
+	return indexer
+}
+
+func newTestEndpointSlice(name, svcName string, endpoints ...discoveryv1.Endpoint) *discoveryv1.EndpointSlice {
+	return &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			Labels:    map[string]string{discoveryv1.LabelServiceName: svcName},
+		},
+		Endpoints: endpoints,
+	}
+}
+
+func newTestEndpoint(nodeName string, ready *bool) discoveryv1.Endpoint {
+	return discoveryv1.Endpoint{
+		NodeName:   &nodeName,
+		Conditions: discoveryv1.EndpointConditions{Ready: ready},
+	}
+}
+
+func TestLoadBalancers_SelectNodes(t *testing.T) {
+	nodes := []*corev1.Node{
+		{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node2"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "node3"}},
+	}
+	endpointSlices := []runtime.Object{
+		newTestEndpointSlice("web-1", "web",
+			newTestEndpoint("node1", hcloud.Ptr(true)),
+			newTestEndpoint("node2", hcloud.Ptr(false)),
+		),
+		newTestEndpointSlice("web-2", "web", newTestEndpoint("node3", nil)),
+		newTestEndpointSlice("other-1", "other", newTestEndpoint("node2", hcloud.Ptr(true))),
+		newTestEndpointSlice("rollout-1", "rollout",
+			discoveryv1.Endpoint{
+				NodeName: hcloud.Ptr("node2"),
+				Conditions: discoveryv1.EndpointConditions{
+					Ready: hcloud.Ptr(false), Serving: hcloud.Ptr(true), Terminating: hcloud.Ptr(true),
+				},
+			},
+			discoveryv1.Endpoint{
+				NodeName: hcloud.Ptr("node3"),
+				Conditions: discoveryv1.EndpointConditions{
+					Ready: hcloud.Ptr(false), Serving: hcloud.Ptr(false), Terminating: hcloud.Ptr(true),
+				},
+			},
+		),
+		newTestEndpointSlice("idle-1", "idle", newTestEndpoint("node1", hcloud.Ptr(false))),
+	}
+
+	tests := []struct {
+		name     string
+		svcName  string
+		policy   corev1.ServiceExternalTrafficPolicy
+		lister   bool
+		expNodes []string
+		expKeep  bool
+	}{
+		{
+			name:     "all nodes of cluster services",
+			svcName:  "web",
+			policy:   corev1.ServiceExternalTrafficPolicyCluster,
+			lister:   true,
+			expNodes: []string{"node1", "node2", "node3"},
+		},
+		{
+			name:     "nodes with ready endpoints of local services",
+			svcName:  "web",
+			policy:   corev1.ServiceExternalTrafficPolicyLocal,
+			lister:   true,
+			expNodes: []string{"node1", "node3"},
+		},
+		{
+			name:     "nodes with terminating but serving endpoints",
+			svcName:  "rollout",
+			policy:   corev1.ServiceExternalTrafficPolicyLocal,
+			lister:   true,
+			expNodes: []string{"node2"},
+		},
+		{
+			name:     "keep targets without ready endpoints",
+			svcName:  "idle",
+			policy:   corev1.ServiceExternalTrafficPolicyLocal,
+			lister:   true,
+			expNodes: []string{},
+			expKeep:  true,
+		},
+		{
+			name:     "keep targets without endpoints",
+			svcName:  "scaled-to-zero",
+			policy:   corev1.ServiceExternalTrafficPolicyLocal,
+			lister:   true,
+			expNodes: []string{},
+			expKeep:  true,
+		},
+		{
+			name:     "all nodes before initialization",
+			svcName:  "web",
+			policy:   corev1.ServiceExternalTrafficPolicyLocal,
+			expNodes: []string{"node1", "node2", "node3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &loadBalancers{}
+			if tt.lister {
+				l.endpointSliceLister = discoverylisters.NewEndpointSliceLister(newTestIndexer(t, endpointSlices...))
+			}
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: tt.svcName, Namespace: "default"},
+				Spec:       corev1.ServiceSpec{ExternalTrafficPolicy: tt.policy},
+			}
+
+			selectedNodes, keep, err := l.selectNodes(svc, nodes)
+			require.NoError(t, err)
+			names := make([]string, len(selectedNodes))
+			for i, n := range selectedNodes {
+				names[i] = n.Name
+			}
+			assert.Equal(t, tt.expNodes, names)
+			assert.Equal(t, tt.expKeep, keep)
+		})
+	}
+}
+
+func TestLocalTargets_Reconcile(t *testing.T) {
+	nodes := []runtime.Object{
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}, Spec: corev1.NodeSpec{ProviderID: "hcloud://1"}},
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node2"}, Spec: corev1.NodeSpec{ProviderID: "hcloud://2"}},
+		&corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: "node3", Labels: map[string]string{labelExcludeFromLB: ""}},
+			Spec:       corev1.NodeSpec{ProviderID: "hcloud://3"},
+		},
+	}
+	endpointSlices := []runtime.Object{
+		newTestEndpointSlice("web-1", "web",
+			newTestEndpoint("node2", hcloud.Ptr(true)),
+			newTestEndpoint("node3", hcloud.Ptr(true)),
+		),
+		newTestEndpointSlice("idle-1", "idle", newTestEndpoint("node2", hcloud.Ptr(false))),
+	}
+	lb := &hcloud.LoadBalancer{ID: 1}
+	localService := func(name string) *corev1.Service {
+		return &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name + "-uid")},
+			Spec: corev1.ServiceSpec{
+				Type:                  corev1.ServiceTypeLoadBalancer,
+				ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyLocal,
+			},
+		}
+	}
+
+	tests := []struct {
+		name           string
+		svc            *corev1.Service
+		appliedTargets map[types.UID]string
+		lbErr          error
+		expLoad        bool
+		expReconcile   bool
+	}{
+		{
+			name:         "reconcile targets of local service",
+			svc:          localService("web"),
+			expLoad:      true,
+			expReconcile: true,
+		},
+		{
+			name:           "reconcile changed targets of local service",
+			svc:            localService("web"),
+			appliedTargets: map[types.UID]string{"web-uid": "node1,node2"},
+			expLoad:        true,
+			expReconcile:   true,
+		},
+		{
+			name:           "skip unchanged targets of local service",
+			svc:            localService("web"),
+			appliedTargets: map[types.UID]string{"web-uid": "node2"},
+		},
+		{
+			name: "keep targets of local service without ready endpoints",
+			svc:  localService("idle"),
+		},
+		{
+			name: "keep targets of local service without endpoints",
+			svc:  localService("scaled-to-zero"),
+		},
+		{
+			name: "ignore cluster service",
+			svc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+				Spec: corev1.ServiceSpec{
+					Type:                  corev1.ServiceTypeLoadBalancer,
+					ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyCluster,
+				},
+			},
+		},
+		{
+			name:    "ignore local service without Load Balancer",
+			svc:     localService("web"),
+			lbErr:   hcops.ErrNotFound,
+			expLoad: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			lbOps := &hcops.MockLoadBalancerOps{}
+			lbOps.Test(t)
+
+			if tt.expLoad {
+				lbOps.On("GetByK8SServiceUID", ctx, tt.svc).Return(lb, tt.lbErr)
+			}
+			// node3 runs an endpoint, but is excluded from Load Balancers.
+			expNodes := []*corev1.Node{nodes[1].(*corev1.Node)}
+			if tt.expReconcile {
+				lbOps.On("ReconcileHCLBTargets", ctx, lb, tt.svc, expNodes).Return(true, nil)
+			}
+
+			lt := &localTargets{
+				loadBalancers: &loadBalancers{
+					lbOps:               lbOps,
+					endpointSliceLister: discoverylisters.NewEndpointSliceLister(newTestIndexer(t, endpointSlices...)),
+					appliedTargets:      tt.appliedTargets,
+				},
+				serviceLister: corelisters.NewServiceLister(newTestIndexer(t, tt.svc)),
+				nodeLister:    corelisters.NewNodeLister(newTestIndexer(t, nodes...)),
+			}
+
+			require.NoError(t, lt.reconcile(ctx, "default/"+tt.svc.Name))
+			lbOps.AssertExpectations(t)
+			if tt.expReconcile {
+				assert.True(t, lt.loadBalancers.targetsApplied(tt.svc, expNodes))
+			}
+		})
+	}
+}
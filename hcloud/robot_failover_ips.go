@@ -0,0 +1,222 @@
+package hcloud
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/syself/hetzner-cloud-controller-manager/internal/annotation"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/metrics"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/providerid"
+	robotclient "github.com/syself/hetzner-cloud-controller-manager/internal/robot/client"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/informers"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+)
+
+// robotFailoverIPClass is the loadBalancerClass of Services exposed by a
+// Robot failover IP. The service controller ignores Services with a
+// loadBalancerClass, so robotFailoverIPs also reports their status.
+const robotFailoverIPClass = "hetzner.cloud/robot-failover-ip"
+
+// Reasons of the events recorded on Services exposed by a Robot failover IP.
+const (
+	eventFailoverIPRouted   = "FailoverIPRouted"
+	eventFailoverIPNoTarget = "FailoverIPNoTarget"
+)
+
+// robotFailoverIPs routes the Robot failover IPs of Services to Robot nodes
+// running a ready endpoint of the Services.
+//
+// The IPs are rerouted if their node becomes NotReady or no longer runs a
+// ready endpoint.
+type robotFailoverIPs struct {
+	robotClient         robotclient.FailoverClient
+	serviceLister       corelisters.ServiceLister
+	nodeLister          corelisters.NodeLister
+	endpointSliceLister discoverylisters.EndpointSliceLister
+	recorder            record.EventRecorder
+
+	// serviceClient is used to report the status of Services with the
+	// robotFailoverIPClass. The status of the other Services is reported by
+	// the service controller.
+	serviceClient corev1client.ServicesGetter
+
+	queue *serviceIPQueue
+
+	// mu serializes the routing by the service controller and by the queue.
+	mu sync.Mutex
+}
+
+func newRobotFailoverIPs(
+	robotClient robotclient.FailoverClient, recorder record.EventRecorder,
+	serviceClient corev1client.ServicesGetter, informerFactory informers.SharedInformerFactory,
+) (*robotFailoverIPs, error) {
+	const op = "hcloud/newRobotFailoverIPs"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	rf := &robotFailoverIPs{
+		robotClient:         robotClient,
+		serviceLister:       informerFactory.Core().V1().Services().Lister(),
+		nodeLister:          informerFactory.Core().V1().Nodes().Lister(),
+		endpointSliceLister: informerFactory.Discovery().V1().EndpointSlices().Lister(),
+		recorder:            recorder,
+		serviceClient:       serviceClient,
+	}
+
+	queue, err := newServiceIPQueue("robot-failover-ips", usesRobotFailoverIP, rf.reconcile, informerFactory)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	rf.queue = queue
+	return rf, nil
+}
+
+// usesRobotFailoverIP reports whether svc is exposed by a Robot failover IP,
+// either by its loadBalancerClass or by the LBRobotFailoverIP annotation.
+func usesRobotFailoverIP(svc *corev1.Service) bool {
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return false
+	}
+	if class := svc.Spec.LoadBalancerClass; class != nil {
+		return *class == robotFailoverIPClass
+	}
+	_, ok := annotation.LBRobotFailoverIP.StringFromService(svc)
+	return ok
+}
+
+// robotFailoverIP returns the failover IP of svc: the LBRobotFailoverIP
+// annotation or the loadBalancerIP of svc.
+func robotFailoverIP(svc *corev1.Service) (string, error) {
+	ip, ok := annotation.LBRobotFailoverIP.StringFromService(svc)
+	if !ok {
+		ip = svc.Spec.LoadBalancerIP
+	}
+	ip = strings.TrimSpace(ip)
+	if ip == "" {
+		return "", fmt.Errorf("no failover IP: set %s or spec.loadBalancerIP", annotation.LBRobotFailoverIP)
+	}
+	if net.ParseIP(ip) == nil {
+		return "", fmt.Errorf("invalid failover IP %q", ip)
+	}
+	return ip, nil
+}
+
+// run processes the queue until stop is closed.
+func (rf *robotFailoverIPs) run(stop <-chan struct{}) {
+	rf.queue.run(stop)
+}
+
+// reconcile routes the failover IP of the Service key.
+func (rf *robotFailoverIPs) reconcile(ctx context.Context, key string) error {
+	const op = "hcloud/robotFailoverIPs.reconcile"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	svc, err := rf.serviceLister.Services(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !usesRobotFailoverIP(svc) {
+		return nil
+	}
+
+	status, err := rf.route(svc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := reportServiceIPStatus(ctx, rf.serviceClient, svc, status); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+// route routes the failover IP of svc to a Ready Robot node running a ready
+// endpoint of svc, unless it is already routed to such a node.
+//
+// If there is no such node, the routing is left unchanged.
+func (rf *robotFailoverIPs) route(svc *corev1.Service) (*corev1.LoadBalancerStatus, error) {
+	const op = "hcloud/robotFailoverIPs.route"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	ip, err := robotFailoverIP(svc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	status := &corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: ip}}}
+
+	targets, err := serviceIPTargetNodes(rf.nodeLister, rf.endpointSliceLister, svc, false)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rf.mu.Lock()
+	defer rf.mu.Unlock()
+
+	failover, err := rf.robotClient.FailoverGet(ip)
+	if err != nil {
+		hcops.HandleRateLimitExceededError(rf.recorder, err, svc)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var (
+		target   *corev1.Node
+		targetIP string
+	)
+	for _, node := range targets {
+		serverIP, err := rf.serverIP(node)
+		if err != nil {
+			hcops.HandleRateLimitExceededError(rf.recorder, err, svc)
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if serverIP == failover.ActiveServerIP {
+			return status, nil
+		}
+		if target == nil {
+			target, targetIP = node, serverIP
+		}
+	}
+	if target == nil {
+		klog.InfoS("no target for failover IP", "op", op, "service", svc.Name, "ip", ip)
+		rf.recorder.Eventf(svc, corev1.EventTypeWarning, eventFailoverIPNoTarget,
+			"No Ready Robot node runs a ready endpoint, failover IP %s is not rerouted", ip)
+		return status, nil
+	}
+
+	klog.InfoS("route failover IP", "op", op, "service", svc.Name, "ip", ip, "node", target.Name)
+	if _, err := rf.robotClient.FailoverSet(ip, targetIP); err != nil {
+		hcops.HandleRateLimitExceededError(rf.recorder, err, svc)
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	rf.recorder.Eventf(svc, corev1.EventTypeNormal, eventFailoverIPRouted,
+		"Routed failover IP %s to node %s", ip, target.Name)
+	return status, nil
+}
+
+// serverIP returns the main IP of the Robot server of node.
+func (rf *robotFailoverIPs) serverIP(node *corev1.Node) (string, error) {
+	id, _, err := providerid.ToServerID(node.Spec.ProviderID)
+	if err != nil {
+		return "", err
+	}
+	server, err := rf.robotClient.ServerGet(int(id))
+	if err != nil {
+		return "", fmt.Errorf("node %s: %w", node.Name, err)
+	}
+	return server.ServerIP, nil
+}
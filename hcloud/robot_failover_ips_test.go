@@ -0,0 +1,149 @@
+package hcloud
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/annotation"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/mocks"
+	"github.com/syself/hrobot-go/models"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func TestUsesRobotFailoverIP(t *testing.T) {
+	tests := []struct {
+		name     string
+		svc      *corev1.Service
+		expected bool
+	}{
+		{
+			name: "annotation",
+			svc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{string(annotation.LBRobotFailoverIP): "1.2.3.4"},
+				},
+				Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer},
+			},
+			expected: true,
+		},
+		{
+			name: "load balancer class",
+			svc: &corev1.Service{
+				Spec: corev1.ServiceSpec{
+					Type:              corev1.ServiceTypeLoadBalancer,
+					LoadBalancerClass: hcloud.Ptr(robotFailoverIPClass),
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "annotation with other load balancer class",
+			svc: &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{string(annotation.LBRobotFailoverIP): "1.2.3.4"},
+				},
+				Spec: corev1.ServiceSpec{
+					Type:              corev1.ServiceTypeLoadBalancer,
+					LoadBalancerClass: hcloud.Ptr("metallb"),
+				},
+			},
+		},
+		{
+			name: "Hetzner Cloud Load Balancer",
+			svc:  &corev1.Service{Spec: corev1.ServiceSpec{Type: corev1.ServiceTypeLoadBalancer}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, usesRobotFailoverIP(tt.svc))
+		})
+	}
+}
+
+func TestRobotFailoverIPs_Reconcile(t *testing.T) {
+	tests := []struct {
+		name           string
+		nodes          []runtime.Object
+		activeServerIP string
+		expRoutedTo    string
+		expEvent       string
+	}{
+		{
+			name: "keep route to ready node with endpoint",
+			nodes: []runtime.Object{
+				newTestRobotNode("node1", 1, true),
+				newTestRobotNode("node2", 2, true),
+			},
+			activeServerIP: "10.0.0.2",
+		},
+		{
+			name: "reroute from NotReady node",
+			nodes: []runtime.Object{
+				newTestRobotNode("node1", 1, true),
+				newTestRobotNode("node2", 2, false),
+			},
+			activeServerIP: "10.0.0.2",
+			expRoutedTo:    "10.0.0.1",
+			expEvent:       "Normal FailoverIPRouted Routed failover IP 1.2.3.4 to node node1",
+		},
+		{
+			name: "reroute from node without ready endpoint",
+			nodes: []runtime.Object{
+				newTestRobotNode("node2", 2, true),
+				newTestRobotNode("node3", 3, true),
+			},
+			activeServerIP: "10.0.0.3",
+			expRoutedTo:    "10.0.0.2",
+			expEvent:       "Normal FailoverIPRouted Routed failover IP 1.2.3.4 to node node2",
+		},
+		{
+			name: "no target",
+			nodes: []runtime.Object{
+				newTestRobotNode("node1", 1, false),
+				newTestRobotNode("node3", 3, true),
+			},
+			activeServerIP: "10.0.0.3",
+			expEvent:       "Warning FailoverIPNoTarget No Ready Robot node runs a ready endpoint, failover IP 1.2.3.4 is not rerouted",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := newServiceIPTest(t, robotFailoverIPClass, tt.nodes...)
+
+			robotClient := &mocks.RobotClient{}
+			robotClient.Test(t)
+			for i := 1; i <= 3; i++ {
+				robotClient.On("ServerGet", i).
+					Return(&models.Server{ServerNumber: i, ServerIP: fmt.Sprintf("10.0.0.%d", i)}, nil).
+					Maybe()
+			}
+			robotClient.On("FailoverGet", "1.2.3.4").
+				Return(&models.Failover{IP: "1.2.3.4", ActiveServerIP: tt.activeServerIP}, nil)
+			if tt.expRoutedTo != "" {
+				robotClient.On("FailoverSet", "1.2.3.4", tt.expRoutedTo).
+					Return(&models.Failover{IP: "1.2.3.4", ActiveServerIP: tt.expRoutedTo}, nil)
+			}
+
+			rf := &robotFailoverIPs{
+				robotClient:         robotClient,
+				serviceLister:       st.serviceLister,
+				nodeLister:          st.nodeLister,
+				endpointSliceLister: st.endpointSliceLister,
+				recorder:            st.recorder,
+				serviceClient:       st.client.CoreV1(),
+			}
+
+			require.NoError(t, rf.reconcile(st.ctx, "default/web"))
+			robotClient.AssertExpectations(t)
+
+			st.assertReconciled(t, tt.expEvent)
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package hcloud
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"slices"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/config"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog/v2"
+)
+
+// Reasons of orphaned routes. Used in events and metrics.
+const (
+	routeOrphanedServerDeleted      = "ServerDeleted"
+	routeOrphanedOutsideClusterCIDR = "OutsideClusterCIDR"
+)
+
+// orphanedRoute is a route created by the controller, which is not needed
+// anymore.
+type orphanedRoute struct {
+	route  hcloud.NetworkRoute
+	reason string
+}
+
+// routeGC removes routes which were created by the controller, but point to
+// deleted servers or lie outside of the cluster CIDR.
+//
+// The route controller only deletes routes within the cluster CIDR. Routes of
+// deleted servers blackhole traffic as soon as their IP is reused.
+//
+// The routes created by the controller are the routes within the cluster
+// CIDRs recorded in the network labels, see routeOwnerLabel, and the routes
+// within the current cluster CIDR, if no other cluster recorded it. Without
+// the cluster CIDR of routes, no route is removed.
+type routeGC struct {
+	routes *routes
+	// dryRun only reports orphaned routes instead of deleting them.
+	dryRun   bool
+	interval time.Duration
+	recorder record.EventRecorder
+}
+
+func newRouteGC(routes *routes, cfg config.RouteConfiguration, eventBroadcaster record.EventBroadcaster) *routeGC {
+	const op = "hcloud/newRouteGC"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	if routes.clusterCIDR == nil {
+		klog.InfoS("the cluster CIDR is not configured, no orphaned routes are garbage collected", "op", op)
+	}
+	return &routeGC{
+		routes:   routes,
+		dryRun:   cfg.GC.DryRun,
+		interval: cfg.GC.Interval.Duration,
+		recorder: eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "hetzner-ccm-routes"}),
+	}
+}
+
+// run removes orphaned routes every interval until stop is closed.
+func (gc *routeGC) run(stop <-chan struct{}) {
+	ctx := wait.ContextForChannel(stop)
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := gc.collect(ctx); err != nil {
+			klog.ErrorS(err, "garbage collection of routes failed")
+		}
+	}, gc.interval)
+}
+
+// collect removes the orphaned routes of the network once.
+func (gc *routeGC) collect(ctx context.Context) error {
+	const op = "hcloud/routeGC.collect"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	clusterName := gc.routes.getClusterName()
+	if clusterName == "" {
+		// The route controller did not list the routes yet, so the owner of
+		// the routes is unknown.
+		return nil
+	}
+
+	network, err := gc.routes.getNetwork(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	orphans, err := gc.routes.orphanedRoutes(network, clusterName)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	found := map[string]int{routeOrphanedServerDeleted: 0, routeOrphanedOutsideClusterCIDR: 0}
+	for _, orphan := range orphans {
+		found[orphan.reason]++
+	}
+	for reason, n := range found {
+		metrics.RouteGCOrphaned.WithLabelValues(reason).Set(float64(n))
+	}
+
+	ref := &corev1.ObjectReference{Kind: "Network", Name: network.Name}
+	for _, orphan := range orphans {
+		destination, gateway := orphan.route.Destination, orphan.route.Gateway
+		if gc.dryRun {
+			gc.recorder.Eventf(ref, corev1.EventTypeWarning, "OrphanedRoute",
+				"Route %s via %s is orphaned (%s), not deleted in dry-run mode", destination, gateway, orphan.reason)
+			continue
+		}
+
+		if err := gc.routes.reconciler.delete(ctx, destination, gateway); err != nil {
+			gc.recorder.Eventf(ref, corev1.EventTypeWarning, "DeleteOrphanedRouteFailed",
+				"Deleting orphaned route %s via %s (%s) failed: %v", destination, gateway, orphan.reason, err)
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		metrics.RouteGCDeleted.WithLabelValues(orphan.reason).Inc()
+		gc.recorder.Eventf(ref, corev1.EventTypeNormal, "DeletedOrphanedRoute",
+			"Deleted orphaned route %s via %s (%s)", destination, gateway, orphan.reason)
+	}
+
+	if !gc.dryRun {
+		if err := gc.releaseClusterCIDRs(ctx, clusterName); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	return nil
+}
+
+// releaseClusterCIDRs removes the owner labels of the previous cluster CIDRs
+// of clusterName, which no route lies in anymore.
+func (gc *routeGC) releaseClusterCIDRs(ctx context.Context, clusterName string) error {
+	clusterCIDR := gc.routes.clusterCIDR
+	if clusterCIDR == nil {
+		return nil
+	}
+
+	gc.routes.invalidateNetwork()
+	network, err := gc.routes.getNetwork(ctx)
+	if err != nil {
+		return err
+	}
+	for _, cidr := range routeOwnerCIDRs(network, clusterName) {
+		if cidr.String() == clusterCIDR.String() {
+			continue
+		}
+		used := slices.ContainsFunc(network.Routes, func(route hcloud.NetworkRoute) bool {
+			return cidrContains(cidr, route.Destination)
+		})
+		if used {
+			continue
+		}
+		if err := gc.routes.setNetworkLabel(ctx, routeOwnerLabel(cidr), ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orphanedRoutes returns the routes of network which are owned by clusterName
+// and point to a deleted server or lie outside of the cluster CIDR.
+func (r *routes) orphanedRoutes(network *hcloud.Network, clusterName string) ([]orphanedRoute, error) {
+	const op = "hcloud/orphanedRoutes"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	clusterCIDR := r.clusterCIDR
+	if clusterCIDR == nil {
+		return nil, nil
+	}
+	ownedCIDRs := routeOwnerCIDRs(network, clusterName)
+	// Routes created before the owner was recorded lie within the cluster
+	// CIDR, unless it is recorded as owned by another cluster.
+	if _, ok := network.Labels[routeOwnerLabel(clusterCIDR)]; !ok {
+		ownedCIDRs = append(ownedCIDRs, clusterCIDR)
+	}
+
+	var orphans []orphanedRoute
+	for _, route := range network.Routes {
+		owned := slices.ContainsFunc(ownedCIDRs, func(cidr *net.IPNet) bool {
+			return cidrContains(cidr, route.Destination)
+		})
+		if !owned {
+			continue
+		}
+
+		if !cidrContains(clusterCIDR, route.Destination) {
+			orphans = append(orphans, orphanedRoute{route: route, reason: routeOrphanedOutsideClusterCIDR})
+			continue
+		}
+
+		if hcops.InSubnets(route.Gateway, hcops.VSwitchSubnets(network)) && r.nodeLister == nil {
+			// Without the nodes, it is unknown whether the Robot server still exists.
+			continue
+		}
+		cpRoute, err := r.hcloudRouteToRoute(network, route)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if cpRoute.Blackhole {
+			orphans = append(orphans, orphanedRoute{route: route, reason: routeOrphanedServerDeleted})
+		}
+	}
+	return orphans, nil
+}
+
+// cidrContains reports whether inner is a subnet of outer.
+func cidrContains(outer, inner *net.IPNet) bool {
+	outerOnes, outerBits := outer.Mask.Size()
+	innerOnes, innerBits := inner.Mask.Size()
+	return outerBits == innerBits && outerOnes <= innerOnes && outer.Contains(inner.IP)
+}
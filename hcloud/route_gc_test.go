@@ -0,0 +1,200 @@
+package hcloud
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"net"
+	"net/http"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestRouteGC_Collect(t *testing.T) {
+	tests := []struct {
+		name          string
+		dryRun        bool
+		labels        map[string]string
+		expDeleted    []string
+		expLabels     map[string]string
+		expEventCount int
+	}{
+		{
+			name:       "delete orphaned routes",
+			expDeleted: []string{"10.5.1.0/24", "192.168.0.0/24"},
+			// The previous cluster CIDR is released once it has no routes.
+			expLabels: map[string]string{
+				"hcloud-ccm/route-cidr.10.5.0.0-16": "my-cluster",
+				"hcloud-ccm/route-cidr.10.6.0.0-16": "other-cluster",
+				"other":                             "label",
+			},
+			expEventCount: 2,
+		},
+		{
+			name:          "dry run",
+			dryRun:        true,
+			expEventCount: 2,
+		},
+		{
+			// Routes created before the owner was recorded are collected
+			// within the cluster CIDR.
+			name:          "unlabelled routes in cluster CIDR",
+			labels:        map[string]string{"other": "label"},
+			expDeleted:    []string{"10.5.1.0/24"},
+			expEventCount: 1,
+		},
+		{
+			name: "cluster CIDR owned by another cluster",
+			labels: map[string]string{
+				"hcloud-ccm/route-cidr.10.5.0.0-16": "other-cluster",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newTestEnv()
+			defer env.Teardown()
+			env.Mux.HandleFunc("/servers", func(w http.ResponseWriter, _ *http.Request) {
+				json.NewEncoder(w).Encode(schema.ServerListResponse{
+					Servers: []schema.Server{
+						{ID: 1, Name: "node1", PrivateNet: []schema.ServerPrivateNet{{Network: 1, IP: "10.0.0.2"}}},
+					},
+				})
+			})
+			env.Mux.HandleFunc("/actions", func(w http.ResponseWriter, _ *http.Request) {
+				json.NewEncoder(w).Encode(schema.ActionListResponse{
+					Actions: []schema.Action{{ID: 1, Status: string(hcloud.ActionStatusSuccess), Progress: 100}},
+				})
+			})
+
+			var mu sync.Mutex
+			var deleted []string
+			networkRoutes := []schema.NetworkRoute{
+				// Owned and the server exists.
+				{Destination: "10.5.0.0/24", Gateway: "10.0.0.2"},
+				// Owned and the server was deleted.
+				{Destination: "10.5.1.0/24", Gateway: "10.0.0.3"},
+				// Not owned.
+				{Destination: "172.16.0.0/24", Gateway: "10.0.0.4"},
+				// Owned by another cluster.
+				{Destination: "10.6.0.0/24", Gateway: "10.0.0.5"},
+				// Owned and in the previous cluster CIDR.
+				{Destination: "192.168.0.0/24", Gateway: "10.0.0.2"},
+			}
+			labels := map[string]string{
+				"hcloud-ccm/route-cidr.10.5.0.0-16":    "my-cluster",
+				"hcloud-ccm/route-cidr.10.6.0.0-16":    "other-cluster",
+				"hcloud-ccm/route-cidr.192.168.0.0-16": "my-cluster",
+				"other":                                "label",
+			}
+			if tt.labels != nil {
+				labels = tt.labels
+			}
+			expLabels := tt.expLabels
+			if expLabels == nil {
+				expLabels = maps.Clone(labels)
+			}
+			env.Mux.HandleFunc("/networks/1", func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				if r.Method == http.MethodPut {
+					var reqBody schema.NetworkUpdateRequest
+					require.NoError(t, json.NewDecoder(r.Body).Decode(&reqBody))
+					labels = *reqBody.Labels
+				}
+				json.NewEncoder(w).Encode(schema.NetworkGetResponse{
+					Network: schema.Network{
+						ID:      1,
+						Name:    "network-1",
+						IPRange: "10.0.0.0/8",
+						Routes:  slices.Clone(networkRoutes),
+						Labels:  maps.Clone(labels),
+					},
+				})
+			})
+			env.Mux.HandleFunc("/networks/1/actions/delete_route", func(w http.ResponseWriter, r *http.Request) {
+				var reqBody schema.NetworkActionDeleteRouteRequest
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&reqBody))
+				mu.Lock()
+				deleted = append(deleted, reqBody.Destination)
+				networkRoutes = slices.DeleteFunc(networkRoutes, func(route schema.NetworkRoute) bool {
+					return route.Destination == reqBody.Destination
+				})
+				mu.Unlock()
+				json.NewEncoder(w).Encode(schema.NetworkActionDeleteRouteResponse{
+					Action: schema.Action{ID: 1, Status: string(hcloud.ActionStatusRunning)},
+				})
+			})
+
+			routes, err := newRoutes(env.Client, 1)
+			require.NoError(t, err)
+			_, routes.clusterCIDR, _ = net.ParseCIDR("10.5.0.0/16")
+			recorder := record.NewFakeRecorder(10)
+			gc := &routeGC{
+				routes:   routes,
+				dryRun:   tt.dryRun,
+				recorder: recorder,
+			}
+
+			// The owner of the routes is not known before the route controller
+			// listed them.
+			require.NoError(t, gc.collect(context.Background()))
+			assert.Empty(t, recorder.Events)
+
+			_, err = routes.ListRoutes(context.Background(), "my-cluster")
+			require.NoError(t, err)
+			require.NoError(t, gc.collect(context.Background()))
+
+			slices.Sort(deleted)
+			assert.Equal(t, tt.expDeleted, deleted)
+			assert.Equal(t, expLabels, labels)
+			assert.Len(t, recorder.Events, tt.expEventCount)
+		})
+	}
+}
+
+func TestCIDRContains(t *testing.T) {
+	tests := []struct {
+		outer, inner string
+		want         bool
+	}{
+		{outer: "10.244.0.0/16", inner: "10.244.1.0/24", want: true},
+		{outer: "10.244.0.0/16", inner: "10.244.0.0/16", want: true},
+		{outer: "10.244.0.0/16", inner: "10.0.0.0/8", want: false},
+		{outer: "10.244.0.0/16", inner: "10.245.0.0/24", want: false},
+		{outer: "10.244.0.0/16", inner: "fd00::/64", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.outer+" "+tt.inner, func(t *testing.T) {
+			_, outer, _ := net.ParseCIDR(tt.outer)
+			_, inner, _ := net.ParseCIDR(tt.inner)
+			assert.Equal(t, tt.want, cidrContains(outer, inner))
+		})
+	}
+}
+
+func TestRouteOwnerCIDRs(t *testing.T) {
+	_, clusterCIDR, _ := net.ParseCIDR("10.244.0.0/16")
+	network := &hcloud.Network{Labels: map[string]string{
+		routeOwnerLabel(clusterCIDR):          "my-cluster",
+		"hcloud-ccm/route-cidr.10.0.0.0-8":    "other-cluster",
+		"hcloud-ccm/route-cidr.invalid":       "my-cluster",
+		"hcloud-ccm/route.10.244.1.0-24":      "my-cluster",
+		"hcloud-ccm/route-cidr.172.16.0.0-12": "my-cluster",
+	}}
+
+	var cidrs []string
+	for _, cidr := range routeOwnerCIDRs(network, "my-cluster") {
+		cidrs = append(cidrs, cidr.String())
+	}
+	slices.Sort(cidrs)
+	assert.Equal(t, []string{"10.244.0.0/16", "172.16.0.0/12"}, cidrs)
+}
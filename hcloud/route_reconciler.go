@@ -0,0 +1,313 @@
+package hcloud
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/metrics"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog/v2"
+)
+
+// defaultRouteBackoff is used to retry route changes which failed, because
+// the network was locked by another action or changed concurrently.
+var defaultRouteBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.1,
+	Steps:    6,
+	Cap:      30 * time.Second,
+}
+
+// routeOwnerLabelPrefix is the prefix of the network labels which mark the
+// cluster CIDRs the controller created routes in. The value of a label is the
+// name of the cluster owning the routes within the CIDR.
+//
+// There is one label per cluster CIDR instead of one per route, so that the
+// number of labels does not grow with the cluster.
+const routeOwnerLabelPrefix = "hcloud-ccm/route-cidr."
+
+// routeOwnerLabel returns the key of the network label which marks the routes
+// within clusterCIDR as created by the controller.
+func routeOwnerLabel(clusterCIDR *net.IPNet) string {
+	return routeOwnerLabelPrefix + strings.ReplaceAll(clusterCIDR.String(), "/", "-")
+}
+
+// routeOwnerCIDRs returns the cluster CIDRs of network owned by clusterName.
+func routeOwnerCIDRs(network *hcloud.Network, clusterName string) []*net.IPNet {
+	var cidrs []*net.IPNet
+	for key, value := range network.Labels {
+		cidr, ok := strings.CutPrefix(key, routeOwnerLabelPrefix)
+		if !ok || value != clusterName {
+			continue
+		}
+		// The last "-" separates the prefix length, see routeOwnerLabel.
+		if i := strings.LastIndex(cidr, "-"); i >= 0 {
+			cidr = cidr[:i] + "/" + cidr[i+1:]
+		}
+		if _, ipNet, err := net.ParseCIDR(cidr); err == nil {
+			cidrs = append(cidrs, ipNet)
+		}
+	}
+	return cidrs
+}
+
+// routeChange is a route which should be added to or deleted from the
+// network.
+type routeChange struct {
+	ctx         context.Context
+	destination *net.IPNet
+	// gateway of the route to add. For deletions the route is only deleted if
+	// it still uses this gateway, nil deletes it regardless of its gateway.
+	gateway net.IP
+	// owner is the name of the cluster the added route belongs to. It is
+	// recorded in the labels of the network, if the route lies in the
+	// cluster CIDR.
+	owner  string
+	delete bool
+	done   chan error
+}
+
+// routeReconciler applies route changes to the network.
+//
+// Every action on a network locks it, so parallel changes mostly fail. The
+// route controller calls CreateRoute for all nodes at once, therefore the
+// changes are queued and applied one after the other by a single worker.
+// The worker compares each batch of queued changes with the routes of the
+// network once, and skips changes which are already applied.
+type routeReconciler struct {
+	routes  *routes
+	backoff wait.Backoff
+
+	startWorker sync.Once
+	kick        chan struct{}
+
+	mu      sync.Mutex // protects pending and queuedSince
+	pending []*routeChange
+	// queuedSince is the time the first change was queued after the queue
+	// was empty. Used to measure the time until the routes converged.
+	queuedSince time.Time
+}
+
+func newRouteReconciler(r *routes) *routeReconciler {
+	return &routeReconciler{
+		routes:  r,
+		backoff: defaultRouteBackoff,
+		kick:    make(chan struct{}, 1),
+	}
+}
+
+// add queues a route with destination and gateway owned by the cluster owner
+// and waits until it was applied or ctx is done.
+func (q *routeReconciler) add(ctx context.Context, destination *net.IPNet, gateway net.IP, owner string) error {
+	return q.submit(&routeChange{ctx: ctx, destination: destination, gateway: gateway, owner: owner})
+}
+
+// delete queues the deletion of the route with destination and waits until it
+// was applied or ctx is done. If gateway is not nil, the route is only deleted
+// if it still uses gateway.
+func (q *routeReconciler) delete(ctx context.Context, destination *net.IPNet, gateway net.IP) error {
+	return q.submit(&routeChange{ctx: ctx, destination: destination, gateway: gateway, delete: true})
+}
+
+func (q *routeReconciler) submit(change *routeChange) error {
+	change.done = make(chan error, 1)
+
+	q.mu.Lock()
+	if len(q.pending) == 0 && q.queuedSince.IsZero() {
+		q.queuedSince = time.Now()
+	}
+	q.pending = append(q.pending, change)
+	q.mu.Unlock()
+
+	q.startWorker.Do(func() { go q.work() })
+	select {
+	case q.kick <- struct{}{}:
+	default:
+	}
+
+	select {
+	case err := <-change.done:
+		return err
+	case <-change.ctx.Done():
+		return change.ctx.Err()
+	}
+}
+
+func (q *routeReconciler) work() {
+	for range q.kick {
+		for {
+			q.mu.Lock()
+			batch := q.pending
+			q.pending = nil
+			if len(batch) == 0 {
+				if !q.queuedSince.IsZero() {
+					metrics.RouteConvergenceDuration.Observe(time.Since(q.queuedSince).Seconds())
+					q.queuedSince = time.Time{}
+				}
+				q.mu.Unlock()
+				break
+			}
+			q.mu.Unlock()
+
+			q.applyBatch(batch)
+		}
+	}
+}
+
+// applyBatch applies the changes of batch in order and reports the result to
+// the submitter of each change.
+func (q *routeReconciler) applyBatch(batch []*routeChange) {
+	const op = "hcloud/routeReconciler.applyBatch"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	// Use a context of a change which is still waiting, so that loading the
+	// network is canceled if all submitters gave up.
+	ctx := context.Background()
+	for _, change := range batch {
+		if change.ctx.Err() == nil {
+			ctx = change.ctx
+			break
+		}
+	}
+
+	q.routes.invalidateNetwork()
+	network, err := q.routes.getNetwork(ctx)
+	if err != nil {
+		for _, change := range batch {
+			change.done <- fmt.Errorf("%s: %w", op, err)
+		}
+		return
+	}
+
+	// Gateways of the routes in the network by destination. Updated with
+	// every change applied.
+	gateways := make(map[string]net.IP, len(network.Routes))
+	for _, route := range network.Routes {
+		gateways[route.Destination.String()] = route.Gateway
+	}
+
+	errs := make([]error, len(batch))
+	for i, change := range batch {
+		if err := change.ctx.Err(); err != nil {
+			errs[i] = err
+			continue
+		}
+		errs[i] = q.apply(network, gateways, change)
+	}
+
+	if owner := addedRouteOwner(q.routes.clusterCIDR, batch, errs); owner != "" {
+		key := routeOwnerLabel(q.routes.clusterCIDR)
+		if network.Labels[key] != owner {
+			if err := q.routes.setNetworkLabel(ctx, key, owner); err != nil {
+				// The routes were changed, only the record of their owner is
+				// missing. Orphaned routes are possibly not garbage collected.
+				klog.ErrorS(err, "recording route owner in network labels", "op", op, "network", network.ID)
+			}
+		}
+	}
+
+	// The submitters are notified after the owner was recorded.
+	q.routes.invalidateNetwork()
+	for i, change := range batch {
+		change.done <- errs[i]
+	}
+}
+
+// addedRouteOwner returns the owner of the routes added within clusterCIDR by
+// batch, or an empty string if there are none or the owner is no valid label
+// value.
+func addedRouteOwner(clusterCIDR *net.IPNet, batch []*routeChange, errs []error) string {
+	if clusterCIDR == nil {
+		return ""
+	}
+	for i, change := range batch {
+		if errs[i] != nil || change.delete || !cidrContains(clusterCIDR, change.destination) {
+			continue
+		}
+		if len(validation.IsValidLabelValue(change.owner)) > 0 {
+			// The owner cannot be recorded, so the routes are not garbage
+			// collected.
+			return ""
+		}
+		return change.owner
+	}
+	return ""
+}
+
+func (q *routeReconciler) apply(network *hcloud.Network, gateways map[string]net.IP, change *routeChange) error {
+	const op = "hcloud/routeReconciler.apply"
+
+	destination := change.destination.String()
+	current, exists := gateways[destination]
+
+	if change.delete && exists && change.gateway != nil && !current.Equal(change.gateway) {
+		// The route was replaced in the meantime.
+		return nil
+	}
+
+	if exists && (change.delete || !current.Equal(change.gateway)) {
+		route := hcloud.NetworkRoute{Destination: change.destination, Gateway: current}
+		err := q.retry(change.ctx, func() (*hcloud.Action, error) {
+			action, _, err := q.routes.client.Network.DeleteRoute(change.ctx, network, hcloud.NetworkDeleteRouteOpts{Route: route})
+			return action, err
+		})
+		if err != nil {
+			return fmt.Errorf("%s: delete route %s via %s: %w", op, destination, current, err)
+		}
+		delete(gateways, destination)
+		exists = false
+	}
+
+	if change.delete || exists {
+		return nil
+	}
+
+	route := hcloud.NetworkRoute{Destination: change.destination, Gateway: change.gateway}
+	err := q.retry(change.ctx, func() (*hcloud.Action, error) {
+		action, _, err := q.routes.client.Network.AddRoute(change.ctx, network, hcloud.NetworkAddRouteOpts{Route: route})
+		return action, err
+	})
+	if err != nil {
+		return fmt.Errorf("%s: add route %s via %s: %w", op, destination, change.gateway, err)
+	}
+	gateways[destination] = change.gateway
+	return nil
+}
+
+// retry runs f and waits for the returned action, if any. Requests which failed,
+// because the network is locked or was changed concurrently, are retried
+// with exponential backoff until the backoff is exhausted or ctx is done.
+func (q *routeReconciler) retry(ctx context.Context, f func() (*hcloud.Action, error)) error {
+	backoff := q.backoff
+	for {
+		action, err := f()
+		if err == nil {
+			if action == nil {
+				return nil
+			}
+			return hcops.WatchAction(ctx, &q.routes.client.Action, action)
+		}
+		if !hcloud.IsError(err, hcloud.ErrorCodeLocked) && !hcloud.IsError(err, hcloud.ErrorCodeConflict) {
+			return err
+		}
+		if backoff.Steps <= 1 {
+			return fmt.Errorf("giving up after retries: %w", err)
+		}
+
+		delay := backoff.Step()
+		klog.InfoS("retry due to conflict or lock", "delay", delay, "err", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
@@ -4,21 +4,52 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net"
+	"sync"
 	"time"
 
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/metrics"
+	"golang.org/x/sync/singleflight"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	cloudprovider "k8s.io/cloud-provider"
-	"k8s.io/klog/v2"
 )
 
+// networkMaxAge is the time a loaded network is reused. The route controller
+// calls ListRoutes and CreateRoute for every node in quick succession, so
+// these calls share one reload of the network.
+const networkMaxAge = 5 * time.Second
+
 type routes struct {
 	client      *hcloud.Client
-	network     *hcloud.Network
+	networkID   int64
 	serverCache *hcops.AllServersCache
+	// nodeLister is used to find the Robot node of a route. Robot nodes are
+	// connected to the network via a vSwitch, and the Robot API does not
+	// know their IP in the vSwitch. Optional, without it routes to Robot
+	// nodes are reported as blackhole.
+	nodeLister corelisters.NodeLister
+
+	// reconciler applies all changes of the routes of the network.
+	reconciler *routeReconciler
+
+	// clusterCIDR is optional. The routes within it are recorded as owned by
+	// the cluster, see routeOwnerLabel.
+	clusterCIDR *net.IPNet
+
+	networkGroup singleflight.Group
+
+	mu            sync.Mutex // protects network, networkLoaded and clusterName
+	network       *hcloud.Network
+	networkLoaded time.Time
+	// clusterName is passed by the route controller. It is only known after
+	// the first call of ListRoutes or CreateRoute.
+	clusterName string
 }
 
 func newRoutes(client *hcloud.Client, networkID int64) (*routes, error) {
@@ -33,45 +64,129 @@ func newRoutes(client *hcloud.Client, networkID int64) (*routes, error) {
 		return nil, fmt.Errorf("network not found: %d", networkID)
 	}
 
-	return &routes{
-		client:  client,
-		network: networkObj,
+	r := &routes{
+		client:        client,
+		networkID:     networkID,
+		network:       networkObj,
+		networkLoaded: time.Now(),
 		serverCache: &hcops.AllServersCache{
 			// client.Server.All will load ALL the servers in the project, even those
 			// that are not part of the Kubernetes cluster.
 			LoadFunc: client.Server.All,
 			Network:  networkObj,
 		},
-	}, nil
+	}
+	r.reconciler = newRouteReconciler(r)
+	return r, nil
+}
+
+// getNetwork returns the network. It is reloaded if it is older than
+// networkMaxAge, concurrent reloads are deduplicated. This is the only place
+// the network is loaded from the API.
+func (r *routes) getNetwork(ctx context.Context) (*hcloud.Network, error) {
+	const op = "hcloud/getNetwork"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	r.mu.Lock()
+	network, loaded := r.network, r.networkLoaded
+	r.mu.Unlock()
+	if time.Since(loaded) < networkMaxAge {
+		return network, nil
+	}
+
+	v, err, _ := r.networkGroup.Do("network", func() (interface{}, error) {
+		networkObj, _, err := r.client.Network.GetByID(ctx, r.networkID)
+		if err != nil {
+			return nil, err
+		}
+		if networkObj == nil {
+			return nil, fmt.Errorf("network not found: %d", r.networkID)
+		}
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.network = networkObj
+		r.networkLoaded = time.Now()
+		return networkObj, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return v.(*hcloud.Network), nil
+}
+
+// invalidateNetwork makes the next call of getNetwork reload the network. It
+// must be called after the routes of the network were changed.
+func (r *routes) invalidateNetwork() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.networkLoaded = time.Time{}
 }
 
-func (r *routes) reloadNetwork(ctx context.Context) error {
-	const op = "hcloud/reloadNetwork"
+// setNetworkLabel sets the network label key to value, or removes it if value
+// is empty. The network is reloaded right before it is updated, so that labels
+// changed concurrently by others are kept.
+func (r *routes) setNetworkLabel(ctx context.Context, key, value string) error {
+	const op = "hcloud/setNetworkLabel"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
-	networkObj, _, err := r.client.Network.GetByID(ctx, r.network.ID)
+	r.invalidateNetwork()
+	network, err := r.getNetwork(ctx)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
-	if networkObj == nil {
-		return fmt.Errorf("network not found: %s", r.network.Name)
+	current, ok := network.Labels[key]
+	if (value == "" && !ok) || (value != "" && current == value) {
+		return nil
+	}
+
+	labels := maps.Clone(network.Labels)
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	if value == "" {
+		delete(labels, key)
+	} else {
+		labels[key] = value
+	}
+	err = r.reconciler.retry(ctx, func() (*hcloud.Action, error) {
+		_, _, err := r.client.Network.Update(ctx, network, hcloud.NetworkUpdateOpts{Labels: labels})
+		return nil, err
+	})
+	r.invalidateNetwork()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
-	r.network = networkObj
 	return nil
 }
 
+func (r *routes) setClusterName(clusterName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clusterName = clusterName
+}
+
+func (r *routes) getClusterName() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.clusterName
+}
+
 // ListRoutes lists all managed routes that belong to the specified clusterName.
-func (r *routes) ListRoutes(ctx context.Context, _ string) ([]*cloudprovider.Route, error) {
+func (r *routes) ListRoutes(ctx context.Context, clusterName string) ([]*cloudprovider.Route, error) {
 	const op = "hcloud/ListRoutes"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
-	if err := r.reloadNetwork(ctx); err != nil {
+	r.setClusterName(clusterName)
+
+	network, err := r.getNetwork(ctx)
+	if err != nil {
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	routes := make([]*cloudprovider.Route, 0, len(r.network.Routes))
-	for _, route := range r.network.Routes {
-		ro, err := r.hcloudRouteToRoute(route)
+	routes := make([]*cloudprovider.Route, 0, len(network.Routes))
+	for _, route := range network.Routes {
+		ro, err := r.hcloudRouteToRoute(network, route)
 		if err != nil {
 			return routes, fmt.Errorf("%s: %w", op, err)
 		}
@@ -83,63 +198,28 @@ func (r *routes) ListRoutes(ctx context.Context, _ string) ([]*cloudprovider.Rou
 // CreateRoute creates the described managed route
 // route.Name will be ignored, although the cloud-provider may use nameHint
 // to create a more user-meaningful name.
-func (r *routes) CreateRoute(ctx context.Context, clusterName, nameHint string, route *cloudprovider.Route) error {
+func (r *routes) CreateRoute(ctx context.Context, clusterName, _ string, route *cloudprovider.Route) error {
 	const op = "hcloud/CreateRoute"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
-	srv, err := r.serverCache.ByName(string(route.TargetNode))
+	network, err := r.getNetwork(ctx)
 	if err != nil {
-		return fmt.Errorf("%s: %v", op, err)
-	}
-
-	privNet, ok := findServerPrivateNetByID(srv, r.network.ID)
-	if !ok {
-		r.serverCache.InvalidateCache()
-		srv, err = r.serverCache.ByName(string(route.TargetNode))
-		if err != nil {
-			return fmt.Errorf("%s: %v", op, err)
-		}
-
-		privNet, ok = findServerPrivateNetByID(srv, r.network.ID)
-		if !ok {
-			return fmt.Errorf("%s: server %v: network with id %d not attached to this server ", op, route.TargetNode, r.network.ID)
-		}
+		return fmt.Errorf("%s: %w", op, err)
 	}
-	ip := privNet.IP
 
-	_, cidr, err := net.ParseCIDR(route.DestinationCIDR)
+	ip, err := r.gatewayOfNode(network, route)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	doesRouteAlreadyExist, err := r.checkIfRouteAlreadyExists(ctx, route)
+	_, cidr, err := net.ParseCIDR(route.DestinationCIDR)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	if !doesRouteAlreadyExist {
-		opts := hcloud.NetworkAddRouteOpts{
-			Route: hcloud.NetworkRoute{
-				Destination: cidr,
-				Gateway:     ip,
-			},
-		}
-		action, _, err := r.client.Network.AddRoute(ctx, r.network, opts)
-		if err != nil {
-			if hcloud.IsError(err, hcloud.ErrorCodeLocked) || hcloud.IsError(err, hcloud.ErrorCodeConflict) {
-				retryDelay := time.Second * 5
-				klog.InfoS("retry due to conflict or lock",
-					"op", op, "delay", fmt.Sprintf("%v", retryDelay), "err", fmt.Sprintf("%v", err))
-				time.Sleep(retryDelay)
-
-				return r.CreateRoute(ctx, clusterName, nameHint, route)
-			}
-			return fmt.Errorf("%s: %w", op, err)
-		}
-
-		if err := hcops.WatchAction(ctx, &r.client.Action, action); err != nil {
-			return fmt.Errorf("%s: %w", op, err)
-		}
+	r.setClusterName(clusterName)
+	if err := r.reconciler.add(ctx, cidr, ip, clusterName); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 	return nil
 }
@@ -150,60 +230,19 @@ func (r *routes) DeleteRoute(ctx context.Context, _ string, route *cloudprovider
 	const op = "hcloud/DeleteRoute"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
-	// Get target IP from current list of routes, routes can be uniquely identified by their destination cidr.
-	var ip net.IP
-	for _, cloudRoute := range r.network.Routes {
-		if cloudRoute.Destination.String() == route.DestinationCIDR {
-			ip = cloudRoute.Gateway
-			break
-		}
-	}
-	if ip.IsUnspecified() {
-		return fmt.Errorf("%s: route %s not found in cloud network routes", op, route.Name)
-	}
-
 	_, cidr, err := net.ParseCIDR(route.DestinationCIDR)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	err = r.deleteRouteFromHcloud(ctx, cidr, ip)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-	return nil
-}
-
-func (r *routes) deleteRouteFromHcloud(ctx context.Context, cidr *net.IPNet, ip net.IP) error {
-	const op = "hcloud/deleteRouteFromHcloud"
-	metrics.OperationCalled.WithLabelValues(op).Inc()
-
-	opts := hcloud.NetworkDeleteRouteOpts{
-		Route: hcloud.NetworkRoute{
-			Destination: cidr,
-			Gateway:     ip,
-		},
-	}
-
-	action, _, err := r.client.Network.DeleteRoute(ctx, r.network, opts)
-	if err != nil {
-		if hcloud.IsError(err, hcloud.ErrorCodeLocked) || hcloud.IsError(err, hcloud.ErrorCodeConflict) {
-			retryDelay := time.Second * 5
-			klog.InfoS("retry due to conflict or lock",
-				"op", op, "delay", fmt.Sprintf("%v", retryDelay), "err", fmt.Sprintf("%v", err))
-			time.Sleep(retryDelay)
-
-			return r.deleteRouteFromHcloud(ctx, cidr, ip)
-		}
-		return fmt.Errorf("%s: %w", op, err)
-	}
-	if err := hcops.WatchAction(ctx, &r.client.Action, action); err != nil {
+	// Routes are uniquely identified by their destination cidr.
+	if err := r.reconciler.delete(ctx, cidr, nil); err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 	return nil
 }
 
-func (r *routes) hcloudRouteToRoute(route hcloud.NetworkRoute) (*cloudprovider.Route, error) {
+func (r *routes) hcloudRouteToRoute(network *hcloud.Network, route hcloud.NetworkRoute) (*cloudprovider.Route, error) {
 	const op = "hcloud/hcloudRouteToRoute"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
@@ -212,6 +251,20 @@ func (r *routes) hcloudRouteToRoute(route hcloud.NetworkRoute) (*cloudprovider.R
 		Name:            fmt.Sprintf("%s-%s", route.Gateway.String(), route.Destination.String()),
 	}
 
+	if hcops.InSubnets(route.Gateway, hcops.VSwitchSubnets(network)) {
+		nodeName, err := r.robotNodeByVSwitchIP(route.Gateway)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if nodeName == "" {
+			// Route belongs to non-existing target
+			cpRoute.Blackhole = true
+			return cpRoute, nil
+		}
+		cpRoute.TargetNode = nodeName
+		return cpRoute, nil
+	}
+
 	srv, err := r.serverCache.ByPrivateIP(route.Gateway)
 	if err != nil {
 		if errors.Is(err, hcops.ErrNotFound) {
@@ -227,43 +280,81 @@ func (r *routes) hcloudRouteToRoute(route hcloud.NetworkRoute) (*cloudprovider.R
 	return cpRoute, nil
 }
 
-func (r *routes) checkIfRouteAlreadyExists(ctx context.Context, route *cloudprovider.Route) (bool, error) {
-	const op = "hcloud/checkIfRouteAlreadyExists"
+// gatewayOfNode returns the IP of the target node of route in the network.
+func (r *routes) gatewayOfNode(network *hcloud.Network, route *cloudprovider.Route) (net.IP, error) {
+	const op = "hcloud/gatewayOfNode"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
-	if err := r.reloadNetwork(ctx); err != nil {
-		return false, fmt.Errorf("%s: %w", op, err)
+	if !r.isHCloudServerNode(route.TargetNode) {
+		// Robot servers use their vSwitch IP, which is reported as InternalIP.
+		subnets := hcops.VSwitchSubnets(network)
+		for _, address := range route.TargetNodeAddresses {
+			ip := net.ParseIP(address.Address)
+			if address.Type == corev1.NodeInternalIP && ip != nil && hcops.InSubnets(ip, subnets) {
+				return ip, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: robot server %v: no InternalIP in a vSwitch subnet of network %d",
+			op, route.TargetNode, network.ID)
 	}
 
-	for _, _route := range r.network.Routes {
-		if _route.Destination.String() == route.DestinationCIDR {
-			srv, err := r.serverCache.ByName(string(route.TargetNode))
-			if err != nil {
-				return false, fmt.Errorf("%s: %v", op, err)
-			}
-			privNet, ok := findServerPrivateNetByID(srv, r.network.ID)
-			if !ok {
-				return false, fmt.Errorf("%s: server %v: no network with id: %d", op, route.TargetNode, r.network.ID)
-			}
-			ip := privNet.IP
-
-			if !_route.Gateway.Equal(ip) {
-				action, _, err := r.client.Network.DeleteRoute(context.Background(), r.network, hcloud.NetworkDeleteRouteOpts{
-					Route: _route,
-				})
-				if err != nil {
-					return false, fmt.Errorf("%s: %w", op, err)
-				}
-
-				if err := hcops.WatchAction(ctx, &r.client.Action, action); err != nil {
-					return false, fmt.Errorf("%s: %w", op, err)
-				}
-			}
+	srv, err := r.serverCache.ByName(string(route.TargetNode))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+
+	privNet, ok := findServerPrivateNetByID(srv, network.ID)
+	if !ok {
+		r.serverCache.InvalidateCache()
+		srv, err = r.serverCache.ByName(string(route.TargetNode))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", op, err)
+		}
 
-			return true, nil
+		privNet, ok = findServerPrivateNetByID(srv, network.ID)
+		if !ok {
+			return nil, fmt.Errorf("%s: server %v: network with id %d not attached to this server ", op, route.TargetNode, network.ID)
+		}
+	}
+	return privNet.IP, nil
+}
+
+// isHCloudServerNode reports whether the node name is a Hetzner Cloud server.
+// The provider ID of the node decides, if the node is known. Otherwise the
+// name decides.
+func (r *routes) isHCloudServerNode(name types.NodeName) bool {
+	if r.nodeLister != nil {
+		if node, err := r.nodeLister.Get(string(name)); err == nil {
+			return isHCloudServer(node)
+		}
+	}
+	return isHCloudServerByName(string(name))
+}
+
+// robotNodeByVSwitchIP returns the name of the Robot node with the InternalIP
+// ip, or an empty string if there is none.
+func (r *routes) robotNodeByVSwitchIP(ip net.IP) (types.NodeName, error) {
+	const op = "hcloud/robotNodeByVSwitchIP"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	if r.nodeLister == nil {
+		return "", nil
+	}
+	nodes, err := r.nodeLister.List(labels.Everything())
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+	for _, node := range nodes {
+		if isHCloudServer(node) {
+			continue
+		}
+		for _, address := range node.Status.Addresses {
+			if address.Type == corev1.NodeInternalIP && ip.Equal(net.ParseIP(address.Address)) {
+				return types.NodeName(node.Name), nil
+			}
 		}
 	}
-	return false, nil
+	return "", nil
 }
 
 func findServerPrivateNetByID(srv *hcloud.Server, id int64) (hcloud.ServerPrivateNet, bool) {
@@ -3,11 +3,24 @@ package hcloud
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"net"
 	"net/http"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 	"github.com/hetznercloud/hcloud-go/v2/hcloud/schema"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 	cloudprovider "k8s.io/cloud-provider"
 )
 
@@ -200,3 +213,426 @@ func TestRoutes_DeleteRoute(t *testing.T) {
 		t.Fatalf("Unexpected error: %v", err)
 	}
 }
+
+func TestRoutes_CreateRouteRobotServer(t *testing.T) {
+	env := newTestEnv()
+	defer env.Teardown()
+	env.Mux.HandleFunc("/networks/1", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(schema.NetworkGetResponse{
+			Network: schema.Network{
+				ID:      1,
+				Name:    "network-1",
+				IPRange: "10.0.0.0/8",
+				Subnets: []schema.NetworkSubnet{
+					{Type: "vswitch", IPRange: "10.0.1.0/24", NetworkZone: "eu-central", VSwitchID: 42},
+				},
+			},
+		})
+	})
+	env.Mux.HandleFunc("/actions", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(schema.ActionListResponse{
+			Actions: []schema.Action{
+				{
+					ID:       1,
+					Status:   string(hcloud.ActionStatusSuccess),
+					Progress: 100,
+				},
+			},
+		})
+	})
+	env.Mux.HandleFunc("/networks/1/actions/add_route", func(w http.ResponseWriter, r *http.Request) {
+		var reqBody schema.NetworkActionAddRouteRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Fatal(err)
+		}
+		if reqBody.Destination != "10.5.0.0/24" {
+			t.Errorf("unexpected Destination: %v", reqBody.Destination)
+		}
+		if reqBody.Gateway != "10.0.1.7" {
+			t.Errorf("unexpected Gateway: %v", reqBody.Gateway)
+		}
+		json.NewEncoder(w).Encode(schema.NetworkActionAddRouteResponse{
+			Action: schema.Action{
+				ID:       1,
+				Progress: 0,
+				Status:   string(hcloud.ActionStatusRunning),
+			},
+		})
+	})
+	routes, err := newRoutes(env.Client, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	err = routes.CreateRoute(context.TODO(), "my-cluster", "route", &cloudprovider.Route{
+		Name:       "route",
+		TargetNode: "bm-server1",
+		TargetNodeAddresses: []corev1.NodeAddress{
+			{Type: corev1.NodeExternalIP, Address: "123.123.123.123"},
+			{Type: corev1.NodeInternalIP, Address: "10.0.1.7"},
+		},
+		DestinationCIDR: "10.5.0.0/24",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	err = routes.CreateRoute(context.TODO(), "my-cluster", "route", &cloudprovider.Route{
+		Name:       "route",
+		TargetNode: "bm-server2",
+		TargetNodeAddresses: []corev1.NodeAddress{
+			{Type: corev1.NodeExternalIP, Address: "123.123.123.124"},
+		},
+		DestinationCIDR: "10.6.0.0/24",
+	})
+	if err == nil {
+		t.Fatal("Expected error for robot server without vSwitch IP")
+	}
+
+	// The provider ID identifies Robot nodes without "bm-" prefix.
+	nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	err = nodeIndexer.Add(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "server3"},
+		Spec:       corev1.NodeSpec{ProviderID: "hrobot://3"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	routes.nodeLister = corelisters.NewNodeLister(nodeIndexer)
+
+	err = routes.CreateRoute(context.TODO(), "my-cluster", "route", &cloudprovider.Route{
+		Name:       "route",
+		TargetNode: "server3",
+		TargetNodeAddresses: []corev1.NodeAddress{
+			{Type: corev1.NodeInternalIP, Address: "10.0.1.7"},
+		},
+		DestinationCIDR: "10.5.0.0/24",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+}
+
+func TestRoutes_ListRoutesRobotServer(t *testing.T) {
+	env := newTestEnv()
+	defer env.Teardown()
+	env.Mux.HandleFunc("/networks/1", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(schema.NetworkGetResponse{
+			Network: schema.Network{
+				ID:      1,
+				Name:    "network-1",
+				IPRange: "10.0.0.0/8",
+				Subnets: []schema.NetworkSubnet{
+					{Type: "vswitch", IPRange: "10.0.1.0/24", NetworkZone: "eu-central", VSwitchID: 42},
+				},
+				Routes: []schema.NetworkRoute{
+					{
+						Destination: "10.5.0.0/24",
+						Gateway:     "10.0.1.7",
+					},
+					{
+						Destination: "10.6.0.0/24",
+						Gateway:     "10.0.1.8",
+					},
+				},
+			},
+		})
+	})
+
+	nodeIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	err := nodeIndexer.Add(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "bm-server1"},
+		Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+			{Type: corev1.NodeInternalIP, Address: "10.0.1.7"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	routes, err := newRoutes(env.Client, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	routes.nodeLister = corelisters.NewNodeLister(nodeIndexer)
+
+	r, err := routes.ListRoutes(context.TODO(), "my-cluster")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(r) != 2 {
+		t.Fatalf("Unexpected routes %v", len(r))
+	}
+	if r[0].TargetNode != "bm-server1" || r[0].Blackhole {
+		t.Errorf("Unexpected route %+v", r[0])
+	}
+	if r[1].TargetNode != "" || !r[1].Blackhole {
+		t.Errorf("Expected blackhole route, got %+v", r[1])
+	}
+}
+
+func TestRoutes_NetworkReuse(t *testing.T) {
+	env := newTestEnv()
+	defer env.Teardown()
+	var networkRequests int
+	env.Mux.HandleFunc("/networks/1", func(w http.ResponseWriter, _ *http.Request) {
+		networkRequests++
+		json.NewEncoder(w).Encode(schema.NetworkGetResponse{
+			Network: schema.Network{
+				ID:      1,
+				Name:    "network-1",
+				IPRange: "10.0.0.0/8",
+			},
+		})
+	})
+	routes, err := newRoutes(env.Client, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	for range 3 {
+		if _, err := routes.ListRoutes(context.TODO(), "my-cluster"); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+	if networkRequests != 1 {
+		t.Errorf("Expected the network to be loaded once, got %d", networkRequests)
+	}
+
+	routes.invalidateNetwork()
+	if _, err := routes.ListRoutes(context.TODO(), "my-cluster"); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if networkRequests != 2 {
+		t.Errorf("Expected the network to be reloaded after invalidation, got %d requests", networkRequests)
+	}
+}
+
+func TestRoutes_CreateRouteBatch(t *testing.T) {
+	env := newTestEnv()
+	defer env.Teardown()
+	env.Mux.HandleFunc("/servers", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(schema.ServerListResponse{
+			Servers: []schema.Server{
+				{ID: 1, Name: "node1", PrivateNet: []schema.ServerPrivateNet{{Network: 1, IP: "10.0.0.2"}}},
+				{ID: 2, Name: "node2", PrivateNet: []schema.ServerPrivateNet{{Network: 1, IP: "10.0.0.3"}}},
+				{ID: 3, Name: "node3", PrivateNet: []schema.ServerPrivateNet{{Network: 1, IP: "10.0.0.4"}}},
+			},
+		})
+	})
+	var mu sync.Mutex
+	networkRoutes := []schema.NetworkRoute{{Destination: "10.5.0.0/24", Gateway: "10.0.0.2"}}
+	env.Mux.HandleFunc("/networks/1", func(w http.ResponseWriter, _ *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		json.NewEncoder(w).Encode(schema.NetworkGetResponse{
+			Network: schema.Network{
+				ID:      1,
+				Name:    "network-1",
+				IPRange: "10.0.0.0/8",
+				Routes:  append([]schema.NetworkRoute(nil), networkRoutes...),
+			},
+		})
+	})
+	env.Mux.HandleFunc("/actions", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(schema.ActionListResponse{
+			Actions: []schema.Action{{ID: 1, Status: string(hcloud.ActionStatusSuccess), Progress: 100}},
+		})
+	})
+	var locked bool
+	env.Mux.HandleFunc("/networks/1/actions/add_route", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !locked {
+			// The first request fails, because the network is locked.
+			locked = true
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusLocked)
+			json.NewEncoder(w).Encode(schema.ErrorResponse{
+				Error: schema.Error{Code: string(hcloud.ErrorCodeLocked), Message: "network is locked"},
+			})
+			return
+		}
+		var reqBody schema.NetworkActionAddRouteRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Fatal(err)
+		}
+		networkRoutes = append(networkRoutes, schema.NetworkRoute{Destination: reqBody.Destination, Gateway: reqBody.Gateway})
+		json.NewEncoder(w).Encode(schema.NetworkActionAddRouteResponse{
+			Action: schema.Action{ID: 1, Status: string(hcloud.ActionStatusRunning)},
+		})
+	})
+	routes, err := newRoutes(env.Client, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	routes.reconciler.backoff = wait.Backoff{Duration: time.Millisecond, Factor: 2, Steps: 3}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for i, cidr := range []string{"10.5.0.0/24", "10.5.1.0/24", "10.5.2.0/24"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- routes.CreateRoute(context.TODO(), "my-cluster", "route", &cloudprovider.Route{
+				TargetNode:      types.NodeName(fmt.Sprintf("node%d", i+1)),
+				DestinationCIDR: cidr,
+			})
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+
+	// The existing route is kept, the others are added once.
+	expected := []schema.NetworkRoute{
+		{Destination: "10.5.0.0/24", Gateway: "10.0.0.2"},
+		{Destination: "10.5.1.0/24", Gateway: "10.0.0.3"},
+		{Destination: "10.5.2.0/24", Gateway: "10.0.0.4"},
+	}
+	if len(networkRoutes) != len(expected) {
+		t.Fatalf("Unexpected routes %v", networkRoutes)
+	}
+	for _, route := range expected {
+		if !slices.Contains(networkRoutes, route) {
+			t.Errorf("Missing route %v in %v", route, networkRoutes)
+		}
+	}
+}
+
+func TestRoutes_CreateRouteOwnerLabel(t *testing.T) {
+	env := newTestEnv()
+	defer env.Teardown()
+	env.Mux.HandleFunc("/servers", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(schema.ServerListResponse{
+			Servers: []schema.Server{
+				{ID: 1, Name: "node1", PrivateNet: []schema.ServerPrivateNet{{Network: 1, IP: "10.0.0.2"}}},
+			},
+		})
+	})
+	var mu sync.Mutex
+	var networkRoutes []schema.NetworkRoute
+	labels := map[string]string{"other": "label"}
+	var labelUpdates int
+	env.Mux.HandleFunc("/networks/1", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.Method == http.MethodPut {
+			var reqBody schema.NetworkUpdateRequest
+			if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+				t.Fatal(err)
+			}
+			labels = *reqBody.Labels
+			labelUpdates++
+		}
+		json.NewEncoder(w).Encode(schema.NetworkGetResponse{
+			Network: schema.Network{
+				ID:      1,
+				Name:    "network-1",
+				IPRange: "10.0.0.0/8",
+				Routes:  append([]schema.NetworkRoute(nil), networkRoutes...),
+				Labels:  maps.Clone(labels),
+			},
+		})
+	})
+	env.Mux.HandleFunc("/actions", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(schema.ActionListResponse{
+			Actions: []schema.Action{{ID: 1, Status: string(hcloud.ActionStatusSuccess), Progress: 100}},
+		})
+	})
+	env.Mux.HandleFunc("/networks/1/actions/add_route", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		var reqBody schema.NetworkActionAddRouteRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Fatal(err)
+		}
+		networkRoutes = append(networkRoutes, schema.NetworkRoute{Destination: reqBody.Destination, Gateway: reqBody.Gateway})
+		json.NewEncoder(w).Encode(schema.NetworkActionAddRouteResponse{
+			Action: schema.Action{ID: 1, Status: string(hcloud.ActionStatusRunning)},
+		})
+	})
+	routes, err := newRoutes(env.Client, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	_, routes.clusterCIDR, _ = net.ParseCIDR("10.5.0.0/16")
+
+	for _, cidr := range []string{"10.5.0.0/24", "10.5.1.0/24", "172.16.0.0/24"} {
+		err := routes.CreateRoute(context.TODO(), "my-cluster", "route", &cloudprovider.Route{
+			TargetNode:      "node1",
+			DestinationCIDR: cidr,
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	// The owner is recorded once for the cluster CIDR, other labels are kept.
+	expected := map[string]string{
+		"hcloud-ccm/route-cidr.10.5.0.0-16": "my-cluster",
+		"other":                             "label",
+	}
+	if !maps.Equal(labels, expected) {
+		t.Errorf("Expected labels %v, got %v", expected, labels)
+	}
+	if labelUpdates != 1 {
+		t.Errorf("Expected 1 label update, got %d", labelUpdates)
+	}
+}
+
+func TestRoutes_CreateRouteRetryBudget(t *testing.T) {
+	env := newTestEnv()
+	defer env.Teardown()
+	env.Mux.HandleFunc("/servers", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(schema.ServerListResponse{
+			Servers: []schema.Server{
+				{ID: 1, Name: "node1", PrivateNet: []schema.ServerPrivateNet{{Network: 1, IP: "10.0.0.2"}}},
+			},
+		})
+	})
+	env.Mux.HandleFunc("/networks/1", func(w http.ResponseWriter, _ *http.Request) {
+		json.NewEncoder(w).Encode(schema.NetworkGetResponse{
+			Network: schema.Network{ID: 1, Name: "network-1", IPRange: "10.0.0.0/8"},
+		})
+	})
+	var requests int
+	env.Mux.HandleFunc("/networks/1/actions/add_route", func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusLocked)
+		json.NewEncoder(w).Encode(schema.ErrorResponse{
+			Error: schema.Error{Code: string(hcloud.ErrorCodeLocked), Message: "network is locked"},
+		})
+	})
+	routes, err := newRoutes(env.Client, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	route := &cloudprovider.Route{TargetNode: "node1", DestinationCIDR: "10.5.0.0/24"}
+
+	t.Run("backoff exhausted", func(t *testing.T) {
+		routes.reconciler.backoff = wait.Backoff{Duration: time.Millisecond, Factor: 2, Steps: 3}
+		err := routes.CreateRoute(context.TODO(), "my-cluster", "route", route)
+		if !hcloud.IsError(err, hcloud.ErrorCodeLocked) {
+			t.Fatalf("Expected locked error, got %v", err)
+		}
+		if requests != 3 {
+			t.Errorf("Expected 3 requests, got %d", requests)
+		}
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		routes.reconciler.backoff = wait.Backoff{Duration: time.Hour, Factor: 2, Steps: 3}
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		err := routes.CreateRoute(ctx, "my-cluster", "route", route)
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("Expected deadline exceeded, got %v", err)
+		}
+	})
+}
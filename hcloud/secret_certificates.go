@@ -0,0 +1,149 @@
+package hcloud
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/metrics"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// secretCertificates reconciles the services of the Load Balancers of
+// Services whose TLS Secrets changed, so that renewed certificates are
+// uploaded and replace the previous ones.
+//
+// The service controller reconciles the Load Balancers only if the Service or
+// the nodes change, not if the Secrets change.
+type secretCertificates struct {
+	loadBalancers *loadBalancers
+	serviceLister corelisters.ServiceLister
+
+	// queue holds the keys of the Services whose Secrets changed.
+	queue *serviceQueue
+}
+
+// newSecretCertificates creates secretCertificates watching the TLS Secrets
+// of secretInformerFactory. lbOps gets the lister of the Secrets.
+func newSecretCertificates(
+	loadBalancers *loadBalancers, lbOps *hcops.LoadBalancerOps,
+	informerFactory, secretInformerFactory informers.SharedInformerFactory,
+) (*secretCertificates, error) {
+	const op = "hcloud/newSecretCertificates"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	secrets := secretInformerFactory.Core().V1().Secrets()
+	lbOps.SecretLister = secrets.Lister()
+
+	sc := &secretCertificates{
+		loadBalancers: loadBalancers,
+		serviceLister: informerFactory.Core().V1().Services().Lister(),
+	}
+	sc.queue = newServiceQueue("secret-certificates", sc.reconcile)
+	// Secrets created after their Service, as cert-manager does, are picked
+	// up once they are added.
+	_, err := secrets.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    sc.enqueueServices,
+		UpdateFunc: sc.secretUpdated,
+		DeleteFunc: sc.enqueueServices,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return sc, nil
+}
+
+// secretUpdated adds the Services using the Secret to the queue, if its
+// certificate or key changed.
+func (sc *secretCertificates) secretUpdated(oldObj, newObj interface{}) {
+	oldSecret, ok := oldObj.(*corev1.Secret)
+	if !ok {
+		return
+	}
+	secret, ok := newObj.(*corev1.Secret)
+	if !ok {
+		return
+	}
+	if maps.EqualFunc(oldSecret.Data, secret.Data, bytes.Equal) {
+		return
+	}
+	sc.enqueueServices(secret)
+}
+
+// enqueueServices adds the Services using the Secret obj to the queue.
+func (sc *secretCertificates) enqueueServices(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	secret, ok := obj.(*corev1.Secret)
+	if !ok {
+		return
+	}
+
+	services, err := sc.serviceLister.Services(secret.Namespace).List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "list services of secret", "secret", secret.Name, "namespace", secret.Namespace)
+		return
+	}
+	for _, svc := range services {
+		names, err := hcops.CertificateSecretNames(svc)
+		if err != nil || !slices.Contains(names, secret.Name) {
+			continue
+		}
+		sc.queue.Add(svc.Namespace + "/" + svc.Name)
+	}
+}
+
+// run processes the queue until stop is closed.
+func (sc *secretCertificates) run(stop <-chan struct{}) {
+	sc.queue.run(stop)
+}
+
+// reconcile updates the services of the Load Balancer of the Service key.
+func (sc *secretCertificates) reconcile(ctx context.Context, key string) error {
+	const op = "hcloud/secretCertificates.reconcile"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	svc, err := sc.serviceLister.Services(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if svc.Spec.Type != corev1.ServiceTypeLoadBalancer || !sc.loadBalancers.managesClass(svc) {
+		return nil
+	}
+
+	lb, err := sc.loadBalancers.reconciledLoadBalancer(ctx, svc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if lb == nil {
+		return nil
+	}
+
+	sc.loadBalancers.servicesMu.Lock()
+	defer sc.loadBalancers.servicesMu.Unlock()
+	// The service controller requeues the Service while the managed
+	// certificate is pending.
+	_, err = sc.loadBalancers.lbOps.ReconcileHCLBServices(ctx, lb, svc)
+	if err != nil && !errors.Is(err, hcops.ErrCertificatePending) {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
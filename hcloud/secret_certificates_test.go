@@ -0,0 +1,116 @@
+package hcloud
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/annotation"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newTestSecretService(name string, annotations map[string]string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Annotations: annotations},
+		Spec: corev1.ServiceSpec{
+			Type:  corev1.ServiceTypeLoadBalancer,
+			Ports: []corev1.ServicePort{{Port: 443}},
+		},
+	}
+}
+
+func TestSecretCertificates_SecretUpdated(t *testing.T) {
+	services := []runtime.Object{
+		newTestSecretService("web", map[string]string{
+			string(annotation.LBSvcHTTPCertificateSecrets): "web-tls,other-tls",
+		}),
+		newTestSecretService("api", map[string]string{
+			string(annotation.LBSvcHTTPCertificateSecrets.ForPort(443)): "web-tls",
+		}),
+		newTestSecretService("other", map[string]string{
+			string(annotation.LBSvcHTTPCertificateSecrets): "other-tls",
+		}),
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-tls", Namespace: "default"},
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("crt")},
+	}
+	renewed := secret.DeepCopy()
+	renewed.Data[corev1.TLSCertKey] = []byte("renewed crt")
+
+	tests := []struct {
+		name    string
+		handle  func(sc *secretCertificates)
+		expKeys []string
+	}{
+		{
+			name:    "enqueue services of changed secret",
+			handle:  func(sc *secretCertificates) { sc.secretUpdated(secret, renewed) },
+			expKeys: []string{"default/api", "default/web"},
+		},
+		{
+			name:   "ignore unchanged secret",
+			handle: func(sc *secretCertificates) { sc.secretUpdated(secret, secret) },
+		},
+		{
+			name:    "enqueue services of secret created after service",
+			handle:  func(sc *secretCertificates) { sc.enqueueServices(secret) },
+			expKeys: []string{"default/api", "default/web"},
+		},
+		{
+			name: "enqueue services of deleted secret",
+			handle: func(sc *secretCertificates) {
+				sc.enqueueServices(cache.DeletedFinalStateUnknown{Key: "default/web-tls", Obj: secret})
+			},
+			expKeys: []string{"default/api", "default/web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &secretCertificates{
+				serviceLister: corelisters.NewServiceLister(newTestIndexer(t, services...)),
+				queue:         newServiceQueue("test", nil),
+			}
+			defer sc.queue.ShutDown()
+
+			tt.handle(sc)
+
+			var keys []string
+			for sc.queue.Len() > 0 {
+				key, _ := sc.queue.Get()
+				keys = append(keys, key)
+				sc.queue.Done(key)
+			}
+			assert.ElementsMatch(t, tt.expKeys, keys)
+		})
+	}
+}
+
+func TestSecretCertificates_Reconcile(t *testing.T) {
+	ctx := context.Background()
+	svc := newTestSecretService("web", map[string]string{
+		string(annotation.LBSvcHTTPCertificateSecrets): "web-tls",
+	})
+	lb := &hcloud.LoadBalancer{ID: 1}
+
+	lbOps := &hcops.MockLoadBalancerOps{}
+	lbOps.Test(t)
+	lbOps.On("GetByK8SServiceUID", ctx, svc).Return(lb, nil)
+	lbOps.On("ReconcileHCLBServices", ctx, lb, svc).Return(true, nil)
+
+	sc := &secretCertificates{
+		loadBalancers: &loadBalancers{lbOps: lbOps},
+		serviceLister: corelisters.NewServiceLister(newTestIndexer(t, svc)),
+	}
+	require.NoError(t, sc.reconcile(ctx, "default/web"))
+	require.NoError(t, sc.reconcile(ctx, "default/deleted"))
+	lbOps.AssertExpectations(t)
+}
@@ -0,0 +1,208 @@
+package hcloud
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/syself/hetzner-cloud-controller-manager/internal/providerid"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/informers"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/klog/v2"
+)
+
+// serviceIPQueue queues the Services exposed by an IP which is moved between
+// their nodes, whenever the readiness of the nodes or the endpoints of the
+// Services change. The service controller does not reconcile the Services in
+// these cases.
+type serviceIPQueue struct {
+	// serviceQueue holds the keys of the Services whose IP may have to be
+	// moved, and moves their IPs.
+	*serviceQueue
+
+	// uses reports whether a Service is exposed by the IPs of the queue.
+	uses func(svc *corev1.Service) bool
+
+	serviceLister corelisters.ServiceLister
+}
+
+func newServiceIPQueue(
+	name string, uses func(*corev1.Service) bool, reconcile func(context.Context, string) error,
+	informerFactory informers.SharedInformerFactory,
+) (*serviceIPQueue, error) {
+	services := informerFactory.Core().V1().Services()
+	nodes := informerFactory.Core().V1().Nodes()
+	endpointSlices := informerFactory.Discovery().V1().EndpointSlices()
+
+	q := &serviceIPQueue{
+		serviceQueue:  newServiceQueue(name, reconcile),
+		uses:          uses,
+		serviceLister: services.Lister(),
+	}
+
+	_, err := services.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    q.enqueueService,
+		UpdateFunc: func(_, obj interface{}) { q.enqueueService(obj) },
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = nodes.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(_ interface{}) { q.enqueueAll() },
+		UpdateFunc: q.nodeUpdated,
+		DeleteFunc: func(_ interface{}) { q.enqueueAll() },
+	})
+	if err != nil {
+		return nil, err
+	}
+	_, err = endpointSlices.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    q.enqueueEndpointSlice,
+		UpdateFunc: func(_, obj interface{}) { q.enqueueEndpointSlice(obj) },
+		DeleteFunc: q.enqueueEndpointSlice,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// enqueueService adds the Service obj to the queue, if it is exposed by the
+// IPs of the queue.
+func (q *serviceIPQueue) enqueueService(obj interface{}) {
+	svc, ok := obj.(*corev1.Service)
+	if !ok || !q.uses(svc) {
+		return
+	}
+	q.Add(svc.Namespace + "/" + svc.Name)
+}
+
+// enqueueEndpointSlice adds the Service of the EndpointSlice obj to the
+// queue.
+func (q *serviceIPQueue) enqueueEndpointSlice(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	slice, ok := obj.(*discoveryv1.EndpointSlice)
+	if !ok {
+		return
+	}
+	name := slice.Labels[discoveryv1.LabelServiceName]
+	if name == "" {
+		return
+	}
+	q.Add(slice.Namespace + "/" + name)
+}
+
+// nodeUpdated adds all Services exposed by the IPs of the queue to the queue,
+// if the readiness of the node changed.
+func (q *serviceIPQueue) nodeUpdated(oldObj, newObj interface{}) {
+	oldNode, ok := oldObj.(*corev1.Node)
+	if !ok {
+		return
+	}
+	node, ok := newObj.(*corev1.Node)
+	if !ok {
+		return
+	}
+	if nodeReady(oldNode) == nodeReady(node) {
+		return
+	}
+	q.enqueueAll()
+}
+
+// enqueueAll adds all Services exposed by the IPs of the queue to the queue.
+func (q *serviceIPQueue) enqueueAll() {
+	services, err := q.serviceLister.List(labels.Everything())
+	if err != nil {
+		klog.ErrorS(err, "list services")
+		return
+	}
+	for _, svc := range services {
+		q.enqueueService(svc)
+	}
+}
+
+// serviceIPTargetNodes returns the Ready nodes running a ready endpoint of
+// svc, sorted by name. If cloudServers is true, only Hetzner Cloud nodes are
+// returned, otherwise only Robot nodes.
+func serviceIPTargetNodes(
+	nodeLister corelisters.NodeLister, endpointSliceLister discoverylisters.EndpointSliceLister,
+	svc *corev1.Service, cloudServers bool,
+) ([]*corev1.Node, error) {
+	nodes, err := nodeLister.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	nodes, err = matchNodeSelector(svc, lbCandidateNodes(nodes))
+	if err != nil {
+		return nil, err
+	}
+	endpointNodes, err := nodesWithEndpoints(endpointSliceLister, svc, false)
+	if err != nil {
+		return nil, err
+	}
+	nodes = slices.DeleteFunc(nodes, func(n *corev1.Node) bool {
+		if !endpointNodes[n.Name] || !nodeReady(n) || n.DeletionTimestamp != nil {
+			return true
+		}
+		_, isCloudServer, err := providerid.ToServerID(n.Spec.ProviderID)
+		return err != nil || isCloudServer != cloudServers
+	})
+	slices.SortFunc(nodes, func(a, b *corev1.Node) int { return strings.Compare(a.Name, b.Name) })
+	return nodes, nil
+}
+
+// reportServiceIPStatus sets the Load Balancer status of svc to status.
+//
+// Only the status of Services with a loadBalancerClass is reported. The
+// status of the other Services is reported by the service controller.
+func reportServiceIPStatus(
+	ctx context.Context, serviceClient corev1client.ServicesGetter,
+	svc *corev1.Service, status *corev1.LoadBalancerStatus,
+) error {
+	if svc.Spec.LoadBalancerClass == nil || serviceClient == nil {
+		return nil
+	}
+	if equalIngress(svc.Status.LoadBalancer.Ingress, status.Ingress) {
+		return nil
+	}
+	patch, err := json.Marshal(map[string]any{
+		"status": map[string]any{"loadBalancer": status},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = serviceClient.Services(svc.Namespace).Patch(
+		ctx, svc.Name, types.MergePatchType, patch, metav1.PatchOptions{}, "status")
+	if err != nil {
+		return fmt.Errorf("report status: %w", err)
+	}
+	return nil
+}
+
+// equalIngress reports whether a and b contain the same IPs.
+func equalIngress(a, b []corev1.LoadBalancerIngress) bool {
+	return slices.EqualFunc(a, b, func(x, y corev1.LoadBalancerIngress) bool {
+		return x.IP == y.IP && x.Hostname == y.Hostname
+	})
+}
+
+// nodeReady reports whether the NodeReady condition of node is true.
+func nodeReady(node *corev1.Node) bool {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
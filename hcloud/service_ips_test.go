@@ -0,0 +1,91 @@
+package hcloud
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	discoverylisters "k8s.io/client-go/listers/discovery/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func newTestNode(name, providerID string, ready bool) *corev1.Node {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       corev1.NodeSpec{ProviderID: providerID},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+		},
+	}
+}
+
+func newTestRobotNode(name string, serverNumber int, ready bool) *corev1.Node {
+	return newTestNode(name, fmt.Sprintf("hrobot://%d", serverNumber), ready)
+}
+
+func newTestCloudNode(name string, serverID int64, ready bool) *corev1.Node {
+	return newTestNode(name, fmt.Sprintf("hcloud://%d", serverID), ready)
+}
+
+// serviceIPTest holds the Service default/web exposed by the IP 1.2.3.4 and
+// the listers and client a service IP controller reconciles it with. node1
+// and node2 run a ready endpoint of the Service, node3 runs one that is not
+// ready.
+type serviceIPTest struct {
+	ctx                 context.Context
+	client              *fake.Clientset
+	recorder            *record.FakeRecorder
+	serviceLister       corelisters.ServiceLister
+	nodeLister          corelisters.NodeLister
+	endpointSliceLister discoverylisters.EndpointSliceLister
+}
+
+func newServiceIPTest(t *testing.T, class string, nodes ...runtime.Object) *serviceIPTest {
+	svc := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: corev1.ServiceSpec{
+			Type:              corev1.ServiceTypeLoadBalancer,
+			LoadBalancerClass: hcloud.Ptr(class),
+			LoadBalancerIP:    "1.2.3.4",
+		},
+	}
+	endpointSlice := newTestEndpointSlice("web-1", "web",
+		newTestEndpoint("node1", hcloud.Ptr(true)),
+		newTestEndpoint("node2", hcloud.Ptr(true)),
+		newTestEndpoint("node3", hcloud.Ptr(false)),
+	)
+
+	return &serviceIPTest{
+		ctx:                 context.Background(),
+		client:              fake.NewClientset(svc.DeepCopy()),
+		recorder:            record.NewFakeRecorder(10),
+		serviceLister:       corelisters.NewServiceLister(newTestIndexer(t, svc)),
+		nodeLister:          corelisters.NewNodeLister(newTestIndexer(t, nodes...)),
+		endpointSliceLister: discoverylisters.NewEndpointSliceLister(newTestIndexer(t, endpointSlice)),
+	}
+}
+
+// assertReconciled asserts that expEvent, if any, is the only recorded event,
+// and that the status of the Service reports the IP.
+func (st *serviceIPTest) assertReconciled(t *testing.T, expEvent string) {
+	if expEvent != "" {
+		assert.Equal(t, expEvent, <-st.recorder.Events)
+	}
+	assert.Empty(t, st.recorder.Events)
+
+	updated, err := st.client.CoreV1().Services("default").Get(st.ctx, "web", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, []corev1.LoadBalancerIngress{{IP: "1.2.3.4"}}, updated.Status.LoadBalancer.Ingress)
+}
@@ -0,0 +1,68 @@
+package hcloud
+
+import (
+	"context"
+	"errors"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/cloud-provider/api"
+	"k8s.io/klog/v2"
+)
+
+// serviceQueue holds the namespace/name keys of Services and reconciles them
+// one after the other. Failed keys are retried with backoff, or after the
+// delay of an api.RetryError.
+type serviceQueue struct {
+	workqueue.TypedRateLimitingInterface[string]
+
+	// name identifies the queue in metrics and logs.
+	name string
+
+	// reconcile reconciles the Service key.
+	reconcile func(ctx context.Context, key string) error
+}
+
+func newServiceQueue(name string, reconcile func(context.Context, string) error) *serviceQueue {
+	return &serviceQueue{
+		TypedRateLimitingInterface: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.DefaultTypedControllerRateLimiter[string](),
+			workqueue.TypedRateLimitingQueueConfig[string]{Name: name},
+		),
+		name:      name,
+		reconcile: reconcile,
+	}
+}
+
+// run processes the queue until stop is closed.
+func (q *serviceQueue) run(stop <-chan struct{}) {
+	ctx := wait.ContextForChannel(stop)
+	go func() {
+		<-stop
+		q.ShutDown()
+	}()
+
+	for q.processNextItem(ctx) {
+	}
+}
+
+func (q *serviceQueue) processNextItem(ctx context.Context) bool {
+	key, quit := q.Get()
+	if quit {
+		return false
+	}
+	defer q.Done(key)
+
+	if err := q.reconcile(ctx, key); err != nil {
+		klog.ErrorS(err, "reconcile service", "queue", q.name, "service", key)
+		var retryErr *api.RetryError
+		if errors.As(err, &retryErr) {
+			q.AddAfter(key, retryErr.RetryAfter())
+			return true
+		}
+		q.AddRateLimited(key)
+		return true
+	}
+	q.Forget(key)
+	return true
+}
@@ -0,0 +1,51 @@
+package hcloud
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/cloud-provider/api"
+)
+
+func TestServiceQueue_ProcessNextItem(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		expRequeues int
+		expRequeued bool
+	}{
+		{
+			name: "forget reconciled service",
+		},
+		{
+			name:        "retry failed service with backoff",
+			err:         errors.New("test error"),
+			expRequeues: 1,
+			expRequeued: true,
+		},
+		{
+			name:        "retry service after delay of retry error",
+			err:         api.NewRetryError("test retry", 10*time.Millisecond),
+			expRequeued: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newServiceQueue("test", func(context.Context, string) error { return tt.err })
+			defer q.ShutDown()
+
+			q.Add("default/web")
+			assert.True(t, q.processNextItem(context.Background()))
+			assert.Equal(t, tt.expRequeues, q.NumRequeues("default/web"))
+			if tt.expRequeued {
+				assert.Eventually(t, func() bool { return q.Len() == 1 }, time.Second, time.Millisecond)
+			} else {
+				assert.Equal(t, 0, q.Len())
+			}
+		})
+	}
+}
@@ -12,6 +12,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 )
 
 // Setenv prepares the environment for testing the
@@ -75,6 +76,7 @@ type LoadBalancerTestCase struct {
 	ServiceAnnotations           map[annotation.Name]interface{}
 	DisablePrivateIngressDefault bool
 	DisableIPv6Default           bool
+	DryRunDefault                bool
 	Nodes                        []*corev1.Node
 	LB                           *hcloud.LoadBalancer
 	LBCreateResult               *hcloud.LoadBalancerCreateResult
@@ -90,6 +92,7 @@ type LoadBalancerTestCase struct {
 	LBClient      *mocks.LoadBalancerClient
 	ActionClient  *mocks.ActionClient
 	LoadBalancers *loadBalancers
+	Recorder      *record.FakeRecorder
 	Service       *corev1.Service
 }
 
@@ -124,7 +127,11 @@ func (tt *LoadBalancerTestCase) run(t *testing.T) {
 		tt.Mock(t, tt)
 	}
 
-	tt.LoadBalancers = newLoadBalancers(tt.LBOps, tt.ActionClient, tt.DisablePrivateIngressDefault, tt.DisableIPv6Default)
+	tt.Recorder = record.NewFakeRecorder(100)
+	tt.LoadBalancers = newLoadBalancers(
+		tt.LBOps, tt.ActionClient, tt.Recorder,
+		tt.DisablePrivateIngressDefault, tt.DisableIPv6Default, tt.DryRunDefault,
+	)
 	tt.Perform(t, tt)
 
 	tt.LBOps.AssertExpectations(t)
@@ -23,8 +23,8 @@ import (
 	"strings"
 
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
-	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/metrics"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/providerid"
 	robotclient "github.com/syself/hetzner-cloud-controller-manager/internal/robot/client"
 	"github.com/syself/hrobot-go/models"
 	corev1 "k8s.io/api/core/v1"
@@ -60,14 +60,8 @@ func getRobotServerByName(c robotclient.Client, node *corev1.Node) (server *mode
 		return nil, errMissingRobotCredentials
 	}
 
-	// check for rate limit
-	if hcops.IsRateLimitExceeded(node) {
-		return nil, fmt.Errorf("%s: rate limit exceeded - next try at %q", op, hcops.TimeOfNextPossibleAPICall().String())
-	}
-
 	serverList, err := c.ServerGetList()
 	if err != nil {
-		hcops.HandleRateLimitExceededError(err, node)
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -90,17 +84,11 @@ func getRobotServerByID(c robotclient.Client, id int, node *corev1.Node) (s *mod
 		return nil, errMissingRobotCredentials
 	}
 
-	// check for rate limit
-	if hcops.IsRateLimitExceeded(node) {
-		return nil, fmt.Errorf("%s: rate limit exceeded - next try at %q", op, hcops.TimeOfNextPossibleAPICall().String())
-	}
-
 	server, err := c.ServerGet(id)
 	if models.IsError(err, models.ErrorCodeServerNotFound) {
 		return nil, nil
 	}
 	if err != nil {
-		hcops.HandleRateLimitExceededError(err, node)
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
@@ -120,6 +108,18 @@ func isHCloudServerByName(name string) bool {
 	return !strings.HasPrefix(name, hostNamePrefixRobot)
 }
 
+// isHCloudServer reports whether node is a Hetzner Cloud server. The scheme of
+// the provider ID of node decides, if it has a valid one. Otherwise the name
+// of node decides.
+func isHCloudServer(node *corev1.Node) bool {
+	if node.Spec.ProviderID != "" {
+		if _, isCloudServer, err := providerid.ToServerID(node.Spec.ProviderID); err == nil {
+			return isCloudServer
+		}
+	}
+	return isHCloudServerByName(node.Name)
+}
+
 func getInstanceTypeOfRobotServer(bmServer *models.Server) string {
 	if bmServer == nil {
 		panic("getInstanceTypeOfRobotServer called with nil server")
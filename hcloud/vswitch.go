@@ -0,0 +1,74 @@
+package hcloud
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/metrics"
+	"golang.org/x/sync/singleflight"
+)
+
+// vSwitchSubnetsMaxAge is the time the vSwitch subnets of a network are
+// cached. They only change if a vSwitch is coupled to or decoupled from the
+// network.
+const vSwitchSubnetsMaxAge = time.Minute
+
+// vSwitchSubnetCache caches the vSwitch subnets of networks, so that they are
+// not loaded for every Robot node.
+type vSwitchSubnetCache struct {
+	client *hcloud.Client
+	group  singleflight.Group
+
+	mu      sync.Mutex // protects entries
+	entries map[int64]vSwitchSubnetEntry
+}
+
+type vSwitchSubnetEntry struct {
+	subnets []*net.IPNet
+	loaded  time.Time
+}
+
+func newVSwitchSubnetCache(client *hcloud.Client) *vSwitchSubnetCache {
+	return &vSwitchSubnetCache{client: client, entries: make(map[int64]vSwitchSubnetEntry)}
+}
+
+// get returns the IP ranges of the subnets which couple the network to a
+// Robot vSwitch. They are reloaded if they are older than
+// vSwitchSubnetsMaxAge, concurrent reloads are deduplicated.
+func (c *vSwitchSubnetCache) get(ctx context.Context, networkID int64) ([]*net.IPNet, error) {
+	const op = "hcloud/vSwitchSubnetCache.get"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	c.mu.Lock()
+	entry, ok := c.entries[networkID]
+	c.mu.Unlock()
+	if ok && time.Since(entry.loaded) < vSwitchSubnetsMaxAge {
+		return entry.subnets, nil
+	}
+
+	v, err, _ := c.group.Do(strconv.FormatInt(networkID, 10), func() (interface{}, error) {
+		network, _, err := c.client.Network.GetByID(ctx, networkID)
+		if err != nil {
+			return nil, err
+		}
+		if network == nil {
+			return nil, fmt.Errorf("network %d not found", networkID)
+		}
+
+		subnets := hcops.VSwitchSubnets(network)
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.entries[networkID] = vSwitchSubnetEntry{subnets: subnets, loaded: time.Now()}
+		return subnets, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return v.([]*net.IPNet), nil
+}
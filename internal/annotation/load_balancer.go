@@ -10,7 +10,9 @@ import (
 
 const (
 	// LBID is the ID assigned to the Hetzner Cloud Load Balancer by the
-	// backend. Read-only.
+	// backend. If a Service has no Load Balancer yet, the Load Balancer with
+	// this ID is adopted, unless it is owned by another Service of the
+	// cluster.
 	LBID Name = "load-balancer.hetzner.cloud/id"
 
 	// LBPublicIPv4 is the public IPv4 address assigned to the Load Balancer by
@@ -60,6 +62,10 @@ const (
 
 	// LBSvcProtocol specifies the protocol of the service. Default: tcp, Possible
 	// values: tcp, http, https
+	//
+	// If not set, the protocol is derived from the appProtocol of the port:
+	// http for http, https for https if certificates are configured, tcp
+	// otherwise.
 	LBSvcProtocol Name = "load-balancer.hetzner.cloud/protocol"
 
 	// LBAlgorithmType specifies the algorithm type of the Load Balancer.
@@ -97,6 +103,19 @@ const (
 	// Mutually exclusive with LBLocation.
 	LBNetworkZone Name = "load-balancer.hetzner.cloud/network-zone"
 
+	// LBNetwork is the name or ID of the Hetzner Cloud Network the Load
+	// Balancer is attached to. It is detached from all other networks.
+	//
+	// Default: the network of the cloud controller manager (HCLOUD_NETWORK).
+	LBNetwork Name = "load-balancer.hetzner.cloud/network"
+
+	// LBDryRun only records the changes required to reconcile the Load
+	// Balancer as event on the Service instead of applying them.
+	//
+	// Default: the dry-run mode of the cloud controller manager
+	// (HCLOUD_LOAD_BALANCERS_DRY_RUN).
+	LBDryRun Name = "load-balancer.hetzner.cloud/dry-run"
+
 	// LBNodeSelector can be set to restrict which Nodes are added as targets to the
 	// Load Balancer. It accepts a Kubernetes label selector string, using either the
 	// set-based or equality-based formats.
@@ -107,6 +126,37 @@ const (
 	// Format: https://kubernetes.io/docs/concepts/overview/working-with-objects/labels/#label-selectors
 	LBNodeSelector Name = "load-balancer.hetzner.cloud/node-selector"
 
+	// LBRobotFailoverIP is a failover IP of the Robot account which is routed
+	// to a Robot node running a ready endpoint of the Service, instead of
+	// creating a Hetzner Cloud Load Balancer. The IP is rerouted whenever the
+	// node becomes NotReady or no longer runs a ready endpoint.
+	//
+	// LBNodeSelector restricts the nodes the IP is routed to.
+	LBRobotFailoverIP Name = "load-balancer.hetzner.cloud/robot-failover-ip"
+
+	// LBFloatingIP is the ID, name or address of a Hetzner Cloud Floating IP
+	// which is assigned to a cloud node running a ready endpoint of the
+	// Service, instead of creating a Hetzner Cloud Load Balancer. The IP is
+	// reassigned whenever the node becomes NotReady or no longer runs a ready
+	// endpoint.
+	//
+	// LBNodeSelector restricts the nodes the IP is assigned to.
+	LBFloatingIP Name = "load-balancer.hetzner.cloud/floating-ip"
+
+	// LBTargetType specifies how the Nodes are added as targets to the Load
+	// Balancer.
+	//
+	// Possible values: server, label_selector
+	//
+	// With server, every cloud server is a separate target. With
+	// label_selector, the cloud servers are labeled and selected by a single
+	// label selector target, so that adding or removing Nodes requires no
+	// changes to the Load Balancer. Dedicated servers are always added as IP
+	// targets.
+	//
+	// Default: server.
+	LBTargetType Name = "load-balancer.hetzner.cloud/target-type"
+
 	// LBSvcProxyProtocol specifies if the Load Balancer services should
 	// use the proxy protocol.
 	//
@@ -137,6 +187,15 @@ const (
 	// HTTPS only.
 	LBSvcHTTPCertificates Name = "load-balancer.hetzner.cloud/http-certificates"
 
+	// LBSvcHTTPCertificateSecrets is a comma separated list of the names of
+	// kubernetes.io/tls Secrets in the namespace of the Service. The
+	// certificates of the Secrets are uploaded to Hetzner Cloud and assigned
+	// to the service in addition to LBSvcHTTPCertificates. They are replaced
+	// whenever the Secrets change.
+	//
+	// HTTPS only.
+	LBSvcHTTPCertificateSecrets Name = "load-balancer.hetzner.cloud/http-certificate-secrets"
+
 	// LBSvcHTTPManagedCertificateName contains the names of the managed
 	// certificate to create by the Cloud Controller manager. Ignored if
 	// LBSvcHTTPCertificateType is missing or set to "uploaded". Optional.
@@ -153,7 +212,8 @@ const (
 	// LBSvcHTTPManagedCertificateDomains contains a coma separated list of the
 	// domain names of the managed certificate.
 	//
-	// All domains are used to create a single managed certificate.
+	// All domains are used to create a single managed certificate. If the
+	// domains change, a new certificate replaces the previous one.
 	LBSvcHTTPManagedCertificateDomains Name = "load-balancer.hetzner.cloud/http-managed-certificate-domains"
 
 	// LBSvcRedirectHTTP create a redirect from HTTP to HTTPS. HTTPS only.
@@ -177,6 +237,15 @@ const (
 	// on.
 	LBSvcHealthCheckPort Name = "load-balancer.hetzner.cloud/health-check-port"
 
+	// LBSvcHealthCheckFromReadinessProbe derives the health check from the
+	// HTTP readiness probe of the pods of the Service, if set to true. The
+	// probe is reached through the node port of the Service port targeting the
+	// probe port. The other health check annotations override the derived
+	// options.
+	//
+	// Default: false.
+	LBSvcHealthCheckFromReadinessProbe Name = "load-balancer.hetzner.cloud/health-check-from-readiness-probe"
+
 	// LBSvcHealthCheckInterval specifies the interval in which time we perform
 	// a health check in seconds.
 	LBSvcHealthCheckInterval Name = "load-balancer.hetzner.cloud/health-check-interval"
@@ -59,6 +59,8 @@ func (s Name) AnnotateService(svc *corev1.Service, v interface{}) error {
 		svc.Annotations[k] = string(vt)
 	case hcloud.LoadBalancerServiceProtocol:
 		svc.Annotations[k] = string(vt)
+	case hcloud.LoadBalancerTargetType:
+		svc.Annotations[k] = string(vt)
 	case fmt.Stringer:
 		svc.Annotations[k] = vt.String()
 	default:
@@ -255,6 +257,29 @@ func (s Name) LBAlgorithmTypeFromService(svc *corev1.Service) (hcloud.LoadBalanc
 	return alg, err
 }
 
+// LBTargetTypeFromService retrieves the hcloud.LoadBalancerTargetType value
+// belonging to the annotation from svc.
+//
+// LBTargetTypeFromService returns an error if the value is neither
+// hcloud.LoadBalancerTargetTypeServer nor
+// hcloud.LoadBalancerTargetTypeLabelSelector, or the annotation was not set.
+// In the case of a missing value, the error wraps ErrNotSet.
+func (s Name) LBTargetTypeFromService(svc *corev1.Service) (hcloud.LoadBalancerTargetType, error) {
+	const op = "annotation/Name.LBTargetTypeFromService"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	var tt hcloud.LoadBalancerTargetType
+
+	err := s.applyToValue(op, svc, func(v string) error {
+		var err error
+
+		tt, err = validateTargetType(v)
+		return err
+	})
+
+	return tt, err
+}
+
 // NetworkZoneFromService retrieves the hcloud.NetworkZone value belonging to
 // the annotation from svc.
 //
@@ -361,6 +386,22 @@ func validateAlgorithmType(algorithmType string) (hcloud.LoadBalancerAlgorithmTy
 	return hcloudAlgorithmType, nil
 }
 
+func validateTargetType(targetType string) (hcloud.LoadBalancerTargetType, error) {
+	const op = "annotation/validateTargetType"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	hcloudTargetType := hcloud.LoadBalancerTargetType(strings.ToLower(targetType))
+
+	switch hcloudTargetType {
+	case hcloud.LoadBalancerTargetTypeServer:
+	case hcloud.LoadBalancerTargetTypeLabelSelector:
+	default:
+		return "", fmt.Errorf("%s: invalid: %s", op, targetType)
+	}
+
+	return hcloudTargetType, nil
+}
+
 func validateServiceProtocol(protocol string) (hcloud.LoadBalancerServiceProtocol, error) {
 	const op = "annotation/validateServiceProtocol"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
@@ -351,6 +351,33 @@ func TestName_LBAlgorithmTypeFromService(t *testing.T) {
 	})
 }
 
+func TestName_LBTargetTypeFromService(t *testing.T) {
+	tests := []typedAccessorTest{
+		{
+			name: "value set",
+			svcAnnotations: map[annotation.Name]interface{}{
+				ann: hcloud.LoadBalancerTargetTypeLabelSelector,
+			},
+			expected: hcloud.LoadBalancerTargetTypeLabelSelector,
+		},
+		{
+			name: "value not set",
+			err:  annotation.ErrNotSet,
+		},
+		{
+			name: "ip targets can not be selected",
+			svcAnnotations: map[annotation.Name]interface{}{
+				ann: hcloud.LoadBalancerTargetTypeIP,
+			},
+			err: errors.New("annotation/Name.LBTargetTypeFromService: annotation/validateTargetType: invalid: ip"),
+		},
+	}
+
+	runAllTypedAccessorTests(t, tests, func(svc *corev1.Service) (interface{}, error) {
+		return ann.LBTargetTypeFromService(svc)
+	})
+}
+
 func TestName_NetworkZoneFromService(t *testing.T) {
 	tests := []typedAccessorTest{
 		{
@@ -0,0 +1,71 @@
+package annotation
+
+import (
+	"fmt"
+	"maps"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// portPrefix is the prefix of the per-port annotations, e.g.
+// load-balancer.hetzner.cloud/port.443.protocol.
+const portPrefix = "load-balancer.hetzner.cloud/port."
+
+// portNames are the annotations configuring a single service of the Load
+// Balancer. They can be set for a single port of the K8S Service.
+var portNames = []Name{
+	LBSvcProtocol,
+	LBSvcProxyProtocol,
+	LBSvcHTTPCookieName,
+	LBSvcHTTPCookieLifetime,
+	LBSvcHTTPCertificateType,
+	LBSvcHTTPCertificates,
+	LBSvcHTTPCertificateSecrets,
+	LBSvcRedirectHTTP,
+	LBSvcHTTPStickySessions,
+	LBSvcHealthCheckProtocol,
+	LBSvcHealthCheckPort,
+	LBSvcHealthCheckFromReadinessProbe,
+	LBSvcHealthCheckInterval,
+	LBSvcHealthCheckTimeout,
+	LBSvcHealthCheckRetries,
+	LBSvcHealthCheckHTTPDomain,
+	LBSvcHealthCheckHTTPPath,
+	LBSvcHealthCheckHTTPValidateCertificate,
+	LBSvcHealthCheckHTTPStatusCodes,
+}
+
+// ForPort returns the annotation overriding s for the port of the K8S
+// Service, e.g. load-balancer.hetzner.cloud/port.443.protocol for
+// LBSvcProtocol and port 443.
+func (s Name) ForPort(port int32) Name {
+	_, suffix, _ := strings.Cut(string(s), "/")
+	return Name(fmt.Sprintf("%s%d.%s", portPrefix, port, suffix))
+}
+
+// ServiceForPort returns svc with the Service-wide annotations replaced by
+// the per-port annotations of port.
+//
+// svc is returned unchanged if it has no per-port annotations for port.
+// Otherwise, ServiceForPort returns a copy of svc.
+func ServiceForPort(svc *corev1.Service, port int32) *corev1.Service {
+	var annotations map[string]string
+	for _, n := range portNames {
+		v, ok := svc.Annotations[string(n.ForPort(port))]
+		if !ok {
+			continue
+		}
+		if annotations == nil {
+			annotations = maps.Clone(svc.Annotations)
+		}
+		annotations[string(n)] = v
+	}
+	if annotations == nil {
+		return svc
+	}
+
+	portSvc := svc.DeepCopy()
+	portSvc.Annotations = annotations
+	return portSvc
+}
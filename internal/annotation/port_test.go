@@ -0,0 +1,77 @@
+package annotation_test
+
+import (
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/annotation"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestName_ForPort(t *testing.T) {
+	assert.Equal(t,
+		annotation.Name("load-balancer.hetzner.cloud/port.443.protocol"),
+		annotation.LBSvcProtocol.ForPort(443))
+}
+
+func TestServiceForPort(t *testing.T) {
+	tests := []struct {
+		name           string
+		svcAnnotations map[annotation.Name]interface{}
+		port           int32
+		expected       map[annotation.Name]interface{}
+	}{
+		{
+			name: "no per-port annotations",
+			svcAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcProtocol: hcloud.LoadBalancerServiceProtocolHTTP,
+			},
+			port: 443,
+			expected: map[annotation.Name]interface{}{
+				annotation.LBSvcProtocol: hcloud.LoadBalancerServiceProtocolHTTP,
+			},
+		},
+		{
+			name: "per-port annotations override service-wide annotations",
+			svcAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcProtocol:                      hcloud.LoadBalancerServiceProtocolHTTP,
+				annotation.LBSvcProtocol.ForPort(443):         hcloud.LoadBalancerServiceProtocolHTTPS,
+				annotation.LBSvcHTTPCertificates.ForPort(443): []string{"1"},
+			},
+			port: 443,
+			expected: map[annotation.Name]interface{}{
+				annotation.LBSvcProtocol:         hcloud.LoadBalancerServiceProtocolHTTPS,
+				annotation.LBSvcHTTPCertificates: []string{"1"},
+			},
+		},
+		{
+			name: "per-port annotations of other ports are ignored",
+			svcAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcProtocol:              hcloud.LoadBalancerServiceProtocolHTTP,
+				annotation.LBSvcProtocol.ForPort(443): hcloud.LoadBalancerServiceProtocolHTTPS,
+			},
+			port: 80,
+			expected: map[annotation.Name]interface{}{
+				annotation.LBSvcProtocol: hcloud.LoadBalancerServiceProtocolHTTP,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var svc corev1.Service
+
+			for k, v := range tt.svcAnnotations {
+				if err := k.AnnotateService(&svc, v); err != nil {
+					t.Error(err)
+				}
+			}
+			original := svc.DeepCopy()
+
+			portSvc := annotation.ServiceForPort(&svc, tt.port)
+			annotation.AssertServiceAnnotated(t, portSvc, tt.expected)
+			assert.Equal(t, original, &svc, "service changed")
+		})
+	}
+}
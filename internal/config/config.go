@@ -0,0 +1,449 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// APIVersion is the version of the configuration file format understood
+	// by this controller.
+	APIVersion = "hcloud-ccm.syself.com/v1alpha1"
+
+	// Kind is the kind of the configuration file.
+	Kind = "HCCMConfiguration"
+)
+
+// Environment variables which override the values from the configuration
+// file. Credentials (HCLOUD_TOKEN, ROBOT_USER_NAME, ROBOT_PASSWORD) are not
+// part of the configuration file, they are read from the mounted secret or
+// the environment.
+const (
+	hcloudEndpoint = "HCLOUD_ENDPOINT"
+	hcloudDebug    = "HCLOUD_DEBUG"
+
+	robotEndpoint          = "ROBOT_ENDPOINT"
+	robotDebug             = "ROBOT_DEBUG"
+	robotCacheTimeout      = "CACHE_TIMEOUT"
+	robotRateLimitWaitTime = "RATE_LIMIT_WAIT_TIME_ROBOT"
+	robotProviderIDFormat  = "ROBOT_PROVIDER_ID_FORMAT"
+
+	metricsEnabled = "HCLOUD_METRICS_ENABLED"
+
+	instancesAddressFamily = "HCLOUD_INSTANCES_ADDRESS_FAMILY"
+
+	network                     = "HCLOUD_NETWORK"
+	networkDisableAttachedCheck = "HCLOUD_NETWORK_DISABLE_ATTACHED_CHECK"
+	networkRoutesEnabled        = "HCLOUD_NETWORK_ROUTES_ENABLED"
+	networkRoutesClusterCIDR    = "HCLOUD_NETWORK_ROUTES_CLUSTER_CIDR"
+	networkRoutesGCEnabled      = "HCLOUD_NETWORK_ROUTES_GC_ENABLED"
+	networkRoutesGCDryRun       = "HCLOUD_NETWORK_ROUTES_GC_DRY_RUN"
+	networkRoutesGCInterval     = "HCLOUD_NETWORK_ROUTES_GC_INTERVAL"
+
+	loadBalancersEnabled               = "HCLOUD_LOAD_BALANCERS_ENABLED"
+	loadBalancersLocation              = "HCLOUD_LOAD_BALANCERS_LOCATION"
+	loadBalancersNetworkZone           = "HCLOUD_LOAD_BALANCERS_NETWORK_ZONE"
+	loadBalancersDisablePrivateIngress = "HCLOUD_LOAD_BALANCERS_DISABLE_PRIVATE_INGRESS"
+	loadBalancersUsePrivateIP          = "HCLOUD_LOAD_BALANCERS_USE_PRIVATE_IP"
+	loadBalancersDisableIPv6           = "HCLOUD_LOAD_BALANCERS_DISABLE_IPV6"
+	loadBalancersDryRun                = "HCLOUD_LOAD_BALANCERS_DRY_RUN"
+	loadBalancersTargetWorkers         = "HCLOUD_LOAD_BALANCERS_TARGET_WORKERS"
+	loadBalancersClass                 = "HCLOUD_LOAD_BALANCERS_CLASS"
+)
+
+// Possible values of InstanceConfiguration.AddressFamily.
+const (
+	AddressFamilyIPv4      = "ipv4"
+	AddressFamilyIPv6      = "ipv6"
+	AddressFamilyDualStack = "dualstack"
+)
+
+// Possible values of RobotConfiguration.ProviderIDFormat.
+const (
+	// RobotProviderIDFormatLegacy generates provider IDs of the form
+	// "hcloud://bm-<server-number>".
+	RobotProviderIDFormatLegacy = "legacy"
+	// RobotProviderIDFormatHRobot generates provider IDs of the form
+	// "hrobot://<server-number>", as used by the upstream
+	// hcloud-cloud-controller-manager.
+	RobotProviderIDFormatHRobot = "hrobot"
+)
+
+// HCloudClientConfiguration configures the client of the Hetzner Cloud API.
+type HCloudClientConfiguration struct {
+	// Endpoint of the Hetzner Cloud API. Defaults to https://api.hetzner.cloud/v1.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Debug writes all requests and responses to stderr.
+	Debug bool `json:"debug,omitempty"`
+}
+
+// RobotConfiguration configures the client of the Hetzner Robot API.
+type RobotConfiguration struct {
+	// Endpoint of the Robot API. Optional, leave empty for the default.
+	Endpoint string `json:"endpoint,omitempty"`
+	// Debug logs every call to the Robot API including its stack trace.
+	Debug bool `json:"debug,omitempty"`
+	// CacheTimeout is the maximum age of the cached Robot server list.
+	CacheTimeout metav1.Duration `json:"cacheTimeout,omitempty"`
+	// RateLimitWaitTime is the time no calls are made to a Robot API endpoint
+	// after its rate limit was exceeded, if the response has no Retry-After
+	// header.
+	RateLimitWaitTime metav1.Duration `json:"rateLimitWaitTime,omitempty"`
+	// ProviderIDFormat is the format of the provider IDs set on new Robot
+	// nodes. One of legacy, hrobot. Nodes which already have a provider ID
+	// keep it, both formats are always accepted.
+	ProviderIDFormat string `json:"providerIDFormat,omitempty"`
+}
+
+// MetricsConfiguration configures the Prometheus metrics endpoint.
+type MetricsConfiguration struct {
+	Enabled bool   `json:"enabled"`
+	Address string `json:"address,omitempty"`
+}
+
+// InstanceConfiguration configures the InstancesV2 implementation.
+type InstanceConfiguration struct {
+	// AddressFamily of the node addresses. One of ipv4, ipv6, dualstack.
+	AddressFamily string `json:"addressFamily,omitempty"`
+}
+
+// NetworkConfiguration configures the private network of the cluster.
+type NetworkConfiguration struct {
+	// NameOrID of the Hetzner Cloud Network. Leave empty to disable network
+	// support.
+	NameOrID string `json:"nameOrID,omitempty"`
+	// DisableAttachedCheck disables the check that the server the controller
+	// runs on is attached to the network.
+	DisableAttachedCheck bool `json:"disableAttachedCheck,omitempty"`
+}
+
+// RouteConfiguration configures the Routes implementation.
+type RouteConfiguration struct {
+	// Enabled has no effect if no network is configured.
+	Enabled bool `json:"enabled"`
+	// ClusterCIDR is the CIDR of the pod network. Optional, if set, routes
+	// owned by the controller with a destination outside of it are removed
+	// by the garbage collection.
+	ClusterCIDR string `json:"clusterCIDR,omitempty"`
+	// GC configures the garbage collection of orphaned routes.
+	GC RouteGCConfiguration `json:"gc"`
+}
+
+// RouteGCConfiguration configures the garbage collection of routes which were
+// created by the controller, but point to deleted servers or lie outside of
+// the cluster CIDR.
+type RouteGCConfiguration struct {
+	Enabled bool `json:"enabled"`
+	// DryRun only reports orphaned routes via events and metrics instead of
+	// deleting them.
+	DryRun bool `json:"dryRun,omitempty"`
+	// Interval between two runs of the garbage collection.
+	Interval metav1.Duration `json:"interval,omitempty"`
+}
+
+// LoadBalancerConfiguration stores the cluster-wide defaults for Load
+// Balancers. Most values can be overridden by Service annotations.
+type LoadBalancerConfiguration struct {
+	Enabled               bool   `json:"enabled"`
+	Location              string `json:"location,omitempty"`
+	NetworkZone           string `json:"networkZone,omitempty"`
+	DisablePrivateIngress bool   `json:"disablePrivateIngress,omitempty"`
+	UsePrivateIP          bool   `json:"usePrivateIP,omitempty"`
+	DisableIPv6           bool   `json:"disableIPv6,omitempty"`
+	// DryRun only records the changes to the Load Balancers as events on
+	// their Services instead of applying them.
+	DryRun bool `json:"dryRun,omitempty"`
+	// TargetWorkers is the maximum number of targets added to or removed from
+	// a Load Balancer concurrently.
+	TargetWorkers int `json:"targetWorkers,omitempty"`
+	// Class is the loadBalancerClass of the Services whose Load Balancers are
+	// created with the defaults above. If set, Services without a
+	// loadBalancerClass are left to other implementations. Optional.
+	Class string `json:"class,omitempty"`
+	// Classes are further loadBalancerClasses whose Load Balancers are
+	// created by the controller, with the defaults of their profile.
+	Classes map[string]LoadBalancerClassConfiguration `json:"classes,omitempty"`
+}
+
+// LoadBalancerClassConfiguration is the profile of a loadBalancerClass. Unset
+// values are taken from the LoadBalancerConfiguration. Setting the location
+// or the network zone overrides both.
+type LoadBalancerClassConfiguration struct {
+	Location              string `json:"location,omitempty"`
+	NetworkZone           string `json:"networkZone,omitempty"`
+	DisablePrivateIngress *bool  `json:"disablePrivateIngress,omitempty"`
+	UsePrivateIP          *bool  `json:"usePrivateIP,omitempty"`
+	DisableIPv6           *bool  `json:"disableIPv6,omitempty"`
+	// DisablePublicNetwork disables the public interface of the Load
+	// Balancers, for example for internal-only classes.
+	DisablePublicNetwork *bool `json:"disablePublicNetwork,omitempty"`
+}
+
+// HCCMConfiguration is the configuration of the cloud controller manager.
+//
+// It is read from the file passed via --cloud-config. Every value can be
+// overridden by its environment variable.
+type HCCMConfiguration struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+
+	HCloudClient HCloudClientConfiguration `json:"hcloudClient"`
+	Robot        RobotConfiguration        `json:"robot"`
+	Metrics      MetricsConfiguration      `json:"metrics"`
+	Instance     InstanceConfiguration     `json:"instance"`
+	Network      NetworkConfiguration      `json:"network"`
+	Route        RouteConfiguration        `json:"route"`
+	LoadBalancer LoadBalancerConfiguration `json:"loadBalancer"`
+}
+
+// Default returns the configuration used if neither a configuration file nor
+// environment variables are given.
+func Default() HCCMConfiguration {
+	return HCCMConfiguration{
+		APIVersion: APIVersion,
+		Kind:       Kind,
+		Robot: RobotConfiguration{
+			CacheTimeout:      metav1.Duration{Duration: 5 * time.Minute},
+			RateLimitWaitTime: metav1.Duration{Duration: 5 * time.Minute},
+			ProviderIDFormat:  RobotProviderIDFormatLegacy,
+		},
+		Metrics: MetricsConfiguration{
+			Enabled: true,
+			Address: ":8233",
+		},
+		Instance: InstanceConfiguration{
+			AddressFamily: AddressFamilyIPv4,
+		},
+		Route: RouteConfiguration{
+			Enabled: true,
+			GC: RouteGCConfiguration{
+				Enabled:  true,
+				Interval: metav1.Duration{Duration: 10 * time.Minute},
+			},
+		},
+		LoadBalancer: LoadBalancerConfiguration{
+			Enabled:       true,
+			TargetWorkers: 5,
+		},
+	}
+}
+
+// Read reads the configuration from r, applies the environment variable
+// overrides and validates the result.
+//
+// r may be nil or empty. In this case the defaults and the environment
+// variables are used. YAML and JSON are supported.
+func Read(r io.Reader) (HCCMConfiguration, error) {
+	const op = "config/Read"
+
+	cfg := Default()
+
+	if r != nil {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return cfg, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := cfg.unmarshal(data); err != nil {
+			return cfg, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return cfg, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf("%s: %w", op, err)
+	}
+	return cfg, nil
+}
+
+func (c *HCCMConfiguration) unmarshal(data []byte) error {
+	if strings.TrimSpace(string(data)) == "" {
+		return nil
+	}
+
+	// Reset the type information, so that files without apiVersion and kind
+	// are rejected by Validate.
+	c.APIVersion = ""
+	c.Kind = ""
+
+	if err := yaml.UnmarshalStrict(data, c); err != nil {
+		return fmt.Errorf("parsing configuration file: %w", err)
+	}
+	return nil
+}
+
+func (c *HCCMConfiguration) applyEnv() error {
+	var errs []error
+
+	lookupString(hcloudEndpoint, &c.HCloudClient.Endpoint)
+	errs = append(errs, lookupBool(hcloudDebug, &c.HCloudClient.Debug))
+
+	lookupString(robotEndpoint, &c.Robot.Endpoint)
+	errs = append(errs, lookupBool(robotDebug, &c.Robot.Debug))
+	errs = append(errs, lookupDuration(robotCacheTimeout, &c.Robot.CacheTimeout.Duration))
+	errs = append(errs, lookupDuration(robotRateLimitWaitTime, &c.Robot.RateLimitWaitTime.Duration))
+	if v, ok := os.LookupEnv(robotProviderIDFormat); ok {
+		c.Robot.ProviderIDFormat = strings.ToLower(v)
+	}
+
+	errs = append(errs, lookupBool(metricsEnabled, &c.Metrics.Enabled))
+
+	if v, ok := os.LookupEnv(instancesAddressFamily); ok {
+		c.Instance.AddressFamily = strings.ToLower(v)
+	}
+
+	lookupString(network, &c.Network.NameOrID)
+	errs = append(errs, lookupBool(networkDisableAttachedCheck, &c.Network.DisableAttachedCheck))
+	errs = append(errs, lookupBool(networkRoutesEnabled, &c.Route.Enabled))
+	lookupString(networkRoutesClusterCIDR, &c.Route.ClusterCIDR)
+	errs = append(errs, lookupBool(networkRoutesGCEnabled, &c.Route.GC.Enabled))
+	errs = append(errs, lookupBool(networkRoutesGCDryRun, &c.Route.GC.DryRun))
+	errs = append(errs, lookupDuration(networkRoutesGCInterval, &c.Route.GC.Interval.Duration))
+
+	errs = append(errs, lookupBool(loadBalancersEnabled, &c.LoadBalancer.Enabled))
+	lookupString(loadBalancersLocation, &c.LoadBalancer.Location)
+	lookupString(loadBalancersNetworkZone, &c.LoadBalancer.NetworkZone)
+	errs = append(errs, lookupBool(loadBalancersDisablePrivateIngress, &c.LoadBalancer.DisablePrivateIngress))
+	errs = append(errs, lookupBool(loadBalancersUsePrivateIP, &c.LoadBalancer.UsePrivateIP))
+	errs = append(errs, lookupBool(loadBalancersDisableIPv6, &c.LoadBalancer.DisableIPv6))
+	errs = append(errs, lookupBool(loadBalancersDryRun, &c.LoadBalancer.DryRun))
+	errs = append(errs, lookupInt(loadBalancersTargetWorkers, &c.LoadBalancer.TargetWorkers))
+	lookupString(loadBalancersClass, &c.LoadBalancer.Class)
+
+	return errors.Join(errs...)
+}
+
+// Validate checks the configuration for invalid and conflicting values. All
+// problems found are reported at once.
+func (c HCCMConfiguration) Validate() error {
+	var errs []error
+
+	if c.APIVersion != APIVersion {
+		errs = append(errs, fmt.Errorf("apiVersion: unsupported value %q, expected %q", c.APIVersion, APIVersion))
+	}
+	if c.Kind != Kind {
+		errs = append(errs, fmt.Errorf("kind: unsupported value %q, expected %q", c.Kind, Kind))
+	}
+
+	if c.Robot.CacheTimeout.Duration < 0 {
+		errs = append(errs, fmt.Errorf("robot.cacheTimeout: must not be negative"))
+	}
+	if c.Robot.RateLimitWaitTime.Duration < 0 {
+		errs = append(errs, fmt.Errorf("robot.rateLimitWaitTime: must not be negative"))
+	}
+	switch c.Robot.ProviderIDFormat {
+	case RobotProviderIDFormatLegacy, RobotProviderIDFormatHRobot:
+	default:
+		errs = append(errs, fmt.Errorf("robot.providerIDFormat: invalid value %q, expected one of: legacy,hrobot",
+			c.Robot.ProviderIDFormat))
+	}
+
+	if c.Metrics.Enabled && c.Metrics.Address == "" {
+		errs = append(errs, fmt.Errorf("metrics.address: must be set if metrics are enabled"))
+	}
+
+	switch c.Instance.AddressFamily {
+	case AddressFamilyIPv4, AddressFamilyIPv6, AddressFamilyDualStack:
+	default:
+		errs = append(errs, fmt.Errorf("instance.addressFamily: invalid value %q, expected one of: ipv4,ipv6,dualstack",
+			c.Instance.AddressFamily))
+	}
+
+	if c.Route.ClusterCIDR != "" {
+		if _, _, err := net.ParseCIDR(c.Route.ClusterCIDR); err != nil {
+			errs = append(errs, fmt.Errorf("route.clusterCIDR: %w", err))
+		}
+	}
+	if c.Route.GC.Enabled && c.Route.GC.Interval.Duration <= 0 {
+		errs = append(errs, fmt.Errorf("route.gc.interval: must be positive if the garbage collection is enabled"))
+	}
+
+	if c.LoadBalancer.Location != "" && c.LoadBalancer.NetworkZone != "" {
+		errs = append(errs, fmt.Errorf("loadBalancer.location/loadBalancer.networkZone (%s/%s): Only one of these can be set",
+			loadBalancersLocation, loadBalancersNetworkZone))
+	}
+	if c.LoadBalancer.TargetWorkers < 1 {
+		errs = append(errs, fmt.Errorf("loadBalancer.targetWorkers: must be at least 1"))
+	}
+	if c.LoadBalancer.Class != "" {
+		for _, msg := range validation.IsQualifiedName(c.LoadBalancer.Class) {
+			errs = append(errs, fmt.Errorf("loadBalancer.class (%s): %s", loadBalancersClass, msg))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.LoadBalancer.Classes)) {
+		for _, msg := range validation.IsQualifiedName(name) {
+			errs = append(errs, fmt.Errorf("loadBalancer.classes[%s]: %s", name, msg))
+		}
+		if name == c.LoadBalancer.Class {
+			errs = append(errs, fmt.Errorf("loadBalancer.classes[%s]: must not be loadBalancer.class", name))
+		}
+		class := c.LoadBalancer.Classes[name]
+		if class.Location != "" && class.NetworkZone != "" {
+			errs = append(errs, fmt.Errorf("loadBalancer.classes[%s].location/networkZone: Only one of these can be set", name))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func lookupString(key string, dst *string) {
+	if v, ok := os.LookupEnv(key); ok {
+		*dst = v
+	}
+}
+
+func lookupBool(key string, dst *bool) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("%s: %v", key, err)
+	}
+	*dst = b
+	return nil
+}
+
+func lookupInt(key string, dst *int) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+
+	i, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("%s: %v", key, err)
+	}
+	*dst = i
+	return nil
+}
+
+func lookupDuration(key string, dst *time.Duration) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("%s: %v", key, err)
+	}
+	*dst = d
+	return nil
+}
@@ -0,0 +1,280 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRead(t *testing.T) {
+	cases := []struct {
+		name   string
+		file   string
+		env    map[string]string
+		expCfg func(cfg *HCCMConfiguration)
+		expErr string
+	}{
+		{
+			name:   "No file, no env",
+			expCfg: func(_ *HCCMConfiguration) {},
+		},
+		{
+			name: "YAML file",
+			file: `
+apiVersion: hcloud-ccm.syself.com/v1alpha1
+kind: HCCMConfiguration
+robot:
+  cacheTimeout: 10m
+  providerIDFormat: hrobot
+network:
+  nameOrID: my-network
+route:
+  enabled: false
+  clusterCIDR: 10.244.0.0/16
+  gc:
+    dryRun: true
+loadBalancer:
+  location: hel1
+  usePrivateIP: true
+`,
+			expCfg: func(cfg *HCCMConfiguration) {
+				cfg.Robot.CacheTimeout = metav1.Duration{Duration: 10 * time.Minute}
+				cfg.Robot.ProviderIDFormat = RobotProviderIDFormatHRobot
+				cfg.Network.NameOrID = "my-network"
+				cfg.Route.Enabled = false
+				cfg.Route.ClusterCIDR = "10.244.0.0/16"
+				cfg.Route.GC.DryRun = true
+				cfg.LoadBalancer.Location = "hel1"
+				cfg.LoadBalancer.UsePrivateIP = true
+			},
+		},
+		{
+			name: "JSON file",
+			file: `{"apiVersion": "hcloud-ccm.syself.com/v1alpha1", "kind": "HCCMConfiguration", "instance": {"addressFamily": "dualstack"}}`,
+			expCfg: func(cfg *HCCMConfiguration) {
+				cfg.Instance.AddressFamily = AddressFamilyDualStack
+			},
+		},
+		{
+			name: "Env overrides file",
+			file: `
+apiVersion: hcloud-ccm.syself.com/v1alpha1
+kind: HCCMConfiguration
+robot:
+  cacheTimeout: 10m
+metrics:
+  enabled: true
+loadBalancer:
+  networkZone: eu-central
+`,
+			env: map[string]string{
+				"HCLOUD_METRICS_ENABLED":             "false",
+				"HCLOUD_LOAD_BALANCERS_NETWORK_ZONE": "us-east",
+				"RATE_LIMIT_WAIT_TIME_ROBOT":         "1m",
+				"CACHE_TIMEOUT":                      "0s",
+			},
+			expCfg: func(cfg *HCCMConfiguration) {
+				cfg.Metrics.Enabled = false
+				cfg.LoadBalancer.NetworkZone = "us-east"
+				cfg.Robot.RateLimitWaitTime = metav1.Duration{Duration: time.Minute}
+				cfg.Robot.CacheTimeout = metav1.Duration{}
+			},
+		},
+		{
+			name: "All load balancer env vars set (except network zone)",
+			env: map[string]string{
+				"HCLOUD_LOAD_BALANCERS_LOCATION":                "hel1",
+				"HCLOUD_LOAD_BALANCERS_DISABLE_PRIVATE_INGRESS": "true",
+				"HCLOUD_LOAD_BALANCERS_DISABLE_IPV6":            "true",
+				"HCLOUD_LOAD_BALANCERS_USE_PRIVATE_IP":          "true",
+				"HCLOUD_LOAD_BALANCERS_DRY_RUN":                 "true",
+				"HCLOUD_LOAD_BALANCERS_TARGET_WORKERS":          "10",
+			},
+			expCfg: func(cfg *HCCMConfiguration) {
+				cfg.LoadBalancer.Location = "hel1"
+				cfg.LoadBalancer.DisablePrivateIngress = true
+				cfg.LoadBalancer.DisableIPv6 = true
+				cfg.LoadBalancer.UsePrivateIP = true
+				cfg.LoadBalancer.DryRun = true
+				cfg.LoadBalancer.TargetWorkers = 10
+			},
+		},
+		{
+			name: "Load balancer classes",
+			file: `
+apiVersion: hcloud-ccm.syself.com/v1alpha1
+kind: HCCMConfiguration
+loadBalancer:
+  location: hel1
+  class: hetzner.cloud/public
+  classes:
+    hetzner.cloud/internal:
+      networkZone: eu-central
+      disablePublicNetwork: true
+      usePrivateIP: true
+`,
+			expCfg: func(cfg *HCCMConfiguration) {
+				cfg.LoadBalancer.Location = "hel1"
+				cfg.LoadBalancer.Class = "hetzner.cloud/public"
+				cfg.LoadBalancer.Classes = map[string]LoadBalancerClassConfiguration{
+					"hetzner.cloud/internal": {
+						NetworkZone:          "eu-central",
+						DisablePublicNetwork: ptr(true),
+						UsePrivateIP:         ptr(true),
+					},
+				}
+			},
+		},
+		{
+			name: "Invalid load balancer classes",
+			file: `
+apiVersion: hcloud-ccm.syself.com/v1alpha1
+kind: HCCMConfiguration
+loadBalancer:
+  class: hetzner.cloud/public
+  classes:
+    hetzner.cloud/public: {}
+    internal class:
+      location: hel1
+      networkZone: eu-central
+`,
+			expErr: "config/Read: loadBalancer.classes[hetzner.cloud/public]: must not be loadBalancer.class\n" +
+				"loadBalancer.classes[internal class]: name part must consist of alphanumeric characters, '-', '_' or '.', " +
+				"and must start and end with an alphanumeric character " +
+				"(e.g. 'MyName',  or 'my.name',  or '123-abc', regex used for validation is '([A-Za-z0-9][-A-Za-z0-9_.]*)?[A-Za-z0-9]')\n" +
+				"loadBalancer.classes[internal class].location/networkZone: Only one of these can be set",
+		},
+		{
+			name: "Missing apiVersion and kind",
+			file: `
+loadBalancer:
+  location: hel1
+`,
+			expErr: `config/Read: apiVersion: unsupported value "", expected "hcloud-ccm.syself.com/v1alpha1"
+kind: unsupported value "", expected "HCCMConfiguration"`,
+		},
+		{
+			name: "Unknown field",
+			file: `
+apiVersion: hcloud-ccm.syself.com/v1alpha1
+kind: HCCMConfiguration
+loadBalancer:
+  locaton: hel1
+`,
+			expErr: `config/Read: parsing configuration file: error unmarshaling JSON: while decoding JSON: json: unknown field "locaton"`,
+		},
+		{
+			name: "Both location and network zone set (error)",
+			env: map[string]string{
+				"HCLOUD_LOAD_BALANCERS_LOCATION":     "hel1",
+				"HCLOUD_LOAD_BALANCERS_NETWORK_ZONE": "eu-central",
+			},
+			expErr: "config/Read: loadBalancer.location/loadBalancer.networkZone " +
+				"(HCLOUD_LOAD_BALANCERS_LOCATION/HCLOUD_LOAD_BALANCERS_NETWORK_ZONE): Only one of these can be set",
+		},
+		{
+			name: "Invalid target workers",
+			env: map[string]string{
+				"HCLOUD_LOAD_BALANCERS_TARGET_WORKERS": "0",
+			},
+			expErr: "config/Read: loadBalancer.targetWorkers: must be at least 1",
+		},
+		{
+			name: "Invalid address family",
+			env: map[string]string{
+				"HCLOUD_INSTANCES_ADDRESS_FAMILY": "ipv5",
+			},
+			expErr: `config/Read: instance.addressFamily: invalid value "ipv5", expected one of: ipv4,ipv6,dualstack`,
+		},
+		{
+			name: "Invalid robot provider ID format",
+			env: map[string]string{
+				"ROBOT_PROVIDER_ID_FORMAT": "bm",
+			},
+			expErr: `config/Read: robot.providerIDFormat: invalid value "bm", expected one of: legacy,hrobot`,
+		},
+		{
+			name: "Route garbage collection env vars",
+			env: map[string]string{
+				"HCLOUD_NETWORK_ROUTES_CLUSTER_CIDR": "10.244.0.0/16",
+				"HCLOUD_NETWORK_ROUTES_GC_ENABLED":   "false",
+				"HCLOUD_NETWORK_ROUTES_GC_DRY_RUN":   "true",
+				"HCLOUD_NETWORK_ROUTES_GC_INTERVAL":  "1h",
+			},
+			expCfg: func(cfg *HCCMConfiguration) {
+				cfg.Route.ClusterCIDR = "10.244.0.0/16"
+				cfg.Route.GC.Enabled = false
+				cfg.Route.GC.DryRun = true
+				cfg.Route.GC.Interval = metav1.Duration{Duration: time.Hour}
+			},
+		},
+		{
+			name: "Invalid cluster CIDR",
+			env: map[string]string{
+				"HCLOUD_NETWORK_ROUTES_CLUSTER_CIDR": "10.244.0.0",
+			},
+			expErr: `config/Read: route.clusterCIDR: invalid CIDR address: 10.244.0.0`,
+		},
+		{
+			name: "Invalid DISABLE_PRIVATE_INGRESS",
+			env: map[string]string{
+				"HCLOUD_LOAD_BALANCERS_DISABLE_PRIVATE_INGRESS": "invalid",
+			},
+			expErr: `config/Read: HCLOUD_LOAD_BALANCERS_DISABLE_PRIVATE_INGRESS: strconv.ParseBool: parsing "invalid": invalid syntax`,
+		},
+		{
+			name: "Invalid DISABLE_IPV6",
+			env: map[string]string{
+				"HCLOUD_LOAD_BALANCERS_DISABLE_IPV6": "invalid",
+			},
+			expErr: `config/Read: HCLOUD_LOAD_BALANCERS_DISABLE_IPV6: strconv.ParseBool: parsing "invalid": invalid syntax`,
+		},
+		{
+			name: "Invalid USE_PRIVATE_IP",
+			env: map[string]string{
+				"HCLOUD_LOAD_BALANCERS_USE_PRIVATE_IP": "invalid",
+			},
+			expErr: `config/Read: HCLOUD_LOAD_BALANCERS_USE_PRIVATE_IP: strconv.ParseBool: parsing "invalid": invalid syntax`,
+		},
+		{
+			name: "Invalid CACHE_TIMEOUT",
+			env: map[string]string{
+				"CACHE_TIMEOUT": "5 minutes",
+			},
+			expErr: `config/Read: CACHE_TIMEOUT: time: unknown unit " minutes" in duration "5 minutes"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			for k, v := range c.env {
+				t.Setenv(k, v)
+			}
+
+			cfg, err := Read(strings.NewReader(c.file))
+
+			if c.expErr != "" {
+				assert.EqualError(t, err, c.expErr)
+				return
+			}
+			assert.NoError(t, err)
+
+			expCfg := Default()
+			c.expCfg(&expCfg)
+			assert.Equal(t, expCfg, cfg)
+		})
+	}
+}
+
+func TestReadNilReader(t *testing.T) {
+	cfg, err := Read(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, Default(), cfg)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -16,6 +16,7 @@ type HCloudCertificateClient interface {
 	CreateCertificate(
 		ctx context.Context, opts hcloud.CertificateCreateOpts,
 	) (hcloud.CertificateCreateResult, *hcloud.Response, error)
+	Delete(ctx context.Context, certificate *hcloud.Certificate) (*hcloud.Response, error)
 }
 
 // CertificateOps implements all operations regarding Hetzner Cloud Certificates.
@@ -71,7 +72,7 @@ func (co *CertificateOps) GetCertificateByLabel(ctx context.Context, label strin
 // certificate already exists.
 func (co *CertificateOps) CreateManagedCertificate(
 	ctx context.Context, name string, domains []string, labels map[string]string,
-) error {
+) (*hcloud.Certificate, error) {
 	const op = "hcops/CertificateOps.CreateManagedCertificate"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
@@ -81,12 +82,70 @@ func (co *CertificateOps) CreateManagedCertificate(
 		DomainNames: domains,
 		Labels:      labels,
 	}
-	_, _, err := co.CertClient.CreateCertificate(ctx, opts)
+	res, _, err := co.CertClient.CreateCertificate(ctx, opts)
 	if hcloud.IsError(err, hcloud.ErrorCodeUniquenessError) {
-		return fmt.Errorf("%s: %w", op, ErrAlreadyExists)
+		return nil, fmt.Errorf("%s: %w", op, ErrAlreadyExists)
 	}
 	if err != nil {
-		return fmt.Errorf("%s: %v", op, err)
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+	return res.Certificate, nil
+}
+
+// GetCertificatesByLabel obtains all certificates matching the label
+// selector.
+func (co *CertificateOps) GetCertificatesByLabel(ctx context.Context, label string) ([]*hcloud.Certificate, error) {
+	const op = "hcops/CertificateOps.GetCertificatesByLabel"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	opts := hcloud.CertificateListOpts{ListOpts: hcloud.ListOpts{LabelSelector: label}}
+	certs, err := co.CertClient.AllWithOpts(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+	return certs, nil
+}
+
+// UploadCertificate uploads the PEM encoded certificate and private key
+// labeled with labels.
+//
+// UploadCertificate returns a wrapped ErrAlreadyExists if a certificate
+// with name already exists.
+func (co *CertificateOps) UploadCertificate(
+	ctx context.Context, name, certificate, privateKey string, labels map[string]string,
+) (*hcloud.Certificate, error) {
+	const op = "hcops/CertificateOps.UploadCertificate"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	opts := hcloud.CertificateCreateOpts{
+		Name:        name,
+		Type:        hcloud.CertificateTypeUploaded,
+		Certificate: certificate,
+		PrivateKey:  privateKey,
+		Labels:      labels,
+	}
+	res, _, err := co.CertClient.CreateCertificate(ctx, opts)
+	if hcloud.IsError(err, hcloud.ErrorCodeUniquenessError) {
+		return nil, fmt.Errorf("%s: %w", op, ErrAlreadyExists)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", op, err)
+	}
+	return res.Certificate, nil
+}
+
+// DeleteCertificate deletes cert. Deleting a certificate which does not
+// exist is not an error.
+func (co *CertificateOps) DeleteCertificate(ctx context.Context, cert *hcloud.Certificate) error {
+	const op = "hcops/CertificateOps.DeleteCertificate"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	_, err := co.CertClient.Delete(ctx, cert)
+	if hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
 	return nil
 }
@@ -139,7 +139,7 @@ func TestCertificateOps_CreateManagedCertificate(t *testing.T) {
 					Return(nil, nil, errors.New("test error"))
 			},
 			Perform: func(t *testing.T, tt *certificateOpsTestCase) {
-				err := tt.CertOps.CreateManagedCertificate(
+				_, err := tt.CertOps.CreateManagedCertificate(
 					tt.Ctx,
 					"test-cert",
 					[]string{"example.com", "*.example.com"},
@@ -158,7 +158,7 @@ func TestCertificateOps_CreateManagedCertificate(t *testing.T) {
 					Return(nil, nil, err)
 			},
 			Perform: func(t *testing.T, tt *certificateOpsTestCase) {
-				err := tt.CertOps.CreateManagedCertificate(
+				_, err := tt.CertOps.CreateManagedCertificate(
 					tt.Ctx,
 					"test-cert",
 					[]string{"example.com", "*.example.com"},
@@ -181,13 +181,14 @@ func TestCertificateOps_CreateManagedCertificate(t *testing.T) {
 					Return(res, nil, nil)
 			},
 			Perform: func(t *testing.T, tt *certificateOpsTestCase) {
-				err := tt.CertOps.CreateManagedCertificate(
+				cert, err := tt.CertOps.CreateManagedCertificate(
 					tt.Ctx,
 					"test-cert",
 					[]string{"example.com", "*.example.com"},
 					map[string]string{"key": "value"},
 				)
 				assert.NoError(t, err)
+				assert.Equal(t, int64(1), cert.ID)
 			},
 		},
 	}
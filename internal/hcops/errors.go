@@ -15,4 +15,13 @@ var (
 	// ErrAlreadyExists signals that the resource creation failed, because the
 	// resource already exists.
 	ErrAlreadyExists = errors.New("already exists")
+
+	// ErrCertificatePending signals that the managed certificate for the
+	// current domains of a Service is not issued yet, so the Load Balancer
+	// still uses the certificate of the previous domains.
+	ErrCertificatePending = errors.New("certificate pending")
+
+	// ErrCertificateFailed signals that the issuance of the managed
+	// certificate for the current domains of a Service failed.
+	ErrCertificateFailed = errors.New("certificate issuance failed")
 )
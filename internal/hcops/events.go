@@ -0,0 +1,26 @@
+package hcops
+
+// Reasons of the events recorded on a Service for every change made to its
+// Load Balancer.
+const (
+	EventLoadBalancerCreated       = "LoadBalancerCreated"
+	EventLoadBalancerUpdated       = "LoadBalancerUpdated"
+	EventReverseDNSChanged         = "ReverseDNSChanged"
+	EventAlgorithmChanged          = "AlgorithmChanged"
+	EventTypeChanged               = "TypeChanged"
+	EventNetworkDetached           = "NetworkDetached"
+	EventNetworkAttached           = "NetworkAttached"
+	EventPublicInterfaceToggled    = "PublicInterfaceToggled"
+	EventTargetAdded               = "TargetAdded"
+	EventTargetRemoved             = "TargetRemoved"
+	EventServerLabeled             = "ServerLabeled"
+	EventServerUnlabeled           = "ServerUnlabeled"
+	EventServiceAdded              = "ServiceAdded"
+	EventServiceUpdated            = "ServiceUpdated"
+	EventServiceRemoved            = "ServiceRemoved"
+	EventManagedCertificateCreated = "ManagedCertificateCreated"
+	EventManagedCertificatePending = "ManagedCertificatePending"
+	EventManagedCertificateFailed  = "ManagedCertificateFailed"
+	EventCertificateUploaded       = "CertificateUploaded"
+	EventCertificateDeleted        = "CertificateDeleted"
+)
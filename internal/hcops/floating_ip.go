@@ -0,0 +1,74 @@
+package hcops
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/metrics"
+)
+
+// HCloudFloatingIPClient defines the hcloud-go functions related to Floating
+// IP management.
+type HCloudFloatingIPClient interface {
+	Get(ctx context.Context, idOrName string) (*hcloud.FloatingIP, *hcloud.Response, error)
+	All(ctx context.Context) ([]*hcloud.FloatingIP, error)
+	Assign(ctx context.Context, floatingIP *hcloud.FloatingIP, server *hcloud.Server) (*hcloud.Action, *hcloud.Response, error)
+}
+
+// FloatingIPOps implements all operations regarding Hetzner Cloud Floating
+// IPs.
+type FloatingIPOps struct {
+	FloatingIPClient HCloudFloatingIPClient
+	ActionClient     HCloudActionClient
+}
+
+// GetFloatingIP obtains a Floating IP from the Hetzner Cloud backend using its
+// ID, its name or its address. An IPv6 address matches the Floating IP of its
+// network.
+//
+// If a Floating IP could not be found the returned error wraps ErrNotFound.
+func (fo *FloatingIPOps) GetFloatingIP(ctx context.Context, idNameOrIP string) (*hcloud.FloatingIP, error) {
+	const op = "hcops/FloatingIPOps.GetFloatingIP"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	ip := net.ParseIP(idNameOrIP)
+	if ip == nil {
+		fip, _, err := fo.FloatingIPClient.Get(ctx, idNameOrIP)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if fip == nil {
+			return nil, fmt.Errorf("%s: %w", op, ErrNotFound)
+		}
+		return fip, nil
+	}
+
+	fips, err := fo.FloatingIPClient.All(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	for _, fip := range fips {
+		if fip.IP.Equal(ip) || (fip.Network != nil && fip.Network.Contains(ip)) {
+			return fip, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", op, ErrNotFound)
+}
+
+// Assign assigns fip to the server with the ID serverID and waits until the
+// assignment is done.
+func (fo *FloatingIPOps) Assign(ctx context.Context, fip *hcloud.FloatingIP, serverID int64) error {
+	const op = "hcops/FloatingIPOps.Assign"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	action, _, err := fo.FloatingIPClient.Assign(ctx, fip, &hcloud.Server{ID: serverID})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := WatchAction(ctx, fo.ActionClient, action); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
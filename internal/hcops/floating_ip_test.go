@@ -0,0 +1,124 @@
+package hcops_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/mocks"
+)
+
+func TestFloatingIPOps_GetFloatingIP(t *testing.T) {
+	_, ipv6Net, err := net.ParseCIDR("2001:db8:1::/64")
+	require.NoError(t, err)
+
+	ipv4 := &hcloud.FloatingIP{ID: 1, Name: "ingress", IP: net.ParseIP("1.2.3.4"), Type: hcloud.FloatingIPTypeIPv4}
+	ipv6 := &hcloud.FloatingIP{ID: 2, Name: "ingress-v6", IP: ipv6Net.IP, Network: ipv6Net, Type: hcloud.FloatingIPTypeIPv6}
+
+	tests := []struct {
+		name       string
+		idNameOrIP string
+		mock       func(client *mocks.FloatingIPClient)
+		expected   *hcloud.FloatingIP
+		expErr     error
+	}{
+		{
+			name:       "by name",
+			idNameOrIP: "ingress",
+			mock: func(client *mocks.FloatingIPClient) {
+				client.On("Get", context.Background(), "ingress").Return(ipv4, nil, nil)
+			},
+			expected: ipv4,
+		},
+		{
+			name:       "by name not found",
+			idNameOrIP: "ingress",
+			mock: func(client *mocks.FloatingIPClient) {
+				client.On("Get", context.Background(), "ingress").Return(nil, nil, nil)
+			},
+			expErr: hcops.ErrNotFound,
+		},
+		{
+			name:       "by IPv4 address",
+			idNameOrIP: "1.2.3.4",
+			mock: func(client *mocks.FloatingIPClient) {
+				client.On("All", context.Background()).Return([]*hcloud.FloatingIP{ipv6, ipv4}, nil)
+			},
+			expected: ipv4,
+		},
+		{
+			name:       "by IPv6 address in network",
+			idNameOrIP: "2001:db8:1::1",
+			mock: func(client *mocks.FloatingIPClient) {
+				client.On("All", context.Background()).Return([]*hcloud.FloatingIP{ipv4, ipv6}, nil)
+			},
+			expected: ipv6,
+		},
+		{
+			name:       "by address not found",
+			idNameOrIP: "5.6.7.8",
+			mock: func(client *mocks.FloatingIPClient) {
+				client.On("All", context.Background()).Return([]*hcloud.FloatingIP{ipv4, ipv6}, nil)
+			},
+			expErr: hcops.ErrNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &mocks.FloatingIPClient{}
+			client.Test(t)
+			tt.mock(client)
+			fo := &hcops.FloatingIPOps{FloatingIPClient: client}
+
+			fip, err := fo.GetFloatingIP(context.Background(), tt.idNameOrIP)
+			if tt.expErr != nil {
+				assert.ErrorIs(t, err, tt.expErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, fip)
+			client.AssertExpectations(t)
+		})
+	}
+}
+
+func TestFloatingIPOps_Assign(t *testing.T) {
+	ctx := context.Background()
+	fip := &hcloud.FloatingIP{ID: 1}
+	action := &hcloud.Action{ID: 2}
+
+	t.Run("success", func(t *testing.T) {
+		client := &mocks.FloatingIPClient{}
+		client.Test(t)
+		client.On("Assign", ctx, fip, &hcloud.Server{ID: 3}).Return(action, nil, nil)
+		actionClient := &mocks.ActionClient{}
+		actionClient.Test(t)
+		actionClient.MockWatchProgress(ctx, action, nil)
+
+		fo := &hcops.FloatingIPOps{FloatingIPClient: client, ActionClient: actionClient}
+		assert.NoError(t, fo.Assign(ctx, fip, 3))
+		client.AssertExpectations(t)
+		actionClient.AssertExpectations(t)
+	})
+
+	t.Run("action fails", func(t *testing.T) {
+		actionErr := errors.New("action failed")
+		client := &mocks.FloatingIPClient{}
+		client.Test(t)
+		client.On("Assign", ctx, fip, &hcloud.Server{ID: 3}).Return(action, nil, nil)
+		actionClient := &mocks.ActionClient{}
+		actionClient.Test(t)
+		actionClient.MockWatchProgress(ctx, action, actionErr)
+
+		fo := &hcops.FloatingIPOps{FloatingIPClient: client, ActionClient: actionClient}
+		assert.ErrorIs(t, fo.Assign(ctx, fip, 3), actionErr)
+		client.AssertExpectations(t)
+		actionClient.AssertExpectations(t)
+	})
+}
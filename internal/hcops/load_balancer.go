@@ -2,9 +2,15 @@ package hcops
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"maps"
 	"net"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,7 +20,15 @@ import (
 	"github.com/syself/hetzner-cloud-controller-manager/internal/providerid"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/robot/client"
 	"github.com/syself/hrobot-go/models"
+	"golang.org/x/sync/errgroup"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog/v2"
 )
@@ -23,6 +37,19 @@ import (
 // identify a load balancer managed by Hetzner Cloud Cloud Controller Manager.
 const LabelServiceUID = "hcloud-ccm/service-uid"
 
+// labelSecretServiceUID and labelSecretHash are the labels of the
+// certificates uploaded from the TLS Secrets of a Service. They hold the UID
+// of the Service and a hash of the certificate and key of the Secret.
+const (
+	labelSecretServiceUID = "hcloud-ccm/secret-service-uid"
+	labelSecretHash       = "hcloud-ccm/secret-hash"
+)
+
+// labelTargetPrefix is the prefix of the label set on the servers selected by
+// the label selector target of a Load Balancer. It is followed by the UID of
+// the Service.
+const labelTargetPrefix = "hcloud-ccm/lb-target-"
+
 // HCloudLoadBalancerClient defines the hcloud-go functions required by the
 // Load Balancer operations type.
 type HCloudLoadBalancerClient interface {
@@ -54,6 +81,12 @@ type HCloudLoadBalancerClient interface {
 
 	AddIPTarget(ctx context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerAddIPTargetOpts) (*hcloud.Action, *hcloud.Response, error)
 	RemoveIPTarget(ctx context.Context, lb *hcloud.LoadBalancer, server net.IP) (*hcloud.Action, *hcloud.Response, error)
+	AddLabelSelectorTarget(
+		ctx context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerAddLabelSelectorTargetOpts,
+	) (*hcloud.Action, *hcloud.Response, error)
+	RemoveLabelSelectorTarget(
+		ctx context.Context, lb *hcloud.LoadBalancer, labelSelector string,
+	) (*hcloud.Action, *hcloud.Response, error)
 
 	AttachToNetwork(ctx context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerAttachToNetworkOpts) (*hcloud.Action, *hcloud.Response, error)
 	DetachFromNetwork(ctx context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerDetachFromNetworkOpts) (*hcloud.Action, *hcloud.Response, error)
@@ -68,17 +101,42 @@ type HCloudLoadBalancerClient interface {
 	AllWithOpts(ctx context.Context, opts hcloud.LoadBalancerListOpts) ([]*hcloud.LoadBalancer, error)
 }
 
+// HCloudServerClient defines the hcloud-go functions required to label the
+// servers selected by label selector targets.
+type HCloudServerClient interface {
+	GetByID(ctx context.Context, id int64) (*hcloud.Server, *hcloud.Response, error)
+	AllWithOpts(ctx context.Context, opts hcloud.ServerListOpts) ([]*hcloud.Server, error)
+	Update(ctx context.Context, server *hcloud.Server, opts hcloud.ServerUpdateOpts) (*hcloud.Server, *hcloud.Response, error)
+}
+
 // LoadBalancerOps implements all operations regarding Hetzner Cloud Load Balancers.
 type LoadBalancerOps struct {
 	LBClient      HCloudLoadBalancerClient
+	ServerClient  HCloudServerClient
 	ActionClient  HCloudActionClient
 	NetworkClient HCloudNetworkClient
 	RobotClient   client.Client
 	CertOps       *CertificateOps
 	RetryDelay    time.Duration
+	// TargetWorkers is the maximum number of targets added or removed
+	// concurrently. Zero means one.
+	TargetWorkers int
 	NetworkID     int64
 	Recorder      record.EventRecorder
 	Defaults      LoadBalancerDefaults
+	// ClassDefaults are the defaults of Services with the loadBalancerClasses
+	// they are keyed by. They replace Defaults for these Services.
+	ClassDefaults map[string]LoadBalancerDefaults
+	// PodClient is used to derive health checks from the readiness probes of
+	// the pods of a Service. The pods are listed on demand by the selector of
+	// the Service, instead of watching all pods of the cluster. Optional.
+	PodClient corev1client.PodsGetter
+	// SecretLister is used to upload the certificates of the TLS Secrets of
+	// a Service. Optional.
+	SecretLister corelisters.SecretLister
+
+	// plan collects the changes instead of applying them. Set by PlanHCLB.
+	plan *LoadBalancerPlan
 }
 
 // LoadBalancerDefaults stores cluster-wide default values for load balancers.
@@ -87,6 +145,23 @@ type LoadBalancerDefaults struct {
 	NetworkZone  string
 	UsePrivateIP bool
 	DisableIPv6  bool
+	// DisablePublicNetwork disables the public interface of Load Balancers
+	// without the LBDisablePublicNetwork annotation.
+	DisablePublicNetwork bool
+	// DisablePrivateIngress is the default of the LBDisablePrivateIngress
+	// annotation. It is only used by the hcloud package.
+	DisablePrivateIngress bool
+}
+
+// defaults returns the defaults of svc: the ClassDefaults of its
+// loadBalancerClass, if any, or Defaults.
+func (l *LoadBalancerOps) defaults(svc *corev1.Service) LoadBalancerDefaults {
+	if class := svc.Spec.LoadBalancerClass; class != nil {
+		if defaults, ok := l.ClassDefaults[*class]; ok {
+			return defaults
+		}
+	}
+	return l.Defaults
 }
 
 // GetByK8SServiceUID tries to find a Load Balancer by its Kubernetes service
@@ -169,6 +244,32 @@ func (l *LoadBalancerOps) Create(
 	const op = "hcops/LoadBalancerOps.Create"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
+	opts, err := l.createOpts(ctx, lbName, svc)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result, _, err := l.LBClient.Create(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if err := WatchAction(ctx, l.ActionClient, result.Action); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	lb, err := l.GetByID(ctx, result.LoadBalancer.ID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: get Load Balancer: %d: %w", op, result.LoadBalancer.ID, err)
+	}
+	l.Recorder.Eventf(svc, corev1.EventTypeNormal, EventLoadBalancerCreated, "Created Load Balancer %s (%d)", lb.Name, lb.ID)
+	return lb, nil
+}
+
+// createOpts returns the options to create the Load Balancer lbName for svc.
+// The returned errors are not wrapped.
+func (l *LoadBalancerOps) createOpts(
+	ctx context.Context, lbName string, svc *corev1.Service,
+) (hcloud.LoadBalancerCreateOpts, error) {
 	opts := hcloud.LoadBalancerCreateOpts{
 		Name:             lbName,
 		LoadBalancerType: &hcloud.LoadBalancerType{Name: "lb11"},
@@ -179,8 +280,9 @@ func (l *LoadBalancerOps) Create(
 	if v, ok := annotation.LBType.StringFromService(svc); ok {
 		opts.LoadBalancerType.Name = v
 	}
-	if l.Defaults.Location != "" {
-		opts.Location = &hcloud.Location{Name: l.Defaults.Location}
+	defaults := l.defaults(svc)
+	if defaults.Location != "" {
+		opts.Location = &hcloud.Location{Name: defaults.Location}
 	}
 	if v, ok := annotation.LBLocation.StringFromService(svc); ok {
 		if v == "" {
@@ -191,12 +293,12 @@ func (l *LoadBalancerOps) Create(
 			opts.Location = &hcloud.Location{Name: v}
 		}
 	}
-	opts.NetworkZone = hcloud.NetworkZone(l.Defaults.NetworkZone)
+	opts.NetworkZone = hcloud.NetworkZone(defaults.NetworkZone)
 	if v, ok := annotation.LBNetworkZone.StringFromService(svc); ok {
 		opts.NetworkZone = hcloud.NetworkZone(v)
 	}
 	if opts.Location == nil && opts.NetworkZone == "" {
-		return nil, fmt.Errorf("%s: neither %s nor %s set", op, annotation.LBLocation, annotation.LBNetworkZone)
+		return opts, fmt.Errorf("neither %s nor %s set", annotation.LBLocation, annotation.LBNetworkZone)
 	}
 	if opts.Location != nil && opts.NetworkZone != "" {
 		opts.NetworkZone = ""
@@ -204,43 +306,34 @@ func (l *LoadBalancerOps) Create(
 
 	algType, err := annotation.LBAlgorithmType.LBAlgorithmTypeFromService(svc)
 	if err != nil && !errors.Is(err, annotation.ErrNotSet) {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return opts, err
 	}
 	if !errors.Is(err, annotation.ErrNotSet) {
 		opts.Algorithm = &hcloud.LoadBalancerAlgorithm{Type: algType}
 	}
 
-	if l.NetworkID > 0 {
-		nw, _, err := l.NetworkClient.GetByID(ctx, l.NetworkID)
+	networkID, err := l.lbNetworkID(ctx, svc)
+	if err != nil {
+		return opts, err
+	}
+	if networkID > 0 {
+		nw, _, err := l.NetworkClient.GetByID(ctx, networkID)
 		if err != nil {
-			return nil, fmt.Errorf("%s: get network %d: %w", op, l.NetworkID, err)
+			return opts, fmt.Errorf("get network %d: %w", networkID, err)
 		}
 		if nw == nil {
-			return nil, fmt.Errorf("%s: get network %d: %w", op, l.NetworkID, ErrNotFound)
+			return opts, fmt.Errorf("get network %d: %w", networkID, ErrNotFound)
 		}
 		opts.Network = nw
 	}
-	disablePubIface, err := annotation.LBDisablePublicNetwork.BoolFromService(svc)
-	if err != nil && !errors.Is(err, annotation.ErrNotSet) {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-	if disablePubIface && !errors.Is(err, annotation.ErrNotSet) {
-		opts.PublicInterface = hcloud.Ptr(false)
-	}
-
-	result, _, err := l.LBClient.Create(ctx, opts)
+	disablePubIface, err := l.getDisablePublicNetwork(svc)
 	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-	if err := WatchAction(ctx, l.ActionClient, result.Action); err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return opts, err
 	}
-
-	lb, err := l.GetByID(ctx, result.LoadBalancer.ID)
-	if err != nil {
-		return nil, fmt.Errorf("%s: get Load Balancer: %d: %w", op, result.LoadBalancer.ID, err)
+	if disablePubIface {
+		opts.PublicInterface = hcloud.Ptr(false)
 	}
-	return lb, nil
+	return opts, nil
 }
 
 // Delete removes a Hetzner Cloud load balancer from the backend.
@@ -248,6 +341,18 @@ func (l *LoadBalancerOps) Delete(ctx context.Context, lb *hcloud.LoadBalancer) e
 	const op = "hcops/LoadBalancerOps.Delete"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
+	// Remove the labels of the label selector targets created for a Service,
+	// before the Load Balancer, and with it the selectors, are gone.
+	for _, target := range lb.Targets {
+		if target.Type != hcloud.LoadBalancerTargetTypeLabelSelector ||
+			!strings.HasPrefix(target.LabelSelector.Selector, labelTargetPrefix) {
+			continue
+		}
+		if _, err := l.reconcileTargetLabels(ctx, nil, target.LabelSelector.Selector, nil); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
 	_, err := l.LBClient.Delete(ctx, lb)
 	if hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
 		return nil
@@ -296,13 +401,18 @@ func (l *LoadBalancerOps) ReconcileHCLB(ctx context.Context, lb *hcloud.LoadBala
 	}
 	changed = changed || typeChanged
 
-	networkDetached, err := l.detachFromNetwork(ctx, lb)
+	networkID, err := l.lbNetworkID(ctx, svc)
+	if err != nil {
+		return changed, fmt.Errorf("%s: %w", op, err)
+	}
+
+	networkDetached, err := l.detachFromNetwork(ctx, lb, svc, networkID)
 	if err != nil {
 		return changed, fmt.Errorf("%s: %w", op, err)
 	}
 	changed = changed || networkDetached
 
-	networkAttached, err := l.attachToNetwork(ctx, lb)
+	networkAttached, err := l.attachToNetwork(ctx, lb, svc, networkID)
 	if err != nil {
 		return changed, fmt.Errorf("%s: %w", op, err)
 	}
@@ -352,6 +462,9 @@ func (l *LoadBalancerOps) changeHCLBInfo(ctx context.Context, lb *hcloud.LoadBal
 	if !update {
 		return false, nil
 	}
+	if l.planned(LoadBalancerChange{Kind: ChangeNameAndLabels, From: lb.Name, To: opts.Name}) {
+		return true, nil
+	}
 
 	updated, _, err := l.LBClient.Update(ctx, lb, opts)
 	if err != nil {
@@ -359,6 +472,7 @@ func (l *LoadBalancerOps) changeHCLBInfo(ctx context.Context, lb *hcloud.LoadBal
 	}
 	lb.Name = updated.Name
 	lb.Labels = updated.Labels
+	l.Recorder.Eventf(svc, corev1.EventTypeNormal, EventLoadBalancerUpdated, "Updated name and labels of Load Balancer %s", lb.Name)
 
 	return true, nil
 }
@@ -376,6 +490,9 @@ func (l *LoadBalancerOps) changeIPv4RDNS(ctx context.Context, lb *hcloud.LoadBal
 	if rdns == lb.PublicNet.IPv4.DNSPtr {
 		return false, nil
 	}
+	if l.planned(LoadBalancerChange{Kind: ChangeReverseDNS, Subject: "IPv4", From: lb.PublicNet.IPv4.DNSPtr, To: rdns}) {
+		return true, nil
+	}
 
 	action, _, err := l.LBClient.ChangeDNSPtr(ctx, lb, lb.PublicNet.IPv4.IP.String(), &rdns)
 	if err != nil {
@@ -385,6 +502,7 @@ func (l *LoadBalancerOps) changeIPv4RDNS(ctx context.Context, lb *hcloud.LoadBal
 	if err != nil {
 		return false, fmt.Errorf("%s: %w", op, err)
 	}
+	l.Recorder.Eventf(svc, corev1.EventTypeNormal, EventReverseDNSChanged, "Changed reverse DNS of %s to %s", lb.PublicNet.IPv4.IP, rdns)
 	return true, nil
 }
 
@@ -401,6 +519,9 @@ func (l *LoadBalancerOps) changeIPv6RDNS(ctx context.Context, lb *hcloud.LoadBal
 	if rdns == lb.PublicNet.IPv6.DNSPtr {
 		return false, nil
 	}
+	if l.planned(LoadBalancerChange{Kind: ChangeReverseDNS, Subject: "IPv6", From: lb.PublicNet.IPv6.DNSPtr, To: rdns}) {
+		return true, nil
+	}
 
 	action, _, err := l.LBClient.ChangeDNSPtr(ctx, lb, lb.PublicNet.IPv6.IP.String(), &rdns)
 	if err != nil {
@@ -410,6 +531,7 @@ func (l *LoadBalancerOps) changeIPv6RDNS(ctx context.Context, lb *hcloud.LoadBal
 	if err != nil {
 		return false, fmt.Errorf("%s: %w", op, err)
 	}
+	l.Recorder.Eventf(svc, corev1.EventTypeNormal, EventReverseDNSChanged, "Changed reverse DNS of %s to %s", lb.PublicNet.IPv6.IP, rdns)
 	return true, nil
 }
 
@@ -428,6 +550,10 @@ func (l *LoadBalancerOps) changeAlgorithm(ctx context.Context, lb *hcloud.LoadBa
 		return false, nil
 	}
 
+	if l.planned(LoadBalancerChange{Kind: ChangeAlgorithm, From: string(lb.Algorithm.Type), To: string(at)}) {
+		return true, nil
+	}
+
 	opts := hcloud.LoadBalancerChangeAlgorithmOpts{Type: at}
 	action, _, err := l.LBClient.ChangeAlgorithm(ctx, lb, opts)
 	if err != nil {
@@ -437,6 +563,7 @@ func (l *LoadBalancerOps) changeAlgorithm(ctx context.Context, lb *hcloud.LoadBa
 	if err != nil {
 		return false, fmt.Errorf("%s: %w", op, err)
 	}
+	l.Recorder.Eventf(svc, corev1.EventTypeNormal, EventAlgorithmChanged, "Changed algorithm from %s to %s", lb.Algorithm.Type, at)
 	return true, nil
 }
 
@@ -452,6 +579,10 @@ func (l *LoadBalancerOps) changeType(ctx context.Context, lb *hcloud.LoadBalance
 		return false, nil
 	}
 
+	if l.planned(LoadBalancerChange{Kind: ChangeType, From: lb.LoadBalancerType.Name, To: lt}) {
+		return true, nil
+	}
+
 	opts := hcloud.LoadBalancerChangeTypeOpts{LoadBalancerType: &hcloud.LoadBalancerType{Name: lt}}
 	action, _, err := l.LBClient.ChangeType(ctx, lb, opts)
 	if err != nil {
@@ -461,10 +592,40 @@ func (l *LoadBalancerOps) changeType(ctx context.Context, lb *hcloud.LoadBalance
 	if err != nil {
 		return false, fmt.Errorf("%s: %w", op, err)
 	}
+	l.Recorder.Eventf(svc, corev1.EventTypeNormal, EventTypeChanged, "Changed type from %s to %s", lb.LoadBalancerType.Name, lt)
 	return true, nil
 }
 
-func (l *LoadBalancerOps) detachFromNetwork(ctx context.Context, lb *hcloud.LoadBalancer) (bool, error) {
+// lbNetworkID returns the ID of the network the Load Balancer of svc is
+// attached to. It is selected by the annotation LBNetwork and defaults to
+// l.NetworkID. Zero means no network.
+func (l *LoadBalancerOps) lbNetworkID(ctx context.Context, svc *corev1.Service) (int64, error) {
+	const op = "hcops/LoadBalancerOps.lbNetworkID"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	nameOrID, ok := annotation.LBNetwork.StringFromService(svc)
+	if !ok {
+		return l.NetworkID, nil
+	}
+	// A numeric annotation is resolved by ID as well, so that a Load Balancer
+	// is not detached from its network for a network which does not exist.
+	var nw *hcloud.Network
+	var err error
+	if id, parseErr := strconv.ParseInt(nameOrID, 10, 64); parseErr == nil {
+		nw, _, err = l.NetworkClient.GetByID(ctx, id)
+	} else {
+		nw, _, err = l.NetworkClient.Get(ctx, nameOrID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: get network %s: %w", op, nameOrID, err)
+	}
+	if nw == nil {
+		return 0, fmt.Errorf("%s: get network %s: %w", op, nameOrID, ErrNotFound)
+	}
+	return nw.ID, nil
+}
+
+func (l *LoadBalancerOps) detachFromNetwork(ctx context.Context, lb *hcloud.LoadBalancer, svc *corev1.Service, networkID int64) (bool, error) {
 	const op = "hcops/LoadBalancerOps.detachFromNetwork"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
@@ -473,7 +634,11 @@ func (l *LoadBalancerOps) detachFromNetwork(ctx context.Context, lb *hcloud.Load
 	for _, lbpn := range lb.PrivateNet {
 		// Don't detach the Load Balancer from the network it is supposed to
 		// be attached to.
-		if l.NetworkID == lbpn.Network.ID {
+		if networkID == lbpn.Network.ID {
+			continue
+		}
+		if l.planned(LoadBalancerChange{Kind: ChangeDetachFromNetwork, Subject: strconv.FormatInt(lbpn.Network.ID, 10)}) {
+			changed = true
 			continue
 		}
 		klog.InfoS("detach from network", "op", op, "loadBalancerID", lb.ID, "networkID", lbpn.Network.ID)
@@ -486,28 +651,32 @@ func (l *LoadBalancerOps) detachFromNetwork(ctx context.Context, lb *hcloud.Load
 		if err := WatchAction(ctx, l.ActionClient, a); err != nil {
 			return changed, fmt.Errorf("%s: %w", op, err)
 		}
+		l.Recorder.Eventf(svc, corev1.EventTypeNormal, EventNetworkDetached, "Detached Load Balancer from network %d", lbpn.Network.ID)
 		changed = true
 	}
 	return changed, nil
 }
 
-func (l *LoadBalancerOps) attachToNetwork(ctx context.Context, lb *hcloud.LoadBalancer) (bool, error) {
+func (l *LoadBalancerOps) attachToNetwork(ctx context.Context, lb *hcloud.LoadBalancer, svc *corev1.Service, networkID int64) (bool, error) {
 	const op = "hcops/LoadBalancerOps.attachToNetwork"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
 	// Don't attach the Load Balancer if network is not set, or the load
 	// balancer is already attached.
-	if l.NetworkID == 0 || lbAttached(lb, l.NetworkID) {
+	if networkID == 0 || lbAttached(lb, networkID) {
 		return false, nil
 	}
-	klog.InfoS("attach to network", "op", op, "loadBalancerID", lb.ID, "networkID", l.NetworkID)
+	if l.planned(LoadBalancerChange{Kind: ChangeAttachToNetwork, Subject: strconv.FormatInt(networkID, 10)}) {
+		return true, nil
+	}
+	klog.InfoS("attach to network", "op", op, "loadBalancerID", lb.ID, "networkID", networkID)
 
-	nw, _, err := l.NetworkClient.GetByID(ctx, l.NetworkID)
+	nw, _, err := l.NetworkClient.GetByID(ctx, networkID)
 	if err != nil {
 		return false, fmt.Errorf("%s: %w", op, err)
 	}
 	if nw == nil || hcloud.IsError(err, hcloud.ErrorCodeNotFound) {
-		return false, fmt.Errorf("%s: %d: not found", op, l.NetworkID)
+		return false, fmt.Errorf("%s: %d: not found", op, networkID)
 	}
 
 	retryDelay := l.RetryDelay
@@ -530,6 +699,7 @@ func (l *LoadBalancerOps) attachToNetwork(ctx context.Context, lb *hcloud.LoadBa
 	if err := WatchAction(ctx, l.ActionClient, a); err != nil {
 		return false, fmt.Errorf("%s: %w", op, err)
 	}
+	l.Recorder.Eventf(svc, corev1.EventTypeNormal, EventNetworkAttached, "Attached Load Balancer to network %s (%d)", nw.Name, nw.ID)
 
 	return true, nil
 }
@@ -542,7 +712,12 @@ func (l *LoadBalancerOps) togglePublicInterface(ctx context.Context, lb *hcloud.
 
 	disable, err := annotation.LBDisablePublicNetwork.BoolFromService(svc)
 	if errors.Is(err, annotation.ErrNotSet) {
-		return false, nil
+		// The public interface is only disabled by default, it is not
+		// enabled for Services without annotation.
+		disable, err = l.defaults(svc).DisablePublicNetwork, nil
+		if !disable {
+			return false, nil
+		}
 	}
 	if err != nil {
 		return false, fmt.Errorf("%s: %w", op, err)
@@ -551,6 +726,10 @@ func (l *LoadBalancerOps) togglePublicInterface(ctx context.Context, lb *hcloud.
 	if disable == !lb.PublicNet.Enabled {
 		return false, nil
 	}
+	if disable && l.planned(LoadBalancerChange{Kind: ChangeDisablePublicInterface}) ||
+		!disable && l.planned(LoadBalancerChange{Kind: ChangeEnablePublicInterface}) {
+		return true, nil
+	}
 
 	if disable {
 		a, _, err = l.LBClient.DisablePublicInterface(ctx, lb)
@@ -564,6 +743,11 @@ func (l *LoadBalancerOps) togglePublicInterface(ctx context.Context, lb *hcloud.
 	if err := WatchAction(ctx, l.ActionClient, a); err != nil {
 		return false, fmt.Errorf("%s: %w", op, err)
 	}
+	if disable {
+		l.Recorder.Event(svc, corev1.EventTypeNormal, EventPublicInterfaceToggled, "Disabled public interface")
+	} else {
+		l.Recorder.Event(svc, corev1.EventTypeNormal, EventPublicInterfaceToggled, "Enabled public interface")
+	}
 	return true, nil
 }
 
@@ -573,7 +757,20 @@ func (l *LoadBalancerOps) getDisableIPv6(svc *corev1.Service) (bool, error) {
 		return disable, nil
 	}
 	if errors.Is(err, annotation.ErrNotSet) {
-		return l.Defaults.DisableIPv6, nil
+		return l.defaults(svc).DisableIPv6, nil
+	}
+	return false, err
+}
+
+// getDisablePublicNetwork reports whether the public interface of the Load
+// Balancer of svc is disabled.
+func (l *LoadBalancerOps) getDisablePublicNetwork(svc *corev1.Service) (bool, error) {
+	disable, err := annotation.LBDisablePublicNetwork.BoolFromService(svc)
+	if err == nil {
+		return disable, nil
+	}
+	if errors.Is(err, annotation.ErrNotSet) {
+		return l.defaults(svc).DisablePublicNetwork, nil
 	}
 	return false, err
 }
@@ -593,9 +790,10 @@ func (l *LoadBalancerOps) ReconcileHCLBTargets(
 		k8sNodeIDsRobot  = make(map[int]bool)
 		k8sNodeNames     = make(map[int64]string)
 
-		robotIPsToIDs = make(map[string]int)
-		robotIDToIPv4 = make(map[int]string)
-		robotIDToIPv6 = make(map[int]string)
+		robotIPsToIDs      = make(map[string]int)
+		robotIDToIPv4      = make(map[int]string)
+		robotIDToIPv6      = make(map[int]string)
+		robotIDToPrivateIP = make(map[int]string)
 		// Set of server IDs assigned as targets to the HC Load Balancer. Some
 		// of the entries may get deleted during reconcilement. In this case
 		// the hclbTargetIDs[id] is always false. If hclbTargetIDs[id] is true,
@@ -620,8 +818,30 @@ func (l *LoadBalancerOps) ReconcileHCLBTargets(
 	if err != nil {
 		return changed, fmt.Errorf("%s: %w", op, err)
 	}
-	if usePrivateIP && l.NetworkID == 0 {
-		return changed, fmt.Errorf("%s: use private ip: missing network id", op)
+	useLabelSelector, err := l.getUseLabelSelector(svc)
+	if err != nil {
+		return changed, fmt.Errorf("%s: %w", op, err)
+	}
+	var vSwitchSubnets []*net.IPNet
+	if usePrivateIP {
+		networkID, err := l.lbNetworkID(ctx, svc)
+		if err != nil {
+			return changed, fmt.Errorf("%s: %w", op, err)
+		}
+		if networkID == 0 {
+			return changed, fmt.Errorf("%s: use private ip: missing network id", op)
+		}
+		// Robot servers are connected to the network via a vSwitch.
+		if slices.ContainsFunc(nodes, isRobotNode) {
+			nw, _, err := l.NetworkClient.GetByID(ctx, networkID)
+			if err != nil {
+				return changed, fmt.Errorf("%s: get network %d: %w", op, networkID, err)
+			}
+			if nw == nil {
+				return changed, fmt.Errorf("%s: get network %d: %w", op, networkID, ErrNotFound)
+			}
+			vSwitchSubnets = VSwitchSubnets(nw)
+		}
 	}
 
 	// Extract HC server IDs of all K8S nodes assigned to the K8S cluster.
@@ -645,6 +865,7 @@ func (l *LoadBalancerOps) ReconcileHCLBTargets(
 			k8sNodeIDsHCloud[id] = true
 		} else {
 			k8sNodeIDsRobot[int(id)] = true
+			robotIDToPrivateIP[int(id)] = RobotVSwitchIP(node, vSwitchSubnets)
 		}
 		k8sNodeNames[id] = node.Name
 	}
@@ -656,7 +877,7 @@ func (l *LoadBalancerOps) ReconcileHCLBTargets(
 	if l.RobotClient != nil {
 		dedicatedServers, err = l.RobotClient.ServerGetList()
 		if err != nil {
-			HandleRateLimitExceededError(err, svc)
+			HandleRateLimitExceededError(l.Recorder, err, svc)
 			return changed, fmt.Errorf("%s: failed to get list of dedicated servers: %w", op, err)
 		}
 	}
@@ -667,9 +888,33 @@ func (l *LoadBalancerOps) ReconcileHCLBTargets(
 		robotIDToIPv4[s.ServerNumber] = s.ServerIP
 		robotIDToIPv6[s.ServerNumber] = s.ServerIPv6Net + "1"
 	}
+	if usePrivateIP {
+		for id, ip := range robotIDToPrivateIP {
+			if ip != "" {
+				robotIPsToIDs[ip] = id
+			}
+		}
+	}
 
 	numberOfTargets := len(lb.Targets)
 
+	// Targets which are removed before the additions are made, so that the
+	// additions do not exceed the maximum number of targets.
+	var removals, additions []targetChange
+	// Targets which are replaced by the label selector target, or replace it.
+	// They are removed only after their replacement was added, so that the
+	// Load Balancer keeps its targets while the Service migrates.
+	var replaced []targetChange
+
+	// The label selector target selects the servers labeled with
+	// targetLabel. A Load Balancer may have it even if useLabelSelector is
+	// false, if the Service used label selector targets before.
+	var (
+		targetLabel         = labelTargetPrefix + string(svc.UID)
+		hasLabelSelector    bool
+		removeLabelSelector bool
+	)
+
 	// Extract IDs of the hc Load Balancer's server targets. Along the way,
 	// Remove all server targets from the HC Load Balancer which are currently
 	// not assigned as nodes to the K8S Load Balancer.
@@ -677,55 +922,80 @@ func (l *LoadBalancerOps) ReconcileHCLBTargets(
 		if target.Type == hcloud.LoadBalancerTargetTypeServer {
 			id := target.Server.Server.ID
 			recreate := target.UsePrivateIP != usePrivateIP
-			hclbTargetIDs[id] = k8sNodeIDsHCloud[id] && !recreate
+			hclbTargetIDs[id] = !useLabelSelector && k8sNodeIDsHCloud[id] && !recreate
 			if hclbTargetIDs[id] {
 				continue
 			}
 
-			klog.InfoS("remove target", "op", op, "service", svc.Name, "targetName", k8sNodeNames[id])
 			// Target needs to be re-created or node currently not in use by k8s
 			// Load Balancer. Remove it from the HC Load Balancer
-			a, _, err := l.LBClient.RemoveServerTarget(ctx, lb, target.Server.Server)
-			if err != nil {
-				return changed, fmt.Errorf("%s: target: %s: %w", op, k8sNodeNames[id], err)
-			}
-			if err := WatchAction(ctx, l.ActionClient, a); err != nil {
-				return changed, fmt.Errorf("%s: target: %s: %w", op, k8sNodeNames[id], err)
+			server := target.Server.Server
+			changes := &removals
+			if useLabelSelector && k8sNodeIDsHCloud[id] {
+				changes = &replaced
 			}
-			changed = true
+			*changes = append(*changes, targetChange{
+				change: LoadBalancerChange{Kind: ChangeRemoveTarget, Subject: fmt.Sprintf("server %d", id)},
+				name:   "target: " + k8sNodeNames[id],
+				event:  fmt.Sprintf("Removed server %d from targets", id),
+				apply: func(ctx context.Context) (*hcloud.Action, error) {
+					a, _, err := l.LBClient.RemoveServerTarget(ctx, lb, server)
+					return a, err
+				},
+			})
 			numberOfTargets--
 		}
 
 		if target.Type == hcloud.LoadBalancerTargetTypeIP {
 			ip := target.IP.IP
 			id, foundServer := robotIPsToIDs[ip]
-			hclbTargetIPs[ip] = foundServer && k8sNodeIDsRobot[id]
+			hclbTargetIPs[ip] = foundServer && k8sNodeIDsRobot[id] && (!usePrivateIP || ip == robotIDToPrivateIP[id])
 			if hclbTargetIPs[ip] {
 				continue
 			}
 
-			klog.InfoS("remove target", "op", op, "service", svc.Name, "targetName", k8sNodeNames[int64(id)])
+			name := "targetIP: " + ip
+			if foundServer {
+				name = "target: " + k8sNodeNames[int64(id)]
+			}
 			// Node currently not in use by k8s Load Balancer. Remove it from the HC Load Balancer.
-			a, _, err := l.LBClient.RemoveIPTarget(ctx, lb, net.ParseIP(ip))
-			if err != nil {
-				var e error
-				if foundServer {
-					e = fmt.Errorf("%s: target: %s: %w", op, k8sNodeNames[int64(id)], err)
-				} else {
-					e = fmt.Errorf("%s: targetIP: %s: %w", op, ip, err)
-				}
-				return changed, e
+			removals = append(removals, targetChange{
+				change: LoadBalancerChange{Kind: ChangeRemoveTarget, Subject: "ip " + ip},
+				name:   name,
+				event:  fmt.Sprintf("Removed IP %s from targets", ip),
+				apply: func(ctx context.Context) (*hcloud.Action, error) {
+					a, _, err := l.LBClient.RemoveIPTarget(ctx, lb, net.ParseIP(ip))
+					return a, err
+				},
+			})
+			numberOfTargets--
+		}
+
+		if target.Type == hcloud.LoadBalancerTargetTypeLabelSelector {
+			selector := target.LabelSelector.Selector
+			// Label selector targets not created for a Service are left alone.
+			if !strings.HasPrefix(selector, labelTargetPrefix) {
+				continue
 			}
-			if err := WatchAction(ctx, l.ActionClient, a); err != nil {
-				var e error
-				if foundServer {
-					e = fmt.Errorf("%s: target: %s: %w", op, k8sNodeNames[int64(id)], err)
-				} else {
-					e = fmt.Errorf("%s: targetIP: %s: %w", op, ip, err)
-				}
-				return changed, e
+			if useLabelSelector && selector == targetLabel && target.UsePrivateIP == usePrivateIP {
+				hasLabelSelector = true
+				continue
 			}
-			changed = true
+			changes := &removals
+			if selector == targetLabel && !useLabelSelector {
+				removeLabelSelector = true
+				changes = &replaced
+			}
+
+			*changes = append(*changes, targetChange{
+				change: LoadBalancerChange{Kind: ChangeRemoveTarget, Subject: "label selector " + selector},
+				name:   "label selector target: " + selector,
+				event:  fmt.Sprintf("Removed label selector %s from targets", selector),
+				apply: func(ctx context.Context) (*hcloud.Action, error) {
+					a, _, err := l.LBClient.RemoveLabelSelectorTarget(ctx, lb, selector)
+					return a, err
+				},
+			})
 			numberOfTargets--
 		}
 	}
@@ -734,8 +1004,8 @@ func (l *LoadBalancerOps) ReconcileHCLBTargets(
 	// to the K8S Load Balancer as server targets to the HC Load Balancer.
 	for id := range k8sNodeIDsHCloud {
 		// Don't assign the node again if it is already assigned to the HC load
-		// balancer.
-		if hclbTargetIDs[id] {
+		// balancer, or selected by the label selector target.
+		if hclbTargetIDs[id] || useLabelSelector {
 			continue
 		}
 		if lb.LoadBalancerType != nil && maxTargetsReached(numberOfTargets, lb.LoadBalancerType.Name) {
@@ -748,23 +1018,19 @@ func (l *LoadBalancerOps) ReconcileHCLBTargets(
 			continue
 		}
 
-		klog.InfoS("add target", "op", op, "service", svc.Name, "targetName", k8sNodeNames[id])
 		opts := hcloud.LoadBalancerAddServerTargetOpts{
 			Server:       &hcloud.Server{ID: id},
 			UsePrivateIP: &usePrivateIP,
 		}
-		a, _, err := l.LBClient.AddServerTarget(ctx, lb, opts)
-		if err != nil {
-			if hcloud.IsError(err, hcloud.ErrorCodeResourceLimitExceeded) {
-				klog.InfoS("resource limit exceeded", "err", err.Error(), "op", op, "service", svc.Name, "targetName", k8sNodeNames[id])
-				return false, nil
-			}
-			return changed, fmt.Errorf("%s: target %s: %w", op, k8sNodeNames[id], err)
-		}
-		if err := WatchAction(ctx, l.ActionClient, a); err != nil {
-			return changed, fmt.Errorf("%s: target %s: %w", op, k8sNodeNames[id], err)
-		}
-		changed = true
+		additions = append(additions, targetChange{
+			change: LoadBalancerChange{Kind: ChangeAddTarget, Subject: "node " + k8sNodeNames[id]},
+			name:   "target " + k8sNodeNames[id],
+			event:  fmt.Sprintf("Added node %s as target", k8sNodeNames[id]),
+			apply: func(ctx context.Context) (*hcloud.Action, error) {
+				a, _, err := l.LBClient.AddServerTarget(ctx, lb, opts)
+				return a, err
+			},
+		})
 		numberOfTargets++
 	}
 
@@ -772,7 +1038,20 @@ func (l *LoadBalancerOps) ReconcileHCLBTargets(
 	// to the K8S Load Balancer as IP targets to the HC Load Balancer.
 	for id := range k8sNodeIDsRobot {
 		var arr []string
-		if disableIPv6 {
+		if usePrivateIP {
+			if robotIDToPrivateIP[id] == "" {
+				l.Recorder.Eventf(
+					svc,
+					corev1.EventTypeWarning,
+					"PrivateIPMissing",
+					"cannot add node %s as private ip target because it has no InternalIP in a vSwitch subnet", k8sNodeNames[int64(id)],
+				)
+				continue
+			}
+			arr = []string{
+				robotIDToPrivateIP[id],
+			}
+		} else if disableIPv6 {
 			arr = []string{
 				robotIDToIPv4[id],
 			}
@@ -804,39 +1083,287 @@ func (l *LoadBalancerOps) ReconcileHCLBTargets(
 				continue
 			}
 
-			klog.InfoS("add target", "op", op, "service", svc.Name, "targetName", k8sNodeNames[int64(id)], "ip", ip)
 			opts := hcloud.LoadBalancerAddIPTargetOpts{
 				IP: net.ParseIP(ip),
 			}
-			a, _, err := l.LBClient.AddIPTarget(ctx, lb, opts)
+			additions = append(additions, targetChange{
+				change: LoadBalancerChange{Kind: ChangeAddTarget, Subject: fmt.Sprintf("node %s ip %s", k8sNodeNames[int64(id)], ip)},
+				name:   "target " + k8sNodeNames[int64(id)],
+				event:  fmt.Sprintf("Added node %s as target with IP %s", k8sNodeNames[int64(id)], ip),
+				apply: func(ctx context.Context) (*hcloud.Action, error) {
+					a, _, err := l.LBClient.AddIPTarget(ctx, lb, opts)
+					return a, err
+				},
+			})
+			numberOfTargets++
+		}
+	}
+
+	if useLabelSelector && !hasLabelSelector {
+		opts := hcloud.LoadBalancerAddLabelSelectorTargetOpts{
+			Selector:     targetLabel,
+			UsePrivateIP: &usePrivateIP,
+		}
+		additions = append(additions, targetChange{
+			change: LoadBalancerChange{Kind: ChangeAddTarget, Subject: "label selector " + targetLabel},
+			name:   "label selector target: " + targetLabel,
+			event:  fmt.Sprintf("Added label selector %s as target", targetLabel),
+			apply: func(ctx context.Context) (*hcloud.Action, error) {
+				a, _, err := l.LBClient.AddLabelSelectorTarget(ctx, lb, opts)
+				return a, err
+			},
+		})
+	}
+
+	removed, errRemove := l.applyTargetChanges(ctx, svc, removals)
+
+	// Label the servers before the label selector target is added, and remove
+	// the labels once it is removed, so that only the servers of the Nodes
+	// receive traffic.
+	var (
+		labeled  bool
+		errLabel error
+	)
+	if useLabelSelector {
+		labeled, errLabel = l.reconcileTargetLabels(ctx, svc, targetLabel, k8sNodeIDsHCloud)
+	}
+
+	added, errAdd := l.applyTargetChanges(ctx, svc, additions)
+
+	// The replaced targets are kept if their replacement failed, so that the
+	// Load Balancer is not left without targets.
+	var (
+		replacedRemoved bool
+		errReplace      error
+	)
+	if errLabel == nil && errAdd == nil {
+		replacedRemoved, errReplace = l.applyTargetChanges(ctx, svc, replaced)
+		if removeLabelSelector && errReplace == nil {
+			var unlabeled bool
+			unlabeled, errReplace = l.reconcileTargetLabels(ctx, svc, targetLabel, nil)
+			labeled = labeled || unlabeled
+		}
+	}
+
+	changed = removed || labeled || added || replacedRemoved
+	if err := errors.Join(errRemove, errLabel, errAdd, errReplace); err != nil {
+		return changed, fmt.Errorf("%s: %w", op, err)
+	}
+	return changed, nil
+}
+
+// reconcileTargetLabels sets the label key on the servers with the IDs in
+// serverIDs, and removes it from all other servers. Events are recorded on
+// svc, unless it is nil.
+func (l *LoadBalancerOps) reconcileTargetLabels(
+	ctx context.Context, svc *corev1.Service, key string, serverIDs map[int64]bool,
+) (bool, error) {
+	const op = "hcops/LoadBalancerOps.reconcileTargetLabels"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	if l.ServerClient == nil {
+		return false, fmt.Errorf("%s: label selector targets require a server client", op)
+	}
+
+	opts := hcloud.ServerListOpts{ListOpts: hcloud.ListOpts{LabelSelector: key}}
+	servers, err := l.ServerClient.AllWithOpts(ctx, opts)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var changed bool
+
+	labeled := make(map[int64]bool, len(servers))
+	for _, server := range servers {
+		if serverIDs[server.ID] {
+			labeled[server.ID] = true
+			continue
+		}
+
+		changed = true
+		if l.planned(LoadBalancerChange{Kind: ChangeUnlabelServer, Subject: fmt.Sprintf("server %d", server.ID)}) {
+			continue
+		}
+		labels := maps.Clone(server.Labels)
+		delete(labels, key)
+		if _, _, err := l.ServerClient.Update(ctx, server, hcloud.ServerUpdateOpts{Labels: labels}); err != nil {
+			return changed, fmt.Errorf("%s: server %s: %w", op, server.Name, err)
+		}
+		if svc != nil {
+			l.Recorder.Eventf(svc, corev1.EventTypeNormal, EventServerUnlabeled, "Removed label %s from server %s", key, server.Name)
+		}
+	}
+
+	for id := range serverIDs {
+		if labeled[id] {
+			continue
+		}
+
+		changed = true
+		if l.planned(LoadBalancerChange{Kind: ChangeLabelServer, Subject: fmt.Sprintf("server %d", id)}) {
+			continue
+		}
+		server, _, err := l.ServerClient.GetByID(ctx, id)
+		if err != nil {
+			return changed, fmt.Errorf("%s: server %d: %w", op, id, err)
+		}
+		if server == nil {
+			klog.InfoS("k8s node found but no corresponding server", "op", op, "id", id)
+			continue
+		}
+		labels := maps.Clone(server.Labels)
+		if labels == nil {
+			labels = make(map[string]string)
+		}
+		labels[key] = ""
+		if _, _, err := l.ServerClient.Update(ctx, server, hcloud.ServerUpdateOpts{Labels: labels}); err != nil {
+			return changed, fmt.Errorf("%s: server %s: %w", op, server.Name, err)
+		}
+		if svc != nil {
+			l.Recorder.Eventf(svc, corev1.EventTypeNormal, EventServerLabeled, "Added label %s to server %s", key, server.Name)
+		}
+	}
+
+	return changed, nil
+}
+
+// targetChange is a target to add to or remove from a Load Balancer.
+type targetChange struct {
+	change LoadBalancerChange
+	// name identifies the target in errors.
+	name string
+	// event is the message of the event recorded once the change is applied.
+	event string
+	apply func(ctx context.Context) (*hcloud.Action, error)
+}
+
+// applyTargetChanges applies changes concurrently, at most l.TargetWorkers at
+// once. It does not stop at the first failed change, but returns the errors
+// of all of them. The returned bool reports whether any change was applied.
+// If l only plans changes, they are added to the plan instead.
+func (l *LoadBalancerOps) applyTargetChanges(ctx context.Context, svc *corev1.Service, changes []targetChange) (bool, error) {
+	const op = "hcops/LoadBalancerOps.applyTargetChanges"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	if l.plan != nil {
+		for _, c := range changes {
+			l.planned(c.change)
+		}
+		return len(changes) > 0, nil
+	}
+
+	var (
+		g       errgroup.Group
+		mu      sync.Mutex
+		changed bool
+		errs    []error
+	)
+	g.SetLimit(max(l.TargetWorkers, 1))
+
+	for _, c := range changes {
+		g.Go(func() error {
+			err := l.applyTargetChange(ctx, svc, c)
+
+			mu.Lock()
+			defer mu.Unlock()
 			if err != nil {
-				if hcloud.IsError(err, hcloud.ErrorCodeResourceLimitExceeded) {
-					klog.InfoS("resource limit exceeded", "err", err.Error(), "op", op, "service", svc.Name, "targetName", k8sNodeNames[int64(id)])
-					return false, nil
-				}
-				return changed, fmt.Errorf("%s: target %s: %w", op, k8sNodeNames[int64(id)], err)
-			}
-			if err := WatchAction(ctx, l.ActionClient, a); err != nil {
-				return changed, fmt.Errorf("%s: target %s: %w", op, k8sNodeNames[int64(id)], err)
+				errs = append(errs, err)
+				return nil
 			}
 			changed = true
-			numberOfTargets++
+			return nil
+		})
+	}
+	_ = g.Wait()
+	return changed, errors.Join(errs...)
+}
+
+func (l *LoadBalancerOps) applyTargetChange(ctx context.Context, svc *corev1.Service, c targetChange) error {
+	const op = "hcops/LoadBalancerOps.applyTargetChange"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	backoff := targetChangeBackoff
+	if l.RetryDelay != 0 {
+		backoff.Duration = l.RetryDelay
+	}
+
+	msg, reason := "add target", EventTargetAdded
+	if c.change.Kind == ChangeRemoveTarget {
+		msg, reason = "remove target", EventTargetRemoved
+	}
+
+	klog.InfoS(msg, "op", op, "service", svc.Name, "target", c.change.Subject)
+	a, err := c.apply(ctx)
+	// Concurrent changes of the targets lock the Load Balancer. The jitter
+	// spreads the retries of the concurrent workers.
+	for backoff.Steps > 1 && isLockedOrConflict(err) {
+		delay := backoff.Step()
+		klog.InfoS("retry due to conflict or lock",
+			"op", op, "delay", fmt.Sprintf("%v", delay), "err", fmt.Sprintf("%v", err))
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w", c.name, ctx.Err())
+		case <-time.After(delay):
 		}
+		a, err = c.apply(ctx)
 	}
-	return changed, nil
+	if hcloud.IsError(err, hcloud.ErrorCodeResourceLimitExceeded) {
+		klog.InfoS("resource limit exceeded", "err", err.Error(), "op", op, "service", svc.Name, "target", c.change.Subject)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: %w", c.name, err)
+	}
+	if err := WatchAction(ctx, l.ActionClient, a); err != nil {
+		return fmt.Errorf("%s: %w", c.name, err)
+	}
+	l.Recorder.Event(svc, corev1.EventTypeNormal, reason, c.event)
+	return nil
+}
+
+// targetChangeBackoff is the backoff of the retries to change a target of a
+// locked Load Balancer. LoadBalancerOps.RetryDelay replaces its Duration.
+var targetChangeBackoff = wait.Backoff{
+	Duration: time.Second,
+	Factor:   2,
+	Jitter:   0.5,
+	Steps:    8,
+	Cap:      30 * time.Second,
+}
+
+func isLockedOrConflict(err error) bool {
+	return hcloud.IsError(err, hcloud.ErrorCodeLocked) || hcloud.IsError(err, hcloud.ErrorCodeConflict)
+}
+
+func (l *LoadBalancerOps) getUseLabelSelector(svc *corev1.Service) (bool, error) {
+	targetType, err := annotation.LBTargetType.LBTargetTypeFromService(svc)
+	if errors.Is(err, annotation.ErrNotSet) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return targetType == hcloud.LoadBalancerTargetTypeLabelSelector, nil
 }
 
 func (l *LoadBalancerOps) getUsePrivateIP(svc *corev1.Service) (bool, error) {
 	usePrivateIP, err := annotation.LBUsePrivateIP.BoolFromService(svc)
 	if err != nil {
 		if errors.Is(err, annotation.ErrNotSet) {
-			return l.Defaults.UsePrivateIP, nil
+			return l.defaults(svc).UsePrivateIP, nil
 		}
 		return false, err
 	}
 	return usePrivateIP, nil
 }
 
+// isRobotNode reports whether node has the provider ID of a Robot server.
+func isRobotNode(node *corev1.Node) bool {
+	_, isCloudServer, err := providerid.ToServerID(node.Spec.ProviderID)
+	return err == nil && !isCloudServer
+}
+
 func maxTargetsReached(currentNumber int, lbType string) bool {
 	var maxNumber int
 	switch lbType {
@@ -861,18 +1388,23 @@ func (l *LoadBalancerOps) ReconcileHCLBServices(
 
 	var changed bool
 
-	if err := l.reconcileManagedCertificate(ctx, svc); err != nil {
-		return false, fmt.Errorf("%s: %v", op, err)
+	managedCert, err := l.reconcileManagedCertificate(ctx, lb, svc)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	secretCerts, err := l.reconcileSecretCertificates(ctx, lb, svc)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
 	}
 
-	hclbListenPorts := make(map[int]bool, len(lb.Services))
+	hclbServices := make(map[int]hcloud.LoadBalancerService, len(lb.Services))
 	for _, hclbService := range lb.Services {
-		hclbListenPorts[hclbService.ListenPort] = true
+		hclbServices[hclbService.ListenPort] = hclbService
 	}
 
 	// Add all ports exposed by the K8S Load Balancer service to the HC load
-	// balancer. Remove the ports from the set of HC Load Balancer listen
-	// ports.
+	// balancer, or update them if their options differ. Remove the ports from
+	// the set of HC Load Balancer services.
 	for _, port := range svc.Spec.Ports {
 		var (
 			addOpts hcloud.LoadBalancerAddServiceOpts
@@ -883,17 +1415,34 @@ func (l *LoadBalancerOps) ReconcileHCLBServices(
 		)
 
 		portNo := int(port.Port)
-		portExists := hclbListenPorts[portNo]
-		delete(hclbListenPorts, portNo)
+		hclbService, portExists := hclbServices[portNo]
+		delete(hclbServices, portNo)
 
-		b := &hclbServiceOptsBuilder{Port: port, Service: svc, CertOps: l.CertOps}
-		if portExists {
-			klog.InfoS("update service", "op", op, "port", portNo, "loadBalancerID", lb.ID)
+		b := &hclbServiceOptsBuilder{
+			Port:      port,
+			Service:   annotation.ServiceForPort(svc, port.Port),
+			CertOps:   l.CertOps,
+			PodClient: l.PodClient,
 
-			updOpts, err = b.buildUpdateServiceOpts()
+			ManagedCertificateID:      managedCert.ID,
+			ManagedCertificatePlanned: l.plan != nil,
+			SecretCertificates:        secretCerts.IDs,
+			SecretCertificatesPlanned: l.plan != nil,
+		}
+		if portExists {
+			var differs bool
+			updOpts, differs, err = b.buildUpdateServiceOptsDiff(hclbService)
 			if err != nil {
 				return changed, fmt.Errorf("%s: %w", op, err)
 			}
+			if !differs {
+				continue
+			}
+			klog.InfoS("update service", "op", op, "port", portNo, "loadBalancerID", lb.ID)
+			if l.planned(LoadBalancerChange{Kind: ChangeUpdateService, Subject: fmt.Sprintf("port %d", portNo)}) {
+				changed = true
+				continue
+			}
 			action, _, err = l.LBClient.UpdateService(ctx, lb, b.listenPort, updOpts)
 			if err != nil {
 				return changed, fmt.Errorf("%s: %w", op, err)
@@ -905,6 +1454,10 @@ func (l *LoadBalancerOps) ReconcileHCLBServices(
 			if err != nil {
 				return changed, fmt.Errorf("%s: %w", op, err)
 			}
+			if l.planned(LoadBalancerChange{Kind: ChangeAddService, Subject: fmt.Sprintf("port %d", portNo)}) {
+				changed = true
+				continue
+			}
 			action, _, err = l.LBClient.AddService(ctx, lb, addOpts)
 			if err != nil {
 				return changed, fmt.Errorf("%s: %w", op, err)
@@ -914,11 +1467,20 @@ func (l *LoadBalancerOps) ReconcileHCLBServices(
 		if err = WatchAction(ctx, l.ActionClient, action); err != nil {
 			return changed, fmt.Errorf("%s: %w", op, err)
 		}
+		if portExists {
+			l.Recorder.Eventf(svc, corev1.EventTypeNormal, EventServiceUpdated, "Updated service on port %d", portNo)
+		} else {
+			l.Recorder.Eventf(svc, corev1.EventTypeNormal, EventServiceAdded, "Added service on port %d", portNo)
+		}
 		changed = true
 	}
 
 	// Remove any left-over services from the hc Load Balancer.
-	for p := range hclbListenPorts {
+	for p := range hclbServices {
+		if l.planned(LoadBalancerChange{Kind: ChangeDeleteService, Subject: fmt.Sprintf("port %d", p)}) {
+			changed = true
+			continue
+		}
 		klog.InfoS("remove service", "op", op, "port", p, "loadBalancerID", lb.ID)
 		a, _, err := l.LBClient.DeleteService(ctx, lb, p)
 		if err != nil {
@@ -928,50 +1490,367 @@ func (l *LoadBalancerOps) ReconcileHCLBServices(
 		if err != nil {
 			return changed, fmt.Errorf("%s: port: %d: %w", op, p, err)
 		}
+		l.Recorder.Eventf(svc, corev1.EventTypeNormal, EventServiceRemoved, "Removed service on port %d", p)
 		changed = true
 	}
 
+	// The services no longer use the certificates of previous domains or
+	// previous versions of the Secrets.
+	for _, cert := range slices.Concat(managedCert.Obsolete, secretCerts.Obsolete) {
+		if l.planned(LoadBalancerChange{Kind: ChangeDeleteCertificate, Subject: cert.Name}) {
+			continue
+		}
+		klog.InfoS("delete certificate", "op", op, "certificateID", cert.ID)
+		if err := l.CertOps.DeleteCertificate(ctx, cert); err != nil {
+			return changed, fmt.Errorf("%s: %w", op, err)
+		}
+		l.Recorder.Eventf(svc, corev1.EventTypeNormal, EventCertificateDeleted, "Deleted certificate %s", cert.Name)
+	}
+
+	if l.plan != nil {
+		return changed, nil
+	}
+	if managedCert.Failed != nil {
+		return changed, fmt.Errorf("%s: %s: %w", op, managedCert.Failed.Name, ErrCertificateFailed)
+	}
+	if managedCert.Pending != nil {
+		return changed, fmt.Errorf("%s: %s: %w", op, managedCert.Pending.Name, ErrCertificatePending)
+	}
 	return changed, nil
 }
 
-func (l *LoadBalancerOps) reconcileManagedCertificate(ctx context.Context, svc *corev1.Service) error {
+// managedCertificate is the managed certificate of a Service.
+type managedCertificate struct {
+	// ID of the certificate for the domains of the Service. ID is 0 if the
+	// certificate is not created yet, because the changes are only planned.
+	ID int64
+	// Obsolete are the certificates of previous domains of the Service, or
+	// all certificates if the Service no longer uses a managed certificate.
+	Obsolete []*hcloud.Certificate
+	// Pending is the certificate for the domains of the Service, if it is not
+	// issued yet and ID is the certificate of the previous domains instead.
+	Pending *hcloud.Certificate
+	// Failed is the certificate for the domains of the Service, if its
+	// issuance failed.
+	Failed *hcloud.Certificate
+}
+
+// reconcileManagedCertificate creates the managed certificate for the domains
+// of svc, if no certificate for these domains exists.
+//
+// Changing the domains of a managed certificate is not possible. A new
+// certificate is created instead, and the certificate of the previous domains
+// becomes obsolete. Until the new certificate is issued, the Load Balancer
+// keeps using the certificate of the previous domains, so that HTTPS keeps
+// working in the meantime.
+func (l *LoadBalancerOps) reconcileManagedCertificate(
+	ctx context.Context, lb *hcloud.LoadBalancer, svc *corev1.Service,
+) (managedCertificate, error) {
 	const op = "hcops/LoadBalancerOps.reconcileManagedCertificate"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
-	if typ, ok := annotation.LBSvcHTTPCertificateType.StringFromService(svc); !ok || typ != string(hcloud.CertificateTypeManaged) {
-		return nil
+	var result managedCertificate
+
+	managed := usesManagedCertificate(svc)
+	usesCerts := slices.ContainsFunc(lb.Services, func(s hcloud.LoadBalancerService) bool {
+		return len(s.HTTP.Certificates) > 0
+	})
+	if !managed && !usesCerts {
+		return result, nil
 	}
+
+	certs, err := l.CertOps.GetCertificatesByLabel(ctx, fmt.Sprintf("%s=%s", LabelServiceUID, svc.UID))
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", op, err)
+	}
+	if !managed {
+		result.Obsolete = certs
+		return result, nil
+	}
+
 	name, ok := annotation.LBSvcHTTPManagedCertificateName.StringFromService(svc)
 	if !ok || name == "" {
 		name = fmt.Sprintf("ccm-managed-certificate-%s", svc.UID)
 	}
 	domains, err := annotation.LBSvcHTTPManagedCertificateDomains.StringsFromService(svc)
 	if errors.Is(err, annotation.ErrNotSet) {
-		return fmt.Errorf("%s: no domains for managed certificate", op)
+		return result, fmt.Errorf("%s: no domains for managed certificate", op)
+	}
+	sortedDomains := slices.Sorted(slices.Values(domains))
+
+	var cert *hcloud.Certificate
+	for _, c := range certs {
+		if cert == nil && slices.Equal(slices.Sorted(slices.Values(c.DomainNames)), sortedDomains) {
+			cert = c
+			continue
+		}
+		result.Obsolete = append(result.Obsolete, c)
+	}
+
+	if cert == nil {
+		// The certificate of the previous domains keeps its name until it is
+		// deleted.
+		if slices.ContainsFunc(certs, func(c *hcloud.Certificate) bool { return c.Name == name }) {
+			h := sha256.Sum256([]byte(strings.Join(sortedDomains, ",")))
+			name = fmt.Sprintf("%s-%s", name, hex.EncodeToString(h[:])[:8])
+		}
+		if l.planned(LoadBalancerChange{Kind: ChangeCreateManagedCertificate, Subject: name, To: strings.Join(domains, ",")}) {
+			return result, nil
+		}
+
+		labels := map[string]string{
+			LabelServiceUID: string(svc.UID),
+		}
+		// It's ok to ignore the error here. We are only interested if the
+		// annotation is set and parseable as a truthy boolean. Anything else
+		// tells us we do not want to use ACME staging.
+		if ok, _ := annotation.LBSvcHTTPManagedCertificateUseACMEStaging.BoolFromService(svc); ok {
+			labels["HC-Use-Staging-CA"] = "true"
+		}
+		cert, err = l.CertOps.CreateManagedCertificate(ctx, name, domains, labels)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", op, err)
+		}
+		l.Recorder.Eventf(svc, corev1.EventTypeNormal, EventManagedCertificateCreated,
+			"Created managed certificate %s for %s", name, strings.Join(domains, ", "))
 	}
-	labels := map[string]string{
-		LabelServiceUID: string(svc.UID),
+	result.ID = cert.ID
+
+	failed := cert.Status != nil && cert.Status.Issuance == hcloud.CertificateStatusTypeFailed
+	if failed {
+		result.Failed = cert
 	}
-	// It's ok to ignore the error here. We are only interested if the
-	// annotation is set and parseable as a truthy boolean. Anything else tells
-	// us we do not want to use ACME staging.
-	if ok, _ := annotation.LBSvcHTTPManagedCertificateUseACMEStaging.BoolFromService(svc); ok {
-		labels["HC-Use-Staging-CA"] = "true"
+	if cert.Status == nil || cert.Status.Issuance != hcloud.CertificateStatusTypeCompleted {
+		if i := slices.IndexFunc(result.Obsolete, func(c *hcloud.Certificate) bool {
+			return lbUsesCertificate(lb, c.ID)
+		}); i >= 0 {
+			result.ID = result.Obsolete[i].ID
+			result.Obsolete = slices.Delete(result.Obsolete, i, i+1)
+			if !failed {
+				result.Pending = cert
+			}
+		}
 	}
-	err = l.CertOps.CreateManagedCertificate(ctx, name, domains, labels)
-	if errors.Is(err, ErrAlreadyExists) {
+
+	l.recordManagedCertificateStatus(svc, cert)
+	return result, nil
+}
+
+// lbUsesCertificate reports whether a service of lb uses the certificate with
+// the ID certID.
+func lbUsesCertificate(lb *hcloud.LoadBalancer, certID int64) bool {
+	return slices.ContainsFunc(lb.Services, func(s hcloud.LoadBalancerService) bool {
+		return slices.ContainsFunc(s.HTTP.Certificates, func(c *hcloud.Certificate) bool {
+			return c.ID == certID
+		})
+	})
+}
+
+// recordManagedCertificateStatus records an event on svc if the issuance of
+// the managed certificate is pending, or if its issuance or renewal failed.
+func (l *LoadBalancerOps) recordManagedCertificateStatus(svc *corev1.Service, cert *hcloud.Certificate) {
+	st := cert.Status
+	if st == nil {
+		return
+	}
+	switch {
+	case st.IsFailed():
+		msg := "unknown error"
+		if st.Error != nil {
+			msg = st.Error.Message
+		}
+		l.Recorder.Eventf(svc, corev1.EventTypeWarning, EventManagedCertificateFailed,
+			"Managed certificate %s failed: %s", cert.Name, msg)
+	case st.Issuance == hcloud.CertificateStatusTypePending:
+		l.Recorder.Eventf(svc, corev1.EventTypeNormal, EventManagedCertificatePending,
+			"Issuance of managed certificate %s is pending", cert.Name)
+	}
+}
+
+// DeleteCertificates deletes the certificates the Load Balancer of the
+// Service with uid no longer uses: its managed certificates and the
+// certificates uploaded from its Secrets.
+func (l *LoadBalancerOps) DeleteCertificates(ctx context.Context, uid types.UID) error {
+	const op = "hcops/LoadBalancerOps.DeleteCertificates"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	for _, label := range []string{LabelServiceUID, labelSecretServiceUID} {
+		certs, err := l.CertOps.GetCertificatesByLabel(ctx, fmt.Sprintf("%s=%s", label, uid))
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		for _, cert := range certs {
+			klog.InfoS("delete certificate", "op", op, "certificateID", cert.ID)
+			if err := l.CertOps.DeleteCertificate(ctx, cert); err != nil {
+				return fmt.Errorf("%s: %w", op, err)
+			}
+		}
+	}
+	return nil
+}
+
+// secretCertificates are the certificates uploaded from the TLS Secrets of a
+// Service.
+type secretCertificates struct {
+	// IDs maps the names of the Secrets to the IDs of their certificates.
+	IDs map[string]int64
+	// Obsolete are the certificates of Secrets which changed or are no longer
+	// used by the Service.
+	Obsolete []*hcloud.Certificate
+}
+
+// CertificateSecretNames returns the names of the TLS Secrets referenced by
+// svc or one of its ports.
+func CertificateSecretNames(svc *corev1.Service) ([]string, error) {
+	var names []string
+	add := func(svc *corev1.Service) error {
+		ss, err := annotation.LBSvcHTTPCertificateSecrets.StringsFromService(svc)
+		if errors.Is(err, annotation.ErrNotSet) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		for _, name := range ss {
+			if name = strings.TrimSpace(name); name != "" && !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
 		return nil
 	}
+	if err := add(svc); err != nil {
+		return nil, err
+	}
+	for _, port := range svc.Spec.Ports {
+		if err := add(annotation.ServiceForPort(svc, port.Port)); err != nil {
+			return nil, err
+		}
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+// reconcileSecretCertificates uploads the certificates of the TLS Secrets of
+// svc which were not uploaded yet.
+//
+// The certificates are looked up only if svc references Secrets, or if lb
+// uses certificates which might have been uploaded from Secrets.
+func (l *LoadBalancerOps) reconcileSecretCertificates(
+	ctx context.Context, lb *hcloud.LoadBalancer, svc *corev1.Service,
+) (secretCertificates, error) {
+	const op = "hcops/LoadBalancerOps.reconcileSecretCertificates"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	var result secretCertificates
+
+	names, err := CertificateSecretNames(svc)
 	if err != nil {
-		return fmt.Errorf("%s: %v", op, err)
+		return result, fmt.Errorf("%s: %w", op, err)
 	}
-	return nil
+	usesCerts := slices.ContainsFunc(lb.Services, func(s hcloud.LoadBalancerService) bool {
+		return len(s.HTTP.Certificates) > 0
+	})
+	if len(names) == 0 && !usesCerts {
+		return result, nil
+	}
+
+	certs, err := l.CertOps.GetCertificatesByLabel(ctx, fmt.Sprintf("%s=%s", labelSecretServiceUID, svc.UID))
+	if err != nil {
+		return result, fmt.Errorf("%s: %w", op, err)
+	}
+	byHash := make(map[string]*hcloud.Certificate, len(certs))
+	for _, cert := range certs {
+		byHash[cert.Labels[labelSecretHash]] = cert
+	}
+	used := make(map[int64]bool, len(names))
+
+	result.IDs = make(map[string]int64, len(names))
+	for _, name := range names {
+		if l.SecretLister == nil {
+			return result, fmt.Errorf("%s: secret %s: secrets not available", op, name)
+		}
+		secret, err := l.SecretLister.Secrets(svc.Namespace).Get(name)
+		if err != nil {
+			return result, fmt.Errorf("%s: %w", op, err)
+		}
+		crt, key := secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey]
+		if secret.Type != corev1.SecretTypeTLS || len(crt) == 0 || len(key) == 0 {
+			return result, fmt.Errorf("%s: secret %s: no %s secret", op, name, corev1.SecretTypeTLS)
+		}
+		hash := secretCertificateHash(crt, key)
+
+		cert, ok := byHash[hash]
+		if !ok {
+			certName := fmt.Sprintf("ccm-secret-%s-%s", svc.UID, hash[:12])
+			if l.planned(LoadBalancerChange{Kind: ChangeUploadCertificate, Subject: certName, To: name}) {
+				continue
+			}
+			cert, err = l.CertOps.UploadCertificate(ctx, certName, string(crt), string(key), map[string]string{
+				labelSecretServiceUID: string(svc.UID),
+				labelSecretHash:       hash,
+			})
+			if err != nil {
+				return result, fmt.Errorf("%s: secret %s: %w", op, name, err)
+			}
+			l.Recorder.Eventf(svc, corev1.EventTypeNormal, EventCertificateUploaded,
+				"Uploaded certificate %s of Secret %s", certName, name)
+			byHash[hash] = cert
+		}
+		result.IDs[name] = cert.ID
+		used[cert.ID] = true
+	}
+
+	for _, cert := range certs {
+		if !used[cert.ID] {
+			result.Obsolete = append(result.Obsolete, cert)
+		}
+	}
+	return result, nil
+}
+
+// secretCertificateHash returns the value of the labelSecretHash label of the
+// certificate uploaded from crt and key.
+func secretCertificateHash(crt, key []byte) string {
+	h := sha256.New()
+	h.Write(crt)
+	h.Write(key)
+	return hex.EncodeToString(h.Sum(nil))[:32]
+}
+
+// usesManagedCertificate reports whether svc or one of its ports uses the
+// managed certificate of svc.
+func usesManagedCertificate(svc *corev1.Service) bool {
+	isManaged := func(svc *corev1.Service) bool {
+		typ, ok := annotation.LBSvcHTTPCertificateType.StringFromService(svc)
+		return ok && typ == string(hcloud.CertificateTypeManaged)
+	}
+	if isManaged(svc) {
+		return true
+	}
+	for _, port := range svc.Spec.Ports {
+		if isManaged(annotation.ServiceForPort(svc, port.Port)) {
+			return true
+		}
+	}
+	return false
 }
 
 type hclbServiceOptsBuilder struct {
-	Port    corev1.ServicePort
-	Service *corev1.Service
-	CertOps *CertificateOps
+	Port      corev1.ServicePort
+	Service   *corev1.Service
+	CertOps   *CertificateOps
+	PodClient corev1client.PodsGetter
+	// ManagedCertificateID is the ID of the managed certificate of Service.
+	ManagedCertificateID int64
+	// ManagedCertificatePlanned is set if the managed certificate of Service
+	// is not created yet, because the changes are only planned.
+	ManagedCertificatePlanned bool
+	// SecretCertificates maps the names of the TLS Secrets of the Service to
+	// the IDs of their uploaded certificates.
+	SecretCertificates map[string]int64
+	// SecretCertificatesPlanned is set if missing certificates of Secrets
+	// are not uploaded yet, because the changes are only planned.
+	SecretCertificatesPlanned bool
 
 	listenPort      int
 	destinationPort int
@@ -1028,6 +1907,7 @@ func (b *hclbServiceOptsBuilder) extract() {
 	b.do(func() error {
 		p, err := annotation.LBSvcProtocol.LBSvcProtocolFromService(b.Service)
 		if errors.Is(err, annotation.ErrNotSet) {
+			b.protocol = b.appProtocol()
 			return nil
 		}
 		if err != nil {
@@ -1089,15 +1969,37 @@ func (b *hclbServiceOptsBuilder) extract() {
 			return nil
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+		if b.ManagedCertificateID == 0 && !b.ManagedCertificatePlanned {
+			return fmt.Errorf("%s: managed certificate not created", op)
+		}
+		if b.ManagedCertificateID != 0 {
+			b.httpOpts.Certificates = []*hcloud.Certificate{{ID: b.ManagedCertificateID}}
+		}
+		b.addHTTP = true
+		return nil
+	})
 
-		svcUID := b.Service.UID
-		cert, err := b.CertOps.GetCertificateByLabel(ctx, fmt.Sprintf("%s=%s", LabelServiceUID, svcUID))
+	b.do(func() error {
+		names, err := annotation.LBSvcHTTPCertificateSecrets.StringsFromService(b.Service)
+		if errors.Is(err, annotation.ErrNotSet) {
+			return nil
+		}
 		if err != nil {
-			return err
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		for _, name := range names {
+			name = strings.TrimSpace(name)
+			id, ok := b.SecretCertificates[name]
+			if !ok && b.SecretCertificatesPlanned {
+				continue
+			}
+			if !ok {
+				return fmt.Errorf("%s: no certificate uploaded for secret %s", op, name)
+			}
+			if !slices.ContainsFunc(b.httpOpts.Certificates, func(c *hcloud.Certificate) bool { return c.ID == id }) {
+				b.httpOpts.Certificates = append(b.httpOpts.Certificates, &hcloud.Certificate{ID: id})
+			}
 		}
-		b.httpOpts.Certificates = []*hcloud.Certificate{{ID: cert.ID}}
 		b.addHTTP = true
 		return nil
 	})
@@ -1155,8 +2057,24 @@ func (b *hclbServiceOptsBuilder) extractHealthCheck() {
 	const op = "hcops/hclbServiceOptsBuilder.extractHealthCheck"
 	metrics.OperationCalled.WithLabelValues(op).Inc()
 
+	var defaults *healthCheckDefaults
+	b.do(func() error {
+		var err error
+
+		defaults, err = b.healthCheckDefaults()
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	})
+
 	b.do(func() error {
 		p, err := annotation.LBSvcHealthCheckProtocol.LBSvcProtocolFromService(b.Service)
+		if errors.Is(err, annotation.ErrNotSet) && defaults != nil {
+			b.healthCheckOpts.Protocol = defaults.Protocol
+			b.addHealthCheck = true
+			return nil
+		}
 		if errors.Is(err, annotation.ErrNotSet) {
 			// Set the service protocol but do not set the addHealthCheck flag.
 			// This way the health check is configured using the service
@@ -1175,6 +2093,11 @@ func (b *hclbServiceOptsBuilder) extractHealthCheck() {
 
 	b.do(func() error {
 		hcPort, err := annotation.LBSvcHealthCheckPort.IntFromService(b.Service)
+		if errors.Is(err, annotation.ErrNotSet) && defaults != nil && defaults.Port != 0 {
+			b.healthCheckOpts.Port = hcloud.Ptr(defaults.Port)
+			b.addHealthCheck = true
+			return nil
+		}
 		if errors.Is(err, annotation.ErrNotSet) {
 			return nil
 		}
@@ -1235,6 +2158,8 @@ func (b *hclbServiceOptsBuilder) extractHealthCheck() {
 
 	if v, ok := annotation.LBSvcHealthCheckHTTPPath.StringFromService(b.Service); ok {
 		b.healthCheckOpts.httpOpts.Path = &v
+	} else if defaults != nil && defaults.Path != "" {
+		b.healthCheckOpts.httpOpts.Path = &defaults.Path
 	}
 
 	b.do(func() error {
@@ -1262,6 +2187,178 @@ func (b *hclbServiceOptsBuilder) extractHealthCheck() {
 	})
 }
 
+// appProtocol returns the protocol of the Load Balancer service matching the
+// appProtocol of the port. It returns TCP for unknown protocols.
+//
+// The Load Balancer terminates TLS for HTTPS services, which requires
+// certificates. Without certificates, https ports stay TCP services passing
+// TLS through to the targets. Cleartext HTTP/2 (kubernetes.io/h2c) is not
+// supported by HTTP services and is passed through as well.
+func (b *hclbServiceOptsBuilder) appProtocol() hcloud.LoadBalancerServiceProtocol {
+	if b.Port.AppProtocol == nil {
+		return hcloud.LoadBalancerServiceProtocolTCP
+	}
+	switch strings.ToLower(*b.Port.AppProtocol) {
+	case "http", "kubernetes.io/ws":
+		return hcloud.LoadBalancerServiceProtocolHTTP
+	case "https", "kubernetes.io/wss":
+		_, certs := b.Service.Annotations[string(annotation.LBSvcHTTPCertificates)]
+		_, secrets := b.Service.Annotations[string(annotation.LBSvcHTTPCertificateSecrets)]
+		certtyp, _ := annotation.LBSvcHTTPCertificateType.StringFromService(b.Service)
+		if certs || secrets || certtyp == string(hcloud.CertificateTypeManaged) {
+			return hcloud.LoadBalancerServiceProtocolHTTPS
+		}
+	}
+	return hcloud.LoadBalancerServiceProtocolTCP
+}
+
+// healthCheckDefaults are the health check options derived from the Service
+// spec. Annotations override them.
+type healthCheckDefaults struct {
+	Protocol hcloud.LoadBalancerServiceProtocol
+	// Port and Path are optional.
+	Port int
+	Path string
+}
+
+// healthCheckDefaults returns the health check options derived from the
+// Service spec, or nil if the health check is derived from the annotations
+// only. In decreasing precedence, they are derived from:
+//
+//   - the readiness probe of the pods, if LBSvcHealthCheckFromReadinessProbe
+//     is set,
+//   - the health check node port of Services with externalTrafficPolicy
+//     Local,
+//   - the appProtocol of the port, if LBSvcHealthCheckHTTPPath or
+//     LBSvcHealthCheckFromReadinessProbe is set.
+func (b *hclbServiceOptsBuilder) healthCheckDefaults() (*healthCheckDefaults, error) {
+	fromProbe, err := annotation.LBSvcHealthCheckFromReadinessProbe.BoolFromService(b.Service)
+	if err != nil && !errors.Is(err, annotation.ErrNotSet) {
+		return nil, err
+	}
+	if fromProbe {
+		defaults, err := b.readinessProbeHealthCheck()
+		if err != nil {
+			return nil, err
+		}
+		if defaults != nil {
+			return defaults, nil
+		}
+		klog.InfoS("no readiness probe found for health check", "service", b.Service.Name, "port", b.Port.Port)
+	}
+
+	// For Services with externalTrafficPolicy Local, kube-proxy reports on the
+	// health check node port whether a node runs ready endpoints. Nodes
+	// without them are taken out of rotation by the Load Balancer.
+	if b.Service.Spec.ExternalTrafficPolicy == corev1.ServiceExternalTrafficPolicyLocal &&
+		b.Service.Spec.HealthCheckNodePort != 0 {
+		return &healthCheckDefaults{
+			Protocol: hcloud.LoadBalancerServiceProtocolHTTP,
+			Port:     int(b.Service.Spec.HealthCheckNodePort),
+			Path:     "/healthz",
+		}, nil
+	}
+
+	// Many backends do not answer the default path "/" with a success, so
+	// the health check only follows the appProtocol if a path is set, or if
+	// the readiness probe was asked for. Otherwise the TCP health check of
+	// existing Load Balancers would be replaced on upgrade.
+	_, hasPath := annotation.LBSvcHealthCheckHTTPPath.StringFromService(b.Service)
+	if b.Port.AppProtocol != nil && (hasPath || fromProbe) {
+		switch strings.ToLower(*b.Port.AppProtocol) {
+		case "http":
+			return &healthCheckDefaults{Protocol: hcloud.LoadBalancerServiceProtocolHTTP}, nil
+		case "https":
+			return &healthCheckDefaults{Protocol: hcloud.LoadBalancerServiceProtocolHTTPS}, nil
+		}
+	}
+	return nil, nil
+}
+
+// readinessProbeHealthCheck returns the health check options derived from the
+// HTTP readiness probe of the container serving the port of the Service. The
+// probe is reached through the node port of the Service port targeting the
+// probe port. It returns nil if there is no such probe or Service port.
+func (b *hclbServiceOptsBuilder) readinessProbeHealthCheck() (*healthCheckDefaults, error) {
+	if b.PodClient == nil || len(b.Service.Spec.Selector) == 0 {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	opts := metav1.ListOptions{LabelSelector: labels.SelectorFromSet(b.Service.Spec.Selector).String()}
+	podList, err := b.PodClient.Pods(b.Service.Namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("list pods: %w", err)
+	}
+	pods := podList.Items
+	// Prefer the probes of the oldest pods, so that the health check does not
+	// change with every rollout of pods.
+	slices.SortFunc(pods, func(a, b corev1.Pod) int {
+		if c := a.CreationTimestamp.Compare(b.CreationTimestamp.Time); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	for _, pod := range pods {
+		for _, c := range pod.Spec.Containers {
+			if !containerServes(c, b.Port.TargetPort) {
+				continue
+			}
+			if c.ReadinessProbe == nil || c.ReadinessProbe.HTTPGet == nil {
+				continue
+			}
+			probe := c.ReadinessProbe.HTTPGet
+			for _, port := range b.Service.Spec.Ports {
+				if port.NodePort == 0 || !sameContainerPort(c, port.TargetPort, probe.Port) {
+					continue
+				}
+				protocol := hcloud.LoadBalancerServiceProtocolHTTP
+				if probe.Scheme == corev1.URISchemeHTTPS {
+					protocol = hcloud.LoadBalancerServiceProtocolHTTPS
+				}
+				return &healthCheckDefaults{
+					Protocol: protocol,
+					Port:     int(port.NodePort),
+					Path:     probe.Path,
+				}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// containerServes reports whether the targetPort of a Service port is a port
+// of c.
+func containerServes(c corev1.Container, targetPort intstr.IntOrString) bool {
+	for _, p := range c.Ports {
+		if sameContainerPort(c, targetPort, intstr.FromInt32(p.ContainerPort)) {
+			return true
+		}
+	}
+	return false
+}
+
+// sameContainerPort reports whether the ports a and b, given by number or by
+// name, refer to the same port of c.
+func sameContainerPort(c corev1.Container, a, b intstr.IntOrString) bool {
+	an, aok := containerPortNumber(c, a)
+	bn, bok := containerPortNumber(c, b)
+	return aok && bok && an == bn
+}
+
+func containerPortNumber(c corev1.Container, port intstr.IntOrString) (int32, bool) {
+	if port.Type == intstr.Int {
+		return port.IntVal, port.IntVal != 0
+	}
+	for _, p := range c.Ports {
+		if p.Name == port.StrVal {
+			return p.ContainerPort, true
+		}
+	}
+	return 0, false
+}
+
 func (b *hclbServiceOptsBuilder) initialize() error {
 	b.once.Do(b.extract)
 	return b.err
@@ -1391,3 +2488,110 @@ func lbAttached(lb *hcloud.LoadBalancer, nwID int64) bool {
 	}
 	return false
 }
+
+// buildUpdateServiceOptsDiff returns the options to update current to the
+// desired service. Only the options which differ from current are set. The
+// returned bool reports whether current needs to be updated at all.
+func (b *hclbServiceOptsBuilder) buildUpdateServiceOptsDiff(
+	current hcloud.LoadBalancerService,
+) (hcloud.LoadBalancerUpdateServiceOpts, bool, error) {
+	const op = "hcops/hclbServiceOptsBuilder.buildUpdateServiceOptsDiff"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	opts, err := b.buildUpdateServiceOpts()
+	if err != nil {
+		return opts, false, fmt.Errorf("%s: %w", op, err)
+	}
+	if opts.Protocol != current.Protocol {
+		// Options which are unchanged for the current protocol may be
+		// required for the new one. Send all of them.
+		return opts, true, nil
+	}
+
+	diff := hcloud.LoadBalancerUpdateServiceOpts{
+		DestinationPort: changedValue(opts.DestinationPort, current.DestinationPort),
+		Proxyprotocol:   changedValue(opts.Proxyprotocol, current.Proxyprotocol),
+	}
+	if opts.HTTP != nil {
+		diff.HTTP = diffUpdateServiceOptsHTTP(*opts.HTTP, current.HTTP)
+	}
+	if opts.HealthCheck != nil {
+		diff.HealthCheck = diffUpdateServiceOptsHealthCheck(*opts.HealthCheck, current.HealthCheck)
+	}
+	return diff, diff != hcloud.LoadBalancerUpdateServiceOpts{}, nil
+}
+
+func diffUpdateServiceOptsHTTP(
+	opts hcloud.LoadBalancerUpdateServiceOptsHTTP, current hcloud.LoadBalancerServiceHTTP,
+) *hcloud.LoadBalancerUpdateServiceOptsHTTP {
+	diff := hcloud.LoadBalancerUpdateServiceOptsHTTP{
+		CookieName:     changedValue(opts.CookieName, current.CookieName),
+		CookieLifetime: changedValue(opts.CookieLifetime, current.CookieLifetime),
+		RedirectHTTP:   changedValue(opts.RedirectHTTP, current.RedirectHTTP),
+		StickySessions: changedValue(opts.StickySessions, current.StickySessions),
+	}
+	if opts.Certificates != nil && !sameCertificates(opts.Certificates, current.Certificates) {
+		diff.Certificates = opts.Certificates
+	}
+	if diff.CookieName == nil && diff.CookieLifetime == nil && diff.RedirectHTTP == nil &&
+		diff.StickySessions == nil && diff.Certificates == nil {
+		return nil
+	}
+	return &diff
+}
+
+func diffUpdateServiceOptsHealthCheck(
+	opts hcloud.LoadBalancerUpdateServiceOptsHealthCheck, current hcloud.LoadBalancerServiceHealthCheck,
+) *hcloud.LoadBalancerUpdateServiceOptsHealthCheck {
+	if opts.Protocol != current.Protocol || (opts.HTTP != nil && current.HTTP == nil) {
+		return &opts
+	}
+
+	diff := hcloud.LoadBalancerUpdateServiceOptsHealthCheck{
+		Port:     changedValue(opts.Port, current.Port),
+		Interval: changedValue(opts.Interval, current.Interval),
+		Timeout:  changedValue(opts.Timeout, current.Timeout),
+		Retries:  changedValue(opts.Retries, current.Retries),
+	}
+	if opts.HTTP != nil {
+		http := hcloud.LoadBalancerUpdateServiceOptsHealthCheckHTTP{
+			Domain:   changedValue(opts.HTTP.Domain, current.HTTP.Domain),
+			Path:     changedValue(opts.HTTP.Path, current.HTTP.Path),
+			Response: changedValue(opts.HTTP.Response, current.HTTP.Response),
+			TLS:      changedValue(opts.HTTP.TLS, current.HTTP.TLS),
+		}
+		if opts.HTTP.StatusCodes != nil && !slices.Equal(opts.HTTP.StatusCodes, current.HTTP.StatusCodes) {
+			http.StatusCodes = opts.HTTP.StatusCodes
+		}
+		if http.Domain != nil || http.Path != nil || http.Response != nil || http.TLS != nil || http.StatusCodes != nil {
+			diff.HTTP = &http
+		}
+	}
+	if diff.Port == nil && diff.Interval == nil && diff.Timeout == nil && diff.Retries == nil && diff.HTTP == nil {
+		return nil
+	}
+	return &diff
+}
+
+// changedValue returns desired if it is set and differs from current, and nil
+// otherwise.
+func changedValue[T comparable](desired *T, current T) *T {
+	if desired == nil || *desired == current {
+		return nil
+	}
+	return desired
+}
+
+// sameCertificates reports whether a and b contain the same certificates,
+// regardless of their order.
+func sameCertificates(a, b []*hcloud.Certificate) bool {
+	ids := func(certs []*hcloud.Certificate) []int64 {
+		ids := make([]int64, len(certs))
+		for i, c := range certs {
+			ids[i] = c.ID
+		}
+		slices.Sort(ids)
+		return ids
+	}
+	return slices.Equal(ids(a), ids(b))
+}
@@ -1,7 +1,7 @@
 package hcops
 
 import (
-	"fmt"
+	"context"
 	"testing"
 	"time"
 
@@ -13,6 +13,8 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestHCLBServiceOptsBuilder(t *testing.T) {
@@ -20,11 +22,15 @@ func TestHCLBServiceOptsBuilder(t *testing.T) {
 		name               string
 		servicePort        corev1.ServicePort
 		serviceUID         string
+		serviceSpec        corev1.ServiceSpec
 		serviceAnnotations map[annotation.Name]interface{}
+		pods               []*corev1.Pod
 		expectedAddOpts    hcloud.LoadBalancerAddServiceOpts
 		expectedUpdateOpts hcloud.LoadBalancerUpdateServiceOpts
 		mock               func(t *testing.T, tt *testCase)
 
+		managedCertificateID int64
+
 		// Set during test setup
 		certClient *mocks.CertificateClient
 	}
@@ -160,23 +166,15 @@ func TestHCLBServiceOptsBuilder(t *testing.T) {
 			},
 		},
 		{
-			name:        "add managed certificate by service uid label",
-			servicePort: corev1.ServicePort{Port: 83, NodePort: 8083},
-			serviceUID:  "some-service-uid",
+			name:                 "add managed certificate",
+			servicePort:          corev1.ServicePort{Port: 83, NodePort: 8083},
+			serviceUID:           "some-service-uid",
+			managedCertificateID: 1,
 			serviceAnnotations: map[annotation.Name]interface{}{
 				annotation.LBSvcProtocol:                      hcloud.LoadBalancerServiceProtocolHTTPS,
 				annotation.LBSvcHTTPCertificateType:           "managed",
 				annotation.LBSvcHTTPManagedCertificateDomains: []string{"*.example.com", "example.com"},
 			},
-			mock: func(_ *testing.T, tt *testCase) {
-				tt.certClient.
-					On("AllWithOpts", mock.Anything, hcloud.CertificateListOpts{
-						ListOpts: hcloud.ListOpts{
-							LabelSelector: fmt.Sprintf("%s=%s", LabelServiceUID, "some-service-uid"),
-						},
-					}).
-					Return([]*hcloud.Certificate{{ID: 1}}, nil, nil)
-			},
 			expectedAddOpts: hcloud.LoadBalancerAddServiceOpts{
 				ListenPort:      hcloud.Ptr(83),
 				DestinationPort: hcloud.Ptr(8083),
@@ -359,6 +357,292 @@ func TestHCLBServiceOptsBuilder(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:        "health check uses health check node port of local services",
+			servicePort: corev1.ServicePort{Port: 85, NodePort: 8085},
+			serviceSpec: corev1.ServiceSpec{
+				ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyLocal,
+				HealthCheckNodePort:   32000,
+			},
+			expectedAddOpts: hcloud.LoadBalancerAddServiceOpts{
+				ListenPort:      hcloud.Ptr(85),
+				DestinationPort: hcloud.Ptr(8085),
+				Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+				HealthCheck: &hcloud.LoadBalancerAddServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolHTTP,
+					Port:     hcloud.Ptr(32000),
+					HTTP: &hcloud.LoadBalancerAddServiceOptsHealthCheckHTTP{
+						Path: hcloud.Ptr("/healthz"),
+					},
+				},
+			},
+			expectedUpdateOpts: hcloud.LoadBalancerUpdateServiceOpts{
+				DestinationPort: hcloud.Ptr(8085),
+				Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+				HealthCheck: &hcloud.LoadBalancerUpdateServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolHTTP,
+					Port:     hcloud.Ptr(32000),
+					HTTP: &hcloud.LoadBalancerUpdateServiceOptsHealthCheckHTTP{
+						Path: hcloud.Ptr("/healthz"),
+					},
+				},
+			},
+		},
+		{
+			name:        "health check annotations override defaults of local services",
+			servicePort: corev1.ServicePort{Port: 86, NodePort: 8086},
+			serviceSpec: corev1.ServiceSpec{
+				ExternalTrafficPolicy: corev1.ServiceExternalTrafficPolicyLocal,
+				HealthCheckNodePort:   32000,
+			},
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcHealthCheckProtocol: hcloud.LoadBalancerServiceProtocolTCP,
+				annotation.LBSvcHealthCheckPort:     8086,
+			},
+			expectedAddOpts: hcloud.LoadBalancerAddServiceOpts{
+				ListenPort:      hcloud.Ptr(86),
+				DestinationPort: hcloud.Ptr(8086),
+				Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+				HealthCheck: &hcloud.LoadBalancerAddServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+					Port:     hcloud.Ptr(8086),
+				},
+			},
+			expectedUpdateOpts: hcloud.LoadBalancerUpdateServiceOpts{
+				DestinationPort: hcloud.Ptr(8086),
+				Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+				HealthCheck: &hcloud.LoadBalancerUpdateServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+					Port:     hcloud.Ptr(8086),
+				},
+			},
+		},
+		{
+			name:        "health check keeps TCP for app protocol of port",
+			servicePort: corev1.ServicePort{Port: 87, NodePort: 8087, AppProtocol: hcloud.Ptr("https")},
+			expectedAddOpts: hcloud.LoadBalancerAddServiceOpts{
+				ListenPort:      hcloud.Ptr(87),
+				DestinationPort: hcloud.Ptr(8087),
+				Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+				HealthCheck: &hcloud.LoadBalancerAddServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+					Port:     hcloud.Ptr(8087),
+				},
+			},
+			expectedUpdateOpts: hcloud.LoadBalancerUpdateServiceOpts{
+				DestinationPort: hcloud.Ptr(8087),
+				Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+				HealthCheck: &hcloud.LoadBalancerUpdateServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+					Port:     hcloud.Ptr(8087),
+				},
+			},
+		},
+		{
+			name:        "health check uses app protocol of port with path",
+			servicePort: corev1.ServicePort{Port: 87, NodePort: 8087, AppProtocol: hcloud.Ptr("https")},
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcHealthCheckHTTPPath: "/healthz",
+			},
+			expectedAddOpts: hcloud.LoadBalancerAddServiceOpts{
+				ListenPort:      hcloud.Ptr(87),
+				DestinationPort: hcloud.Ptr(8087),
+				Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+				HealthCheck: &hcloud.LoadBalancerAddServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolHTTPS,
+					Port:     hcloud.Ptr(8087),
+					HTTP:     &hcloud.LoadBalancerAddServiceOptsHealthCheckHTTP{Path: hcloud.Ptr("/healthz")},
+				},
+			},
+			expectedUpdateOpts: hcloud.LoadBalancerUpdateServiceOpts{
+				DestinationPort: hcloud.Ptr(8087),
+				Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+				HealthCheck: &hcloud.LoadBalancerUpdateServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolHTTPS,
+					Port:     hcloud.Ptr(8087),
+					HTTP:     &hcloud.LoadBalancerUpdateServiceOptsHealthCheckHTTP{Path: hcloud.Ptr("/healthz")},
+				},
+			},
+		},
+		{
+			name:        "protocol uses app protocol of port",
+			servicePort: corev1.ServicePort{Port: 80, NodePort: 8080, AppProtocol: hcloud.Ptr("http")},
+			expectedAddOpts: hcloud.LoadBalancerAddServiceOpts{
+				ListenPort:      hcloud.Ptr(80),
+				DestinationPort: hcloud.Ptr(8080),
+				Protocol:        hcloud.LoadBalancerServiceProtocolHTTP,
+				HealthCheck: &hcloud.LoadBalancerAddServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+					Port:     hcloud.Ptr(8080),
+				},
+			},
+			expectedUpdateOpts: hcloud.LoadBalancerUpdateServiceOpts{
+				DestinationPort: hcloud.Ptr(8080),
+				Protocol:        hcloud.LoadBalancerServiceProtocolHTTP,
+				HealthCheck: &hcloud.LoadBalancerUpdateServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+					Port:     hcloud.Ptr(8080),
+				},
+			},
+		},
+		{
+			name:        "protocol uses https app protocol with certificates",
+			servicePort: corev1.ServicePort{Port: 443, NodePort: 8443, AppProtocol: hcloud.Ptr("https")},
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcHTTPCertificates: []*hcloud.Certificate{{ID: 1}},
+			},
+			expectedAddOpts: hcloud.LoadBalancerAddServiceOpts{
+				ListenPort:      hcloud.Ptr(443),
+				DestinationPort: hcloud.Ptr(8443),
+				Protocol:        hcloud.LoadBalancerServiceProtocolHTTPS,
+				HTTP: &hcloud.LoadBalancerAddServiceOptsHTTP{
+					Certificates: []*hcloud.Certificate{{ID: 1}},
+				},
+				HealthCheck: &hcloud.LoadBalancerAddServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+					Port:     hcloud.Ptr(8443),
+				},
+			},
+			expectedUpdateOpts: hcloud.LoadBalancerUpdateServiceOpts{
+				DestinationPort: hcloud.Ptr(8443),
+				Protocol:        hcloud.LoadBalancerServiceProtocolHTTPS,
+				HTTP: &hcloud.LoadBalancerUpdateServiceOptsHTTP{
+					Certificates: []*hcloud.Certificate{{ID: 1}},
+				},
+				HealthCheck: &hcloud.LoadBalancerUpdateServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+					Port:     hcloud.Ptr(8443),
+				},
+			},
+		},
+		{
+			name:        "protocol annotation overrides app protocol of port",
+			servicePort: corev1.ServicePort{Port: 80, NodePort: 8080, AppProtocol: hcloud.Ptr("kubernetes.io/h2c")},
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcProtocol: hcloud.LoadBalancerServiceProtocolHTTP,
+			},
+			expectedAddOpts: hcloud.LoadBalancerAddServiceOpts{
+				ListenPort:      hcloud.Ptr(80),
+				DestinationPort: hcloud.Ptr(8080),
+				Protocol:        hcloud.LoadBalancerServiceProtocolHTTP,
+				HealthCheck: &hcloud.LoadBalancerAddServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+					Port:     hcloud.Ptr(8080),
+				},
+			},
+			expectedUpdateOpts: hcloud.LoadBalancerUpdateServiceOpts{
+				DestinationPort: hcloud.Ptr(8080),
+				Protocol:        hcloud.LoadBalancerServiceProtocolHTTP,
+				HealthCheck: &hcloud.LoadBalancerUpdateServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+					Port:     hcloud.Ptr(8080),
+				},
+			},
+		},
+		{
+			name:        "health check uses readiness probe of pods",
+			servicePort: corev1.ServicePort{Port: 88, NodePort: 8088, TargetPort: intstr.FromString("web")},
+			serviceSpec: corev1.ServiceSpec{
+				Selector: map[string]string{"app": "web"},
+				Ports: []corev1.ServicePort{
+					{Port: 88, NodePort: 8088, TargetPort: intstr.FromString("web")},
+					{Port: 9090, NodePort: 9099, TargetPort: intstr.FromInt32(9090)},
+				},
+			},
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcHealthCheckFromReadinessProbe: true,
+			},
+			pods: []*corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "default", Labels: map[string]string{"app": "other"}},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: map[string]string{"app": "web"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Name: "sidecar",
+								Ports: []corev1.ContainerPort{
+									{Name: "metrics", ContainerPort: 9091},
+								},
+							},
+							{
+								Name: "web",
+								Ports: []corev1.ContainerPort{
+									{Name: "web", ContainerPort: 8000},
+									{Name: "health", ContainerPort: 9090},
+								},
+								ReadinessProbe: &corev1.Probe{
+									ProbeHandler: corev1.ProbeHandler{
+										HTTPGet: &corev1.HTTPGetAction{Path: "/ready", Port: intstr.FromString("health")},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+			expectedAddOpts: hcloud.LoadBalancerAddServiceOpts{
+				ListenPort:      hcloud.Ptr(88),
+				DestinationPort: hcloud.Ptr(8088),
+				Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+				HealthCheck: &hcloud.LoadBalancerAddServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolHTTP,
+					Port:     hcloud.Ptr(9099),
+					HTTP: &hcloud.LoadBalancerAddServiceOptsHealthCheckHTTP{
+						Path: hcloud.Ptr("/ready"),
+					},
+				},
+			},
+			expectedUpdateOpts: hcloud.LoadBalancerUpdateServiceOpts{
+				DestinationPort: hcloud.Ptr(8088),
+				Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+				HealthCheck: &hcloud.LoadBalancerUpdateServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolHTTP,
+					Port:     hcloud.Ptr(9099),
+					HTTP: &hcloud.LoadBalancerUpdateServiceOptsHealthCheckHTTP{
+						Path: hcloud.Ptr("/ready"),
+					},
+				},
+			},
+		},
+		{
+			name:        "health check falls back without readiness probe",
+			servicePort: corev1.ServicePort{Port: 89, NodePort: 8089, TargetPort: intstr.FromInt32(8000)},
+			serviceSpec: corev1.ServiceSpec{
+				Selector: map[string]string{"app": "web"},
+			},
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcHealthCheckFromReadinessProbe: true,
+			},
+			pods: []*corev1.Pod{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: map[string]string{"app": "web"}},
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{Name: "web", Ports: []corev1.ContainerPort{{ContainerPort: 8000}}},
+						},
+					},
+				},
+			},
+			expectedAddOpts: hcloud.LoadBalancerAddServiceOpts{
+				ListenPort:      hcloud.Ptr(89),
+				DestinationPort: hcloud.Ptr(8089),
+				Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+				HealthCheck: &hcloud.LoadBalancerAddServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+					Port:     hcloud.Ptr(8089),
+				},
+			},
+			expectedUpdateOpts: hcloud.LoadBalancerUpdateServiceOpts{
+				DestinationPort: hcloud.Ptr(8089),
+				Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+				HealthCheck: &hcloud.LoadBalancerUpdateServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+					Port:     hcloud.Ptr(8089),
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -370,14 +654,25 @@ func TestHCLBServiceOptsBuilder(t *testing.T) {
 				tt.mock(t, &tt)
 			}
 
+			client := fake.NewSimpleClientset()
+			for _, pod := range tt.pods {
+				_, err := client.CoreV1().Pods(pod.Namespace).Create(context.Background(), pod, metav1.CreateOptions{})
+				assert.NoError(t, err)
+			}
+
 			builder := &hclbServiceOptsBuilder{
 				Port: tt.servicePort,
 				Service: &corev1.Service{
 					ObjectMeta: metav1.ObjectMeta{
-						UID: types.UID(tt.serviceUID),
+						Namespace: "default",
+						UID:       types.UID(tt.serviceUID),
 					},
+					Spec: tt.serviceSpec,
 				},
-				CertOps: &CertificateOps{CertClient: tt.certClient},
+				CertOps:   &CertificateOps{CertClient: tt.certClient},
+				PodClient: client.CoreV1(),
+
+				ManagedCertificateID: tt.managedCertificateID,
 			}
 			for k, v := range tt.serviceAnnotations {
 				if err := k.AnnotateService(builder.Service, v); err != nil {
@@ -394,3 +689,121 @@ func TestHCLBServiceOptsBuilder(t *testing.T) {
 		})
 	}
 }
+
+func TestHCLBServiceOptsBuilder_BuildUpdateServiceOptsDiff(t *testing.T) {
+	cert1, cert2 := &hcloud.Certificate{ID: 1}, &hcloud.Certificate{ID: 2}
+	httpService := hcloud.LoadBalancerService{
+		Protocol:        hcloud.LoadBalancerServiceProtocolHTTPS,
+		ListenPort:      443,
+		DestinationPort: 8080,
+		HTTP: hcloud.LoadBalancerServiceHTTP{
+			CookieName:   "HCLBSTICKY",
+			Certificates: []*hcloud.Certificate{cert2, cert1},
+		},
+		HealthCheck: hcloud.LoadBalancerServiceHealthCheck{
+			Protocol: hcloud.LoadBalancerServiceProtocolHTTP,
+			Port:     8080,
+			HTTP: &hcloud.LoadBalancerServiceHealthCheckHTTP{
+				Path:        "/healthz",
+				StatusCodes: []string{"2??"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name               string
+		appProtocol        *string
+		serviceAnnotations map[annotation.Name]interface{}
+		current            hcloud.LoadBalancerService
+		expectedOpts       hcloud.LoadBalancerUpdateServiceOpts
+		expectedDiffers    bool
+	}{
+		{
+			name: "unchanged",
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcProtocol:                   string(hcloud.LoadBalancerServiceProtocolHTTPS),
+				annotation.LBSvcHTTPCookieName:             "HCLBSTICKY",
+				annotation.LBSvcHTTPCertificates:           []*hcloud.Certificate{cert1, cert2},
+				annotation.LBSvcHealthCheckProtocol:        string(hcloud.LoadBalancerServiceProtocolHTTP),
+				annotation.LBSvcHealthCheckHTTPPath:        "/healthz",
+				annotation.LBSvcHealthCheckHTTPStatusCodes: []string{"2??"},
+			},
+			current: httpService,
+		},
+		{
+			name: "changed HTTP and health check options",
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcProtocol:                   string(hcloud.LoadBalancerServiceProtocolHTTPS),
+				annotation.LBSvcHTTPCookieName:             "OTHERCOOKIE",
+				annotation.LBSvcHTTPCertificates:           []*hcloud.Certificate{cert1},
+				annotation.LBSvcHealthCheckProtocol:        string(hcloud.LoadBalancerServiceProtocolHTTP),
+				annotation.LBSvcHealthCheckHTTPPath:        "/ready",
+				annotation.LBSvcHealthCheckHTTPStatusCodes: []string{"2??"},
+			},
+			current: httpService,
+			expectedOpts: hcloud.LoadBalancerUpdateServiceOpts{
+				HTTP: &hcloud.LoadBalancerUpdateServiceOptsHTTP{
+					CookieName:   hcloud.Ptr("OTHERCOOKIE"),
+					Certificates: []*hcloud.Certificate{cert1},
+				},
+				HealthCheck: &hcloud.LoadBalancerUpdateServiceOptsHealthCheck{
+					HTTP: &hcloud.LoadBalancerUpdateServiceOptsHealthCheckHTTP{
+						Path: hcloud.Ptr("/ready"),
+					},
+				},
+			},
+			expectedDiffers: true,
+		},
+		{
+			name:    "changed protocol",
+			current: httpService,
+			expectedOpts: hcloud.LoadBalancerUpdateServiceOpts{
+				DestinationPort: hcloud.Ptr(8080),
+				Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+				HealthCheck: &hcloud.LoadBalancerUpdateServiceOptsHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+					Port:     hcloud.Ptr(8080),
+				},
+			},
+			expectedDiffers: true,
+		},
+		{
+			name:        "keep TCP health check of port with app protocol",
+			appProtocol: hcloud.Ptr("https"),
+			current: hcloud.LoadBalancerService{
+				Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+				ListenPort:      443,
+				DestinationPort: 8080,
+				HealthCheck: hcloud.LoadBalancerServiceHealthCheck{
+					Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+					Port:     8080,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			certClient := &mocks.CertificateClient{}
+			certClient.Test(t)
+			certClient.On("GetByID", mock.Anything, cert1.ID).Return(cert1, nil, nil)
+			certClient.On("GetByID", mock.Anything, cert2.ID).Return(cert2, nil, nil)
+
+			builder := &hclbServiceOptsBuilder{
+				Port:    corev1.ServicePort{Port: 443, NodePort: 8080, AppProtocol: tt.appProtocol},
+				Service: &corev1.Service{},
+				CertOps: &CertificateOps{CertClient: certClient},
+			}
+			for k, v := range tt.serviceAnnotations {
+				if err := k.AnnotateService(builder.Service, v); err != nil {
+					t.Error(err)
+				}
+			}
+
+			opts, differs, err := builder.buildUpdateServiceOptsDiff(tt.current)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedDiffers, differs)
+			assert.Equal(t, tt.expectedOpts, opts)
+		})
+	}
+}
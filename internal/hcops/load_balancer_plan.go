@@ -0,0 +1,155 @@
+package hcops
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/metrics"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// LoadBalancerChangeKind is the kind of a change to a Load Balancer.
+type LoadBalancerChangeKind string
+
+// Kinds of changes to a Load Balancer.
+const (
+	ChangeCreateLoadBalancer       LoadBalancerChangeKind = "CreateLoadBalancer"
+	ChangeNameAndLabels            LoadBalancerChangeKind = "ChangeNameAndLabels"
+	ChangeReverseDNS               LoadBalancerChangeKind = "ChangeReverseDNS"
+	ChangeAlgorithm                LoadBalancerChangeKind = "ChangeAlgorithm"
+	ChangeType                     LoadBalancerChangeKind = "ChangeType"
+	ChangeAttachToNetwork          LoadBalancerChangeKind = "AttachToNetwork"
+	ChangeDetachFromNetwork        LoadBalancerChangeKind = "DetachFromNetwork"
+	ChangeEnablePublicInterface    LoadBalancerChangeKind = "EnablePublicInterface"
+	ChangeDisablePublicInterface   LoadBalancerChangeKind = "DisablePublicInterface"
+	ChangeCreateManagedCertificate LoadBalancerChangeKind = "CreateManagedCertificate"
+	ChangeUploadCertificate        LoadBalancerChangeKind = "UploadCertificate"
+	ChangeDeleteCertificate        LoadBalancerChangeKind = "DeleteCertificate"
+	ChangeAddService               LoadBalancerChangeKind = "AddService"
+	ChangeUpdateService            LoadBalancerChangeKind = "UpdateService"
+	ChangeDeleteService            LoadBalancerChangeKind = "DeleteService"
+	ChangeAddTarget                LoadBalancerChangeKind = "AddTarget"
+	ChangeRemoveTarget             LoadBalancerChangeKind = "RemoveTarget"
+	ChangeLabelServer              LoadBalancerChangeKind = "LabelServer"
+	ChangeUnlabelServer            LoadBalancerChangeKind = "UnlabelServer"
+)
+
+// LoadBalancerChange is a single change to a Load Balancer.
+type LoadBalancerChange struct {
+	Kind LoadBalancerChangeKind
+	// Subject is the changed part of the Load Balancer, for example the
+	// listen port of a service or the target. Optional.
+	Subject string
+	// From and To are the current and the desired value. Optional.
+	From string
+	To   string
+}
+
+func (c LoadBalancerChange) String() string {
+	s := string(c.Kind)
+	if c.Subject != "" {
+		s += " " + c.Subject
+	}
+	if c.From != "" || c.To != "" {
+		s += fmt.Sprintf(" (%q -> %q)", c.From, c.To)
+	}
+	return s
+}
+
+// LoadBalancerPlan lists the changes required to reconcile a Load Balancer
+// with its Service, in the order they are applied.
+type LoadBalancerPlan struct {
+	Changes []LoadBalancerChange
+}
+
+// Empty reports whether no changes are required.
+func (p *LoadBalancerPlan) Empty() bool {
+	return len(p.Changes) == 0
+}
+
+func (p *LoadBalancerPlan) String() string {
+	changes := make([]string, len(p.Changes))
+	for i, c := range p.Changes {
+		changes[i] = c.String()
+	}
+	return strings.Join(changes, "; ")
+}
+
+func (p *LoadBalancerPlan) has(kind LoadBalancerChangeKind) bool {
+	for _, c := range p.Changes {
+		if c.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// planned adds change to the plan of l and reports whether l only plans
+// changes. The change must not be applied if planned returns true.
+func (l *LoadBalancerOps) planned(change LoadBalancerChange) bool {
+	if l.plan == nil {
+		return false
+	}
+	l.plan.Changes = append(l.plan.Changes, change)
+	return true
+}
+
+// PlanHCLB returns the changes ReconcileHCLB, ReconcileHCLBServices and
+// ReconcileHCLBTargets would make to lb, without making them. Only read
+// requests are sent to the Hetzner Cloud API.
+//
+// If lb is nil, the plan contains the creation of the Load Balancer lbName
+// followed by the changes to the new Load Balancer. If nodes is nil, the
+// targets are kept and no target changes are planned.
+func (l *LoadBalancerOps) PlanHCLB(
+	ctx context.Context, lbName string, lb *hcloud.LoadBalancer, svc *corev1.Service, nodes []*corev1.Node,
+) (*LoadBalancerPlan, error) {
+	const op = "hcops/LoadBalancerOps.PlanHCLB"
+	metrics.OperationCalled.WithLabelValues(op).Inc()
+
+	planner := *l
+	planner.plan = &LoadBalancerPlan{}
+
+	if lb == nil {
+		opts, err := l.createOpts(ctx, lbName, svc)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		planner.planned(LoadBalancerChange{Kind: ChangeCreateLoadBalancer, Subject: lbName})
+		lb = plannedLoadBalancer(opts)
+	}
+
+	if _, err := planner.ReconcileHCLB(ctx, lb, svc); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if _, err := planner.ReconcileHCLBServices(ctx, lb, svc); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if nodes == nil {
+		return planner.plan, nil
+	}
+	if _, err := planner.ReconcileHCLBTargets(ctx, lb, svc, nodes); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return planner.plan, nil
+}
+
+// plannedLoadBalancer returns the Load Balancer which would be created with
+// opts.
+func plannedLoadBalancer(opts hcloud.LoadBalancerCreateOpts) *hcloud.LoadBalancer {
+	lb := &hcloud.LoadBalancer{
+		Name:             opts.Name,
+		Labels:           opts.Labels,
+		LoadBalancerType: opts.LoadBalancerType,
+	}
+	if opts.Algorithm != nil {
+		lb.Algorithm = *opts.Algorithm
+	}
+	if opts.Network != nil {
+		lb.PrivateNet = []hcloud.LoadBalancerPrivateNet{{Network: opts.Network}}
+	}
+	lb.PublicNet.Enabled = opts.PublicInterface == nil || *opts.PublicInterface
+	return lb
+}
@@ -0,0 +1,125 @@
+package hcops_test
+
+import (
+	"testing"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/annotation"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLoadBalancerOps_PlanHCLB(t *testing.T) {
+	node1 := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+		Spec:       corev1.NodeSpec{ProviderID: "hcloud://1"},
+	}
+
+	tests := []LBReconcilementTestCase{
+		{
+			name:       "plan changes of existing Load Balancer",
+			serviceUID: "uid",
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBType: "lb21",
+			},
+			servicePorts: []corev1.ServicePort{{Port: 443, NodePort: 8443}},
+			k8sNodes:     []*corev1.Node{node1},
+			initialLB: &hcloud.LoadBalancer{
+				ID:               1,
+				Labels:           map[string]string{hcops.LabelServiceUID: "uid"},
+				LoadBalancerType: &hcloud.LoadBalancerType{Name: "lb11"},
+				Services:         []hcloud.LoadBalancerService{{ListenPort: 80, DestinationPort: 8080}},
+				Targets: []hcloud.LoadBalancerTarget{
+					{
+						Type:   hcloud.LoadBalancerTargetTypeServer,
+						Server: &hcloud.LoadBalancerTargetServer{Server: &hcloud.Server{ID: 5}},
+					},
+				},
+			},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				tt.fx.MockListRobotServers(nil, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				plan, err := tt.fx.LBOps.PlanHCLB(tt.fx.Ctx, "my-lb", tt.initialLB, tt.service, tt.k8sNodes)
+				require.NoError(t, err)
+				assert.Equal(t, []hcops.LoadBalancerChange{
+					{Kind: hcops.ChangeType, From: "lb11", To: "lb21"},
+					{Kind: hcops.ChangeAddService, Subject: "port 443"},
+					{Kind: hcops.ChangeDeleteService, Subject: "port 80"},
+					{Kind: hcops.ChangeRemoveTarget, Subject: "server 5"},
+					{Kind: hcops.ChangeAddTarget, Subject: "node node1"},
+				}, plan.Changes)
+				assert.Equal(t,
+					`ChangeType ("lb11" -> "lb21"); AddService port 443; DeleteService port 80; RemoveTarget server 5; AddTarget node node1`,
+					plan.String())
+				// Nothing was changed, so no events are recorded.
+				assert.Empty(t, tt.fx.Recorder.Events)
+			},
+		},
+		{
+			name:       "plan creation of Load Balancer",
+			serviceUID: "uid",
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBLocation: "fsn1",
+			},
+			servicePorts: []corev1.ServicePort{{Port: 80, NodePort: 8080}},
+			k8sNodes:     []*corev1.Node{node1},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				tt.fx.MockListRobotServers(nil, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				plan, err := tt.fx.LBOps.PlanHCLB(tt.fx.Ctx, "my-lb", nil, tt.service, tt.k8sNodes)
+				require.NoError(t, err)
+				assert.Equal(t, []hcops.LoadBalancerChange{
+					{Kind: hcops.ChangeCreateLoadBalancer, Subject: "my-lb"},
+					{Kind: hcops.ChangeAddService, Subject: "port 80"},
+					{Kind: hcops.ChangeAddTarget, Subject: "node node1"},
+				}, plan.Changes)
+			},
+		},
+		{
+			name:       "fail to plan creation of invalid Load Balancer",
+			serviceUID: "uid",
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				_, err := tt.fx.LBOps.PlanHCLB(tt.fx.Ctx, "my-lb", nil, tt.service, tt.k8sNodes)
+				assert.EqualError(t, err,
+					"hcops/LoadBalancerOps.PlanHCLB: neither load-balancer.hetzner.cloud/location nor load-balancer.hetzner.cloud/network-zone set")
+			},
+		},
+		{
+			name:       "plan managed certificate",
+			serviceUID: "uid",
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcHTTPCertificateType:           hcloud.CertificateTypeManaged,
+				annotation.LBSvcHTTPManagedCertificateDomains: []string{"example.com"},
+			},
+			servicePorts: []corev1.ServicePort{{Port: 443, NodePort: 8443}},
+			initialLB: &hcloud.LoadBalancer{
+				ID:     1,
+				Labels: map[string]string{hcops.LabelServiceUID: "uid"},
+			},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				tt.fx.CertClient.
+					On("AllWithOpts", mock.Anything, mock.Anything).
+					Return([]*hcloud.Certificate{}, nil, nil)
+				tt.fx.MockListRobotServers(nil, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				plan, err := tt.fx.LBOps.PlanHCLB(tt.fx.Ctx, "my-lb", tt.initialLB, tt.service, tt.k8sNodes)
+				require.NoError(t, err)
+				assert.Equal(t, []hcops.LoadBalancerChange{
+					{Kind: hcops.ChangeCreateManagedCertificate, Subject: "ccm-managed-certificate-uid", To: "example.com"},
+					{Kind: hcops.ChangeAddService, Subject: "port 443"},
+				}, plan.Changes)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, tt.run)
+	}
+}
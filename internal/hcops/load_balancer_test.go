@@ -6,17 +6,23 @@ import (
 	"fmt"
 	"math/rand"
 	"net"
+	"slices"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/annotation"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/hcops"
 	"github.com/syself/hrobot-go/models"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
 )
 
 var errTestLbClient = errors.New("lb client failed")
@@ -241,6 +247,8 @@ func TestLoadBalancerOps_Create(t *testing.T) {
 	type testCase struct {
 		name               string
 		defaults           hcops.LoadBalancerDefaults
+		classDefaults      map[string]hcops.LoadBalancerDefaults
+		class              string
 		serviceAnnotations map[annotation.Name]interface{}
 		createOpts         hcloud.LoadBalancerCreateOpts
 		mock               func(t *testing.T, tt *testCase, fx *hcops.LoadBalancerOpsFixture)
@@ -501,6 +509,45 @@ func TestLoadBalancerOps_Create(t *testing.T) {
 			},
 			lb: &hcloud.LoadBalancer{ID: 6},
 		},
+		{
+			name: "create with defaults of load balancer class",
+			defaults: hcops.LoadBalancerDefaults{
+				Location: "hel1",
+			},
+			classDefaults: map[string]hcops.LoadBalancerDefaults{
+				"hetzner.cloud/internal": {NetworkZone: "eu-central", DisablePublicNetwork: true},
+			},
+			class: "hetzner.cloud/internal",
+			createOpts: hcloud.LoadBalancerCreateOpts{
+				Name:             "internal-lb",
+				LoadBalancerType: &hcloud.LoadBalancerType{Name: "lb11"},
+				NetworkZone:      hcloud.NetworkZoneEUCentral,
+				PublicInterface:  hcloud.Ptr(false),
+				Labels: map[string]string{
+					hcops.LabelServiceUID: "internal-lb-uid",
+				},
+			},
+			lb: &hcloud.LoadBalancer{ID: 7},
+		},
+		{
+			name: "create with defaults of unknown load balancer class",
+			defaults: hcops.LoadBalancerDefaults{
+				Location: "hel1",
+			},
+			classDefaults: map[string]hcops.LoadBalancerDefaults{
+				"hetzner.cloud/internal": {NetworkZone: "eu-central", DisablePublicNetwork: true},
+			},
+			class: "hetzner.cloud/public",
+			createOpts: hcloud.LoadBalancerCreateOpts{
+				Name:             "public-lb",
+				LoadBalancerType: &hcloud.LoadBalancerType{Name: "lb11"},
+				Location:         &hcloud.Location{Name: "hel1"},
+				Labels: map[string]string{
+					hcops.LabelServiceUID: "public-lb-uid",
+				},
+			},
+			lb: &hcloud.LoadBalancer{ID: 8},
+		},
 	}
 
 	for _, tt := range tests {
@@ -508,6 +555,7 @@ func TestLoadBalancerOps_Create(t *testing.T) {
 			fx := hcops.NewLoadBalancerOpsFixture(t)
 
 			fx.LBOps.Defaults = tt.defaults
+			fx.LBOps.ClassDefaults = tt.classDefaults
 
 			if tt.mock == nil {
 				tt.mock = func(_ *testing.T, tt *testCase, fx *hcops.LoadBalancerOpsFixture) {
@@ -526,6 +574,9 @@ func TestLoadBalancerOps_Create(t *testing.T) {
 					UID: types.UID(tt.createOpts.Labels[hcops.LabelServiceUID]),
 				},
 			}
+			if tt.class != "" {
+				service.Spec.LoadBalancerClass = &tt.class
+			}
 			for k, v := range tt.serviceAnnotations {
 				if err := k.AnnotateService(service, v); err != nil {
 					t.Error(err)
@@ -546,13 +597,31 @@ func TestLoadBalancerOps_Create(t *testing.T) {
 
 func TestLoadBalancerOps_Delete(t *testing.T) {
 	tests := []struct {
-		name      string
-		clientErr error
-		err       error
+		name           string
+		targets        []hcloud.LoadBalancerTarget
+		labeledServers []*hcloud.Server
+		clientErr      error
+		err            error
 	}{
 		{
 			name: "deletion successful",
 		},
+		{
+			name: "remove labels of label selector target",
+			targets: []hcloud.LoadBalancerTarget{
+				{
+					Type:          hcloud.LoadBalancerTargetTypeLabelSelector,
+					LabelSelector: &hcloud.LoadBalancerTargetLabelSelector{Selector: "hcloud-ccm/lb-target-uid"},
+				},
+				{
+					Type:          hcloud.LoadBalancerTargetTypeLabelSelector,
+					LabelSelector: &hcloud.LoadBalancerTargetLabelSelector{Selector: "role=worker"},
+				},
+			},
+			labeledServers: []*hcloud.Server{
+				{ID: 1, Labels: map[string]string{"hcloud-ccm/lb-target-uid": "", "role": "worker"}},
+			},
+		},
 		{
 			name:      "load balancer not found",
 			clientErr: hcloud.Error{Code: hcloud.ErrorCodeNotFound},
@@ -568,11 +637,73 @@ func TestLoadBalancerOps_Delete(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			fx := hcops.NewLoadBalancerOpsFixture(t)
 			ctx := context.Background()
-			lb := &hcloud.LoadBalancer{ID: 1}
-
+			lb := &hcloud.LoadBalancer{ID: 1, Targets: tt.targets}
+
+			if tt.labeledServers != nil {
+				opts := hcloud.ServerListOpts{ListOpts: hcloud.ListOpts{LabelSelector: "hcloud-ccm/lb-target-uid"}}
+				fx.ServerClient.On("AllWithOpts", ctx, opts).Return(tt.labeledServers, nil)
+				for _, server := range tt.labeledServers {
+					updateOpts := hcloud.ServerUpdateOpts{Labels: map[string]string{"role": "worker"}}
+					fx.ServerClient.On("Update", ctx, server, updateOpts).Return(server, nil, nil)
+				}
+			}
 			fx.LBClient.On("Delete", ctx, lb).Return(nil, tt.clientErr)
 
 			err := fx.LBOps.Delete(ctx, lb)
+			fx.AssertExpectations()
+			if tt.err == nil {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.err.Error())
+		})
+	}
+}
+
+func TestLoadBalancerOps_DeleteCertificates(t *testing.T) {
+	tests := []struct {
+		name         string
+		managedCerts []*hcloud.Certificate
+		secretCerts  []*hcloud.Certificate
+		clientErr    error
+		err          error
+	}{
+		{
+			name: "no certificates",
+		},
+		{
+			name:         "delete managed certificates and certificates of secrets",
+			managedCerts: []*hcloud.Certificate{{ID: 1, Name: "ccm-managed-certificate-uid"}},
+			secretCerts: []*hcloud.Certificate{
+				{ID: 2, Name: "ccm-secret-uid-1"},
+				{ID: 3, Name: "ccm-secret-uid-2"},
+			},
+		},
+		{
+			name:      "listing certificates fails",
+			clientErr: errors.New("test error"),
+			err: errors.New(
+				"hcops/LoadBalancerOps.DeleteCertificates: hcops/CertificateOps.GetCertificatesByLabel: test error"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fx := hcops.NewLoadBalancerOpsFixture(t)
+			ctx := context.Background()
+
+			opts := hcloud.CertificateListOpts{ListOpts: hcloud.ListOpts{LabelSelector: "hcloud-ccm/service-uid=uid"}}
+			fx.CertClient.On("AllWithOpts", ctx, opts).Return(tt.managedCerts, tt.clientErr)
+			if tt.clientErr == nil {
+				opts = hcloud.CertificateListOpts{ListOpts: hcloud.ListOpts{LabelSelector: "hcloud-ccm/secret-service-uid=uid"}}
+				fx.CertClient.On("AllWithOpts", ctx, opts).Return(tt.secretCerts, nil)
+			}
+			for _, cert := range slices.Concat(tt.managedCerts, tt.secretCerts) {
+				fx.CertClient.On("Delete", ctx, cert).Return(nil, nil)
+			}
+
+			err := fx.LBOps.DeleteCertificates(ctx, "uid")
+			fx.AssertExpectations()
 			if tt.err == nil {
 				assert.NoError(t, err)
 				return
@@ -582,6 +713,25 @@ func TestLoadBalancerOps_Delete(t *testing.T) {
 	}
 }
 
+func newTestSecretLister(t *testing.T, secrets ...*corev1.Secret) corelisters.SecretLister {
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for _, secret := range secrets {
+		require.NoError(t, indexer.Add(secret))
+	}
+	return corelisters.NewSecretLister(indexer)
+}
+
+func newTestTLSSecret(name, crt string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Type:       corev1.SecretTypeTLS,
+		Data: map[string][]byte{
+			corev1.TLSCertKey:       []byte(crt),
+			corev1.TLSPrivateKeyKey: []byte("key"),
+		},
+	}
+}
+
 type LBReconcilementTestCase struct {
 	name               string
 	defaults           hcops.LoadBalancerDefaults
@@ -712,6 +862,7 @@ func TestLoadBalancerOps_ReconcileHCLB(t *testing.T) {
 				changed, err := tt.fx.LBOps.ReconcileHCLB(tt.fx.Ctx, tt.initialLB, tt.service)
 				assert.NoError(t, err)
 				assert.True(t, changed)
+				assert.Equal(t, "Normal TypeChanged Changed type from lb11 to lb21", <-tt.fx.Recorder.Events)
 			},
 		},
 		{
@@ -965,6 +1116,79 @@ func TestLoadBalancerOps_ReconcileHCLB(t *testing.T) {
 				assert.False(t, changed)
 			},
 		},
+		{
+			name: "move Load Balancer to network of annotation",
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBNetwork: "tenant-b",
+			},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 5,
+				PrivateNet: []hcloud.LoadBalancerPrivateNet{
+					{
+						Network: &hcloud.Network{ID: 15, Name: "some-network"},
+					},
+				},
+			},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				tt.fx.LBOps.NetworkID = tt.initialLB.PrivateNet[0].Network.ID
+
+				nw := &hcloud.Network{ID: 20, Name: "tenant-b"}
+				tt.fx.NetworkClient.On("Get", tt.fx.Ctx, "tenant-b").Return(nw, nil, nil)
+				tt.fx.NetworkClient.On("GetByID", tt.fx.Ctx, nw.ID).Return(nw, nil, nil)
+
+				detachOpts := hcloud.LoadBalancerDetachFromNetworkOpts{Network: tt.initialLB.PrivateNet[0].Network}
+				detachAction := &hcloud.Action{ID: rand.Int63()}
+				tt.fx.LBClient.On("DetachFromNetwork", tt.fx.Ctx, tt.initialLB, detachOpts).Return(detachAction, nil, nil)
+				tt.fx.MockWatchProgress(detachAction, nil)
+
+				attachOpts := hcloud.LoadBalancerAttachToNetworkOpts{Network: nw}
+				attachAction := &hcloud.Action{ID: rand.Int63()}
+				tt.fx.LBClient.On("AttachToNetwork", tt.fx.Ctx, tt.initialLB, attachOpts).Return(attachAction, nil, nil)
+				tt.fx.MockWatchProgress(attachAction, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				changed, err := tt.fx.LBOps.ReconcileHCLB(tt.fx.Ctx, tt.initialLB, tt.service)
+				assert.NoError(t, err)
+				assert.True(t, changed)
+			},
+		},
+		{
+			name: "fail if network of annotation does not exist",
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBNetwork: "tenant-c",
+			},
+			initialLB: &hcloud.LoadBalancer{ID: 5},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				tt.fx.NetworkClient.On("Get", tt.fx.Ctx, "tenant-c").Return(nil, nil, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				_, err := tt.fx.LBOps.ReconcileHCLB(tt.fx.Ctx, tt.initialLB, tt.service)
+				assert.ErrorIs(t, err, hcops.ErrNotFound)
+			},
+		},
+		{
+			name: "fail if network ID of annotation does not exist",
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBNetwork: "42",
+			},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 5,
+				PrivateNet: []hcloud.LoadBalancerPrivateNet{
+					{
+						Network: &hcloud.Network{ID: 15, Name: "some-network"},
+					},
+				},
+			},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				tt.fx.LBOps.NetworkID = tt.initialLB.PrivateNet[0].Network.ID
+				tt.fx.NetworkClient.On("GetByID", tt.fx.Ctx, int64(42)).Return(nil, nil, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				_, err := tt.fx.LBOps.ReconcileHCLB(tt.fx.Ctx, tt.initialLB, tt.service)
+				assert.ErrorIs(t, err, hcops.ErrNotFound)
+				tt.fx.LBClient.AssertNotCalled(t, "DetachFromNetwork", mock.Anything, mock.Anything, mock.Anything)
+			},
+		},
 		{
 			name: "disable enabled public network",
 			serviceAnnotations: map[annotation.Name]interface{}{
@@ -989,6 +1213,33 @@ func TestLoadBalancerOps_ReconcileHCLB(t *testing.T) {
 				assert.True(t, changed)
 			},
 		},
+		{
+			name: "disable public network of load balancer class",
+			service: &corev1.Service{
+				Spec: corev1.ServiceSpec{LoadBalancerClass: hcloud.Ptr("hetzner.cloud/internal")},
+			},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 6,
+				PublicNet: hcloud.LoadBalancerPublicNet{
+					Enabled: true,
+				},
+			},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				tt.fx.LBOps.ClassDefaults = map[string]hcops.LoadBalancerDefaults{
+					"hetzner.cloud/internal": {DisablePublicNetwork: true},
+				}
+				action := &hcloud.Action{ID: rand.Int63()}
+				tt.fx.LBClient.
+					On("DisablePublicInterface", tt.fx.Ctx, tt.initialLB).
+					Return(action, nil, nil)
+				tt.fx.MockWatchProgress(action, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				changed, err := tt.fx.LBOps.ReconcileHCLB(tt.fx.Ctx, tt.initialLB, tt.service)
+				assert.NoError(t, err)
+				assert.True(t, changed)
+			},
+		},
 		{
 			name: "keep disabled public interface",
 			serviceAnnotations: map[annotation.Name]interface{}{
@@ -1305,6 +1556,80 @@ func TestLoadBalancerOps_ReconcileHCLBTargets(t *testing.T) {
 				assert.True(t, changed)
 			},
 		},
+		{
+			name: "use vSwitch IPs of robot nodes as private ip targets",
+			defaults: hcops.LoadBalancerDefaults{
+				UsePrivateIP: true,
+			},
+			k8sNodes: []*corev1.Node{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "bm-3"},
+					Spec:       corev1.NodeSpec{ProviderID: "hcloud://bm-3"},
+					Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+						{Type: corev1.NodeExternalIP, Address: "1.2.3.4"},
+						{Type: corev1.NodeInternalIP, Address: "10.0.1.3"},
+					}},
+				},
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "bm-4"},
+					Spec:       corev1.NodeSpec{ProviderID: "hrobot://4"},
+					Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+						{Type: corev1.NodeExternalIP, Address: "1.2.3.5"},
+						// Not in a vSwitch subnet.
+						{Type: corev1.NodeInternalIP, Address: "1.2.3.5"},
+					}},
+				},
+			},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 5,
+				Targets: []hcloud.LoadBalancerTarget{
+					{
+						Type: hcloud.LoadBalancerTargetTypeIP,
+						IP:   &hcloud.LoadBalancerTargetIP{IP: "1.2.3.4"},
+					},
+				},
+			},
+			robotServers: []models.Server{
+				{
+					ServerNumber:  3,
+					ServerIP:      "1.2.3.4",
+					ServerIPv6Net: "2a01:f48:111:4221::",
+				},
+				{
+					ServerNumber:  4,
+					ServerIP:      "1.2.3.5",
+					ServerIPv6Net: "2a01:f48:111:4222::",
+				},
+			},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				tt.fx.LBOps.NetworkID = 4711
+				_, vSwitchSubnet, _ := net.ParseCIDR("10.0.1.0/24")
+				nw := &hcloud.Network{
+					ID:      4711,
+					Subnets: []hcloud.NetworkSubnet{{Type: hcloud.NetworkSubnetTypeVSwitch, IPRange: vSwitchSubnet}},
+				}
+				tt.fx.NetworkClient.On("GetByID", tt.fx.Ctx, nw.ID).Return(nw, nil, nil)
+
+				action := tt.fx.MockRemoveIPTarget(tt.initialLB, net.ParseIP("1.2.3.4"), nil)
+				tt.fx.MockWatchProgress(action, nil)
+
+				optsIP := hcloud.LoadBalancerAddIPTargetOpts{IP: net.ParseIP("10.0.1.3")}
+				action = tt.fx.MockAddIPTarget(tt.initialLB, optsIP, nil)
+				tt.fx.MockWatchProgress(action, nil)
+
+				tt.fx.MockListRobotServers(tt.robotServers, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				changed, err := tt.fx.LBOps.ReconcileHCLBTargets(tt.fx.Ctx, tt.initialLB, tt.service, tt.k8sNodes)
+				assert.NoError(t, err)
+				assert.True(t, changed)
+				assert.Equal(t,
+					"Warning PrivateIPMissing cannot add node bm-4 as private ip target because it has no InternalIP in a vSwitch subnet",
+					<-tt.fx.Recorder.Events)
+				assert.Equal(t, "Normal TargetRemoved Removed IP 1.2.3.4 from targets", <-tt.fx.Recorder.Events)
+				assert.Equal(t, "Normal TargetAdded Added node bm-3 as target with IP 10.0.1.3", <-tt.fx.Recorder.Events)
+			},
+		},
 		{
 			name: "disable use of private network via annotation",
 			defaults: hcops.LoadBalancerDefaults{
@@ -1347,62 +1672,577 @@ func TestLoadBalancerOps_ReconcileHCLBTargets(t *testing.T) {
 				assert.True(t, changed)
 			},
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, tt.run)
-	}
-}
-
-func TestLoadBalancerOps_ReconcileHCLBServices(t *testing.T) {
-	tests := []LBReconcilementTestCase{
 		{
-			name: "add services to hc Load Balancer",
-			servicePorts: []corev1.ServicePort{
-				{Port: 80, NodePort: 8080},
-				{Port: 443, NodePort: 8443},
+			name: "remove targets before adding targets concurrently",
+			k8sNodes: []*corev1.Node{
+				{ObjectMeta: metav1.ObjectMeta{Name: "node3"}, Spec: corev1.NodeSpec{ProviderID: "hcloud://3"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "node4"}, Spec: corev1.NodeSpec{ProviderID: "hcloud://4"}},
 			},
 			initialLB: &hcloud.LoadBalancer{
-				ID: 4,
-			},
-			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
-				opts := hcloud.LoadBalancerAddServiceOpts{
-					Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
-					ListenPort:      hcloud.Ptr(80),
-					DestinationPort: hcloud.Ptr(8080),
-					HealthCheck: &hcloud.LoadBalancerAddServiceOptsHealthCheck{
-						Protocol: hcloud.LoadBalancerServiceProtocolTCP,
-						Port:     hcloud.Ptr(8080),
+				ID: 5,
+				Targets: []hcloud.LoadBalancerTarget{
+					{
+						Type:   hcloud.LoadBalancerTargetTypeServer,
+						Server: &hcloud.LoadBalancerTargetServer{Server: &hcloud.Server{ID: 1}},
 					},
-				}
-				action := tt.fx.MockAddService(opts, tt.initialLB, nil)
-				tt.fx.MockWatchProgress(action, nil)
-
-				opts = hcloud.LoadBalancerAddServiceOpts{
-					Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
-					ListenPort:      hcloud.Ptr(443),
-					DestinationPort: hcloud.Ptr(8443),
-					HealthCheck: &hcloud.LoadBalancerAddServiceOptsHealthCheck{
-						Protocol: hcloud.LoadBalancerServiceProtocolTCP,
-						Port:     hcloud.Ptr(8443),
+					{
+						Type:   hcloud.LoadBalancerTargetTypeServer,
+						Server: &hcloud.LoadBalancerTargetServer{Server: &hcloud.Server{ID: 2}},
 					},
+				},
+			},
+			mock: func(t *testing.T, tt *LBReconcilementTestCase) {
+				tt.fx.LBOps.TargetWorkers = 2
+
+				var removed atomic.Int32
+				for _, id := range []int64{1, 2} {
+					action := &hcloud.Action{ID: id}
+					tt.fx.LBClient.
+						On("RemoveServerTarget", tt.fx.Ctx, tt.initialLB, &hcloud.Server{ID: id}).
+						Run(func(mock.Arguments) { removed.Add(1) }).
+						Return(action, nil, nil)
+					tt.fx.MockWatchProgress(action, nil)
 				}
-				action = tt.fx.MockAddService(opts, tt.initialLB, nil)
-				tt.fx.MockWatchProgress(action, nil)
+				for _, id := range []int64{3, 4} {
+					action := &hcloud.Action{ID: id}
+					opts := hcloud.LoadBalancerAddServerTargetOpts{
+						Server:       &hcloud.Server{ID: id},
+						UsePrivateIP: hcloud.Ptr(false),
+					}
+					tt.fx.LBClient.
+						On("AddServerTarget", tt.fx.Ctx, tt.initialLB, opts).
+						Run(func(mock.Arguments) { assert.Equal(t, int32(2), removed.Load()) }).
+						Return(action, nil, nil)
+					tt.fx.MockWatchProgress(action, nil)
+				}
+
+				tt.fx.MockListRobotServers(nil, nil)
 			},
 			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
-				changed, err := tt.fx.LBOps.ReconcileHCLBServices(tt.fx.Ctx, tt.initialLB, tt.service)
+				changed, err := tt.fx.LBOps.ReconcileHCLBTargets(tt.fx.Ctx, tt.initialLB, tt.service, tt.k8sNodes)
 				assert.NoError(t, err)
 				assert.True(t, changed)
 			},
 		},
 		{
-			name: "reference TLS certificate by id",
-			servicePorts: []corev1.ServicePort{
-				{Port: 443, NodePort: 8443},
+			name: "report errors of all failed targets",
+			k8sNodes: []*corev1.Node{
+				{ObjectMeta: metav1.ObjectMeta{Name: "node1"}, Spec: corev1.NodeSpec{ProviderID: "hcloud://1"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "node2"}, Spec: corev1.NodeSpec{ProviderID: "hcloud://2"}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "node3"}, Spec: corev1.NodeSpec{ProviderID: "hcloud://3"}},
 			},
 			initialLB: &hcloud.LoadBalancer{
-				ID: 10,
+				ID: 6,
+			},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				tt.fx.LBOps.TargetWorkers = 3
+				tt.fx.LBOps.RetryDelay = time.Millisecond
+
+				for _, id := range []int64{1, 2} {
+					opts := hcloud.LoadBalancerAddServerTargetOpts{
+						Server:       &hcloud.Server{ID: id},
+						UsePrivateIP: hcloud.Ptr(false),
+					}
+					tt.fx.MockAddServerTarget(tt.initialLB, opts, errTestLbClient)
+				}
+
+				// The Load Balancer is locked by the other changes at first.
+				opts := hcloud.LoadBalancerAddServerTargetOpts{
+					Server:       &hcloud.Server{ID: 3},
+					UsePrivateIP: hcloud.Ptr(false),
+				}
+				tt.fx.LBClient.
+					On("AddServerTarget", tt.fx.Ctx, tt.initialLB, opts).
+					Return(nil, nil, hcloud.Error{Code: hcloud.ErrorCodeLocked}).
+					Once()
+				action := tt.fx.MockAddServerTarget(tt.initialLB, opts, nil)
+				tt.fx.MockWatchProgress(action, nil)
+
+				tt.fx.MockListRobotServers(nil, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				changed, err := tt.fx.LBOps.ReconcileHCLBTargets(tt.fx.Ctx, tt.initialLB, tt.service, tt.k8sNodes)
+				assert.ErrorIs(t, err, errTestLbClient)
+				assert.ErrorContains(t, err, "target node1: lb client failed")
+				assert.ErrorContains(t, err, "target node2: lb client failed")
+				assert.True(t, changed)
+				assert.Equal(t, "Normal TargetAdded Added node node3 as target", <-tt.fx.Recorder.Events)
+			},
+		},
+		{
+			name: "retry target while Load Balancer is locked repeatedly",
+			k8sNodes: []*corev1.Node{
+				{ObjectMeta: metav1.ObjectMeta{Name: "node1"}, Spec: corev1.NodeSpec{ProviderID: "hcloud://1"}},
+			},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 6,
+			},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				tt.fx.LBOps.RetryDelay = time.Millisecond
+
+				opts := hcloud.LoadBalancerAddServerTargetOpts{
+					Server:       &hcloud.Server{ID: 1},
+					UsePrivateIP: hcloud.Ptr(false),
+				}
+				tt.fx.LBClient.
+					On("AddServerTarget", tt.fx.Ctx, tt.initialLB, opts).
+					Return(nil, nil, hcloud.Error{Code: hcloud.ErrorCodeLocked}).
+					Times(3)
+				tt.fx.LBClient.
+					On("AddServerTarget", tt.fx.Ctx, tt.initialLB, opts).
+					Return(nil, nil, hcloud.Error{Code: hcloud.ErrorCodeConflict}).
+					Times(2)
+				action := tt.fx.MockAddServerTarget(tt.initialLB, opts, nil)
+				tt.fx.MockWatchProgress(action, nil)
+
+				tt.fx.MockListRobotServers(nil, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				changed, err := tt.fx.LBOps.ReconcileHCLBTargets(tt.fx.Ctx, tt.initialLB, tt.service, tt.k8sNodes)
+				assert.NoError(t, err)
+				assert.True(t, changed)
+				tt.fx.LBClient.AssertNumberOfCalls(t, "AddServerTarget", 6)
+			},
+		},
+		{
+			name: "stop retrying locked Load Balancer when context is done",
+			k8sNodes: []*corev1.Node{
+				{ObjectMeta: metav1.ObjectMeta{Name: "node1"}, Spec: corev1.NodeSpec{ProviderID: "hcloud://1"}},
+			},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 6,
+			},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				tt.fx.LBOps.RetryDelay = time.Hour
+				ctx, cancel := context.WithCancel(tt.fx.Ctx)
+				tt.fx.Ctx = ctx
+
+				opts := hcloud.LoadBalancerAddServerTargetOpts{
+					Server:       &hcloud.Server{ID: 1},
+					UsePrivateIP: hcloud.Ptr(false),
+				}
+				tt.fx.LBClient.
+					On("AddServerTarget", tt.fx.Ctx, tt.initialLB, opts).
+					Run(func(_ mock.Arguments) { cancel() }).
+					Return(nil, nil, hcloud.Error{Code: hcloud.ErrorCodeLocked}).
+					Once()
+
+				tt.fx.MockListRobotServers(nil, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				_, err := tt.fx.LBOps.ReconcileHCLBTargets(tt.fx.Ctx, tt.initialLB, tt.service, tt.k8sNodes)
+				assert.ErrorIs(t, err, context.Canceled)
+				tt.fx.LBClient.AssertNumberOfCalls(t, "AddServerTarget", 1)
+			},
+		},
+		{
+			name:       "use label selector target",
+			serviceUID: "uid",
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBTargetType: hcloud.LoadBalancerTargetTypeLabelSelector,
+			},
+			k8sNodes: []*corev1.Node{
+				{Spec: corev1.NodeSpec{ProviderID: "hcloud://1"}},
+				{Spec: corev1.NodeSpec{ProviderID: "hcloud://2"}},
+			},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 7,
+				Targets: []hcloud.LoadBalancerTarget{
+					{
+						Type:   hcloud.LoadBalancerTargetTypeServer,
+						Server: &hcloud.LoadBalancerTargetServer{Server: &hcloud.Server{ID: 1}},
+					},
+				},
+			},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				action := tt.fx.MockRemoveServerTarget(tt.initialLB, &hcloud.Server{ID: 1}, nil)
+				tt.fx.MockWatchProgress(action, nil)
+
+				// Server 1 is labeled already, server 3 is no node anymore.
+				server1 := &hcloud.Server{ID: 1, Name: "server1", Labels: map[string]string{"hcloud-ccm/lb-target-uid": ""}}
+				server2 := &hcloud.Server{ID: 2, Name: "server2", Labels: map[string]string{"role": "worker"}}
+				server3 := &hcloud.Server{ID: 3, Name: "server3", Labels: map[string]string{"hcloud-ccm/lb-target-uid": ""}}
+				listOpts := hcloud.ServerListOpts{ListOpts: hcloud.ListOpts{LabelSelector: "hcloud-ccm/lb-target-uid"}}
+				tt.fx.ServerClient.On("AllWithOpts", tt.fx.Ctx, listOpts).Return([]*hcloud.Server{server1, server3}, nil)
+				tt.fx.ServerClient.
+					On("Update", tt.fx.Ctx, server3, hcloud.ServerUpdateOpts{Labels: map[string]string{}}).
+					Return(server3, nil, nil)
+				tt.fx.ServerClient.On("GetByID", tt.fx.Ctx, int64(2)).Return(server2, nil, nil)
+				labels := map[string]string{"role": "worker", "hcloud-ccm/lb-target-uid": ""}
+				tt.fx.ServerClient.
+					On("Update", tt.fx.Ctx, server2, hcloud.ServerUpdateOpts{Labels: labels}).
+					Return(server2, nil, nil)
+
+				opts := hcloud.LoadBalancerAddLabelSelectorTargetOpts{
+					Selector:     "hcloud-ccm/lb-target-uid",
+					UsePrivateIP: hcloud.Ptr(false),
+				}
+				action = &hcloud.Action{ID: rand.Int63()}
+				tt.fx.LBClient.On("AddLabelSelectorTarget", tt.fx.Ctx, tt.initialLB, opts).Return(action, nil, nil)
+				tt.fx.MockWatchProgress(action, nil)
+
+				tt.fx.MockListRobotServers(nil, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				changed, err := tt.fx.LBOps.ReconcileHCLBTargets(tt.fx.Ctx, tt.initialLB, tt.service, tt.k8sNodes)
+				assert.NoError(t, err)
+				assert.True(t, changed)
+				// The server target is removed only after the label selector
+				// target was added.
+				assert.Equal(t, []string{
+					"Normal ServerUnlabeled Removed label hcloud-ccm/lb-target-uid from server server3",
+					"Normal ServerLabeled Added label hcloud-ccm/lb-target-uid to server server2",
+					"Normal TargetAdded Added label selector hcloud-ccm/lb-target-uid as target",
+					"Normal TargetRemoved Removed server 1 from targets",
+				}, []string{<-tt.fx.Recorder.Events, <-tt.fx.Recorder.Events, <-tt.fx.Recorder.Events, <-tt.fx.Recorder.Events})
+			},
+		},
+		{
+			name:       "keep label selector target of unchanged nodes",
+			serviceUID: "uid",
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBTargetType: hcloud.LoadBalancerTargetTypeLabelSelector,
+			},
+			k8sNodes: []*corev1.Node{
+				{Spec: corev1.NodeSpec{ProviderID: "hcloud://1"}},
+			},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 8,
+				Targets: []hcloud.LoadBalancerTarget{
+					{
+						Type:          hcloud.LoadBalancerTargetTypeLabelSelector,
+						LabelSelector: &hcloud.LoadBalancerTargetLabelSelector{Selector: "hcloud-ccm/lb-target-uid"},
+					},
+				},
+			},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				server1 := &hcloud.Server{ID: 1, Labels: map[string]string{"hcloud-ccm/lb-target-uid": ""}}
+				listOpts := hcloud.ServerListOpts{ListOpts: hcloud.ListOpts{LabelSelector: "hcloud-ccm/lb-target-uid"}}
+				tt.fx.ServerClient.On("AllWithOpts", tt.fx.Ctx, listOpts).Return([]*hcloud.Server{server1}, nil)
+
+				tt.fx.MockListRobotServers(nil, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				changed, err := tt.fx.LBOps.ReconcileHCLBTargets(tt.fx.Ctx, tt.initialLB, tt.service, tt.k8sNodes)
+				assert.NoError(t, err)
+				assert.False(t, changed)
+			},
+		},
+		{
+			name:       "keep server targets if label selector target cannot be added",
+			serviceUID: "uid",
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBTargetType: hcloud.LoadBalancerTargetTypeLabelSelector,
+			},
+			k8sNodes: []*corev1.Node{
+				{Spec: corev1.NodeSpec{ProviderID: "hcloud://1"}},
+			},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 7,
+				Targets: []hcloud.LoadBalancerTarget{
+					{
+						Type:   hcloud.LoadBalancerTargetTypeServer,
+						Server: &hcloud.LoadBalancerTargetServer{Server: &hcloud.Server{ID: 1}},
+					},
+				},
+			},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				server1 := &hcloud.Server{ID: 1, Labels: map[string]string{"hcloud-ccm/lb-target-uid": ""}}
+				listOpts := hcloud.ServerListOpts{ListOpts: hcloud.ListOpts{LabelSelector: "hcloud-ccm/lb-target-uid"}}
+				tt.fx.ServerClient.On("AllWithOpts", tt.fx.Ctx, listOpts).Return([]*hcloud.Server{server1}, nil)
+
+				opts := hcloud.LoadBalancerAddLabelSelectorTargetOpts{
+					Selector:     "hcloud-ccm/lb-target-uid",
+					UsePrivateIP: hcloud.Ptr(false),
+				}
+				tt.fx.LBClient.On("AddLabelSelectorTarget", tt.fx.Ctx, tt.initialLB, opts).Return(nil, nil, errTestLbClient)
+
+				tt.fx.MockListRobotServers(nil, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				_, err := tt.fx.LBOps.ReconcileHCLBTargets(tt.fx.Ctx, tt.initialLB, tt.service, tt.k8sNodes)
+				assert.ErrorIs(t, err, errTestLbClient)
+				tt.fx.LBClient.AssertNotCalled(t, "RemoveServerTarget", mock.Anything, mock.Anything, mock.Anything)
+			},
+		},
+		{
+			name:       "replace label selector target by server targets",
+			serviceUID: "uid",
+			k8sNodes: []*corev1.Node{
+				{ObjectMeta: metav1.ObjectMeta{Name: "node1"}, Spec: corev1.NodeSpec{ProviderID: "hcloud://1"}},
+			},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 9,
+				Targets: []hcloud.LoadBalancerTarget{
+					{
+						Type:          hcloud.LoadBalancerTargetTypeLabelSelector,
+						LabelSelector: &hcloud.LoadBalancerTargetLabelSelector{Selector: "hcloud-ccm/lb-target-uid"},
+					},
+				},
+			},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				action := &hcloud.Action{ID: rand.Int63()}
+				tt.fx.LBClient.
+					On("RemoveLabelSelectorTarget", tt.fx.Ctx, tt.initialLB, "hcloud-ccm/lb-target-uid").
+					Return(action, nil, nil)
+				tt.fx.MockWatchProgress(action, nil)
+
+				server1 := &hcloud.Server{ID: 1, Name: "server1", Labels: map[string]string{"hcloud-ccm/lb-target-uid": ""}}
+				listOpts := hcloud.ServerListOpts{ListOpts: hcloud.ListOpts{LabelSelector: "hcloud-ccm/lb-target-uid"}}
+				tt.fx.ServerClient.On("AllWithOpts", tt.fx.Ctx, listOpts).Return([]*hcloud.Server{server1}, nil)
+				tt.fx.ServerClient.
+					On("Update", tt.fx.Ctx, server1, hcloud.ServerUpdateOpts{Labels: map[string]string{}}).
+					Return(server1, nil, nil)
+
+				opts := hcloud.LoadBalancerAddServerTargetOpts{
+					Server:       &hcloud.Server{ID: 1},
+					UsePrivateIP: hcloud.Ptr(false),
+				}
+				action = tt.fx.MockAddServerTarget(tt.initialLB, opts, nil)
+				tt.fx.MockWatchProgress(action, nil)
+
+				tt.fx.MockListRobotServers(nil, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				changed, err := tt.fx.LBOps.ReconcileHCLBTargets(tt.fx.Ctx, tt.initialLB, tt.service, tt.k8sNodes)
+				assert.NoError(t, err)
+				assert.True(t, changed)
+				// The label selector target and the labels are removed only
+				// after the server target was added.
+				assert.Equal(t, []string{
+					"Normal TargetAdded Added node node1 as target",
+					"Normal TargetRemoved Removed label selector hcloud-ccm/lb-target-uid from targets",
+					"Normal ServerUnlabeled Removed label hcloud-ccm/lb-target-uid from server server1",
+				}, []string{<-tt.fx.Recorder.Events, <-tt.fx.Recorder.Events, <-tt.fx.Recorder.Events})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, tt.run)
+	}
+}
+
+func TestLoadBalancerOps_ReconcileHCLBServices(t *testing.T) {
+	tests := []LBReconcilementTestCase{
+		{
+			name: "add services to hc Load Balancer",
+			servicePorts: []corev1.ServicePort{
+				{Port: 80, NodePort: 8080},
+				{Port: 443, NodePort: 8443},
+			},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 4,
+			},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				opts := hcloud.LoadBalancerAddServiceOpts{
+					Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+					ListenPort:      hcloud.Ptr(80),
+					DestinationPort: hcloud.Ptr(8080),
+					HealthCheck: &hcloud.LoadBalancerAddServiceOptsHealthCheck{
+						Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+						Port:     hcloud.Ptr(8080),
+					},
+				}
+				action := tt.fx.MockAddService(opts, tt.initialLB, nil)
+				tt.fx.MockWatchProgress(action, nil)
+
+				opts = hcloud.LoadBalancerAddServiceOpts{
+					Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+					ListenPort:      hcloud.Ptr(443),
+					DestinationPort: hcloud.Ptr(8443),
+					HealthCheck: &hcloud.LoadBalancerAddServiceOptsHealthCheck{
+						Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+						Port:     hcloud.Ptr(8443),
+					},
+				}
+				action = tt.fx.MockAddService(opts, tt.initialLB, nil)
+				tt.fx.MockWatchProgress(action, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				changed, err := tt.fx.LBOps.ReconcileHCLBServices(tt.fx.Ctx, tt.initialLB, tt.service)
+				assert.NoError(t, err)
+				assert.True(t, changed)
+			},
+		},
+		{
+			name: "per-port annotations override service-wide annotations",
+			servicePorts: []corev1.ServicePort{
+				{Port: 80, NodePort: 8080},
+				{Port: 443, NodePort: 8443},
+			},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 4,
+			},
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcProtocol:                        string(hcloud.LoadBalancerServiceProtocolHTTP),
+				annotation.LBSvcProtocol.ForPort(443):           string(hcloud.LoadBalancerServiceProtocolHTTPS),
+				annotation.LBSvcHTTPCertificates.ForPort(443):   []string{"1"},
+				annotation.LBSvcHealthCheckProtocol.ForPort(80): string(hcloud.LoadBalancerServiceProtocolHTTP),
+			},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				opts := hcloud.LoadBalancerAddServiceOpts{
+					Protocol:        hcloud.LoadBalancerServiceProtocolHTTP,
+					ListenPort:      hcloud.Ptr(80),
+					DestinationPort: hcloud.Ptr(8080),
+					HealthCheck: &hcloud.LoadBalancerAddServiceOptsHealthCheck{
+						Protocol: hcloud.LoadBalancerServiceProtocolHTTP,
+						Port:     hcloud.Ptr(8080),
+						HTTP:     &hcloud.LoadBalancerAddServiceOptsHealthCheckHTTP{},
+					},
+				}
+				action := tt.fx.MockAddService(opts, tt.initialLB, nil)
+				tt.fx.MockWatchProgress(action, nil)
+
+				opts = hcloud.LoadBalancerAddServiceOpts{
+					Protocol:        hcloud.LoadBalancerServiceProtocolHTTPS,
+					ListenPort:      hcloud.Ptr(443),
+					DestinationPort: hcloud.Ptr(8443),
+					HTTP: &hcloud.LoadBalancerAddServiceOptsHTTP{
+						Certificates: []*hcloud.Certificate{
+							{ID: 1},
+						},
+					},
+					HealthCheck: &hcloud.LoadBalancerAddServiceOptsHealthCheck{
+						Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+						Port:     hcloud.Ptr(8443),
+					},
+				}
+				action = tt.fx.MockAddService(opts, tt.initialLB, nil)
+				tt.fx.MockWatchProgress(action, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				changed, err := tt.fx.LBOps.ReconcileHCLBServices(tt.fx.Ctx, tt.initialLB, tt.service)
+				assert.NoError(t, err)
+				assert.True(t, changed)
+			},
+		},
+		{
+			name: "upload certificate of TLS secret",
+			servicePorts: []corev1.ServicePort{
+				{Port: 443, NodePort: 8443},
+			},
+			serviceUID: "uid",
+			initialLB: &hcloud.LoadBalancer{
+				ID: 4,
+			},
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcProtocol:               hcloud.LoadBalancerServiceProtocolHTTPS,
+				annotation.LBSvcHTTPCertificateSecrets: "web-tls",
+			},
+			mock: func(t *testing.T, tt *LBReconcilementTestCase) {
+				tt.service.Namespace = "default"
+				tt.fx.LBOps.SecretLister = newTestSecretLister(t, newTestTLSSecret("web-tls", "crt"))
+				tt.fx.CertClient.
+					On("AllWithOpts", tt.fx.Ctx, hcloud.CertificateListOpts{
+						ListOpts: hcloud.ListOpts{LabelSelector: "hcloud-ccm/secret-service-uid=uid"},
+					}).
+					Return(nil, nil)
+				tt.fx.CertClient.
+					On("CreateCertificate", tt.fx.Ctx, mock.MatchedBy(func(opts hcloud.CertificateCreateOpts) bool {
+						return opts.Type == hcloud.CertificateTypeUploaded &&
+							opts.Certificate == "crt" && opts.PrivateKey == "key" &&
+							opts.Labels["hcloud-ccm/secret-service-uid"] == "uid"
+					})).
+					Return(hcloud.CertificateCreateResult{Certificate: &hcloud.Certificate{ID: 5}}, nil, nil)
+
+				opts := hcloud.LoadBalancerAddServiceOpts{
+					Protocol:        hcloud.LoadBalancerServiceProtocolHTTPS,
+					ListenPort:      hcloud.Ptr(443),
+					DestinationPort: hcloud.Ptr(8443),
+					HTTP: &hcloud.LoadBalancerAddServiceOptsHTTP{
+						Certificates: []*hcloud.Certificate{{ID: 5}},
+					},
+					HealthCheck: &hcloud.LoadBalancerAddServiceOptsHealthCheck{
+						Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+						Port:     hcloud.Ptr(8443),
+					},
+				}
+				action := tt.fx.MockAddService(opts, tt.initialLB, nil)
+				tt.fx.MockWatchProgress(action, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				changed, err := tt.fx.LBOps.ReconcileHCLBServices(tt.fx.Ctx, tt.initialLB, tt.service)
+				assert.NoError(t, err)
+				assert.True(t, changed)
+				assert.Regexp(t, "^Normal CertificateUploaded Uploaded certificate ccm-secret-uid-[0-9a-f]{12} of Secret web-tls$",
+					<-tt.fx.Recorder.Events)
+			},
+		},
+		{
+			name: "replace certificate of changed TLS secret",
+			servicePorts: []corev1.ServicePort{
+				{Port: 443, NodePort: 8443},
+			},
+			serviceUID: "uid",
+			initialLB: &hcloud.LoadBalancer{
+				ID: 4,
+				Services: []hcloud.LoadBalancerService{
+					{
+						ListenPort:      443,
+						DestinationPort: 8443,
+						Protocol:        hcloud.LoadBalancerServiceProtocolHTTPS,
+						HTTP: hcloud.LoadBalancerServiceHTTP{
+							Certificates: []*hcloud.Certificate{{ID: 4}},
+						},
+						HealthCheck: hcloud.LoadBalancerServiceHealthCheck{
+							Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+							Port:     8443,
+						},
+					},
+				},
+			},
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcProtocol:               hcloud.LoadBalancerServiceProtocolHTTPS,
+				annotation.LBSvcHTTPCertificateSecrets: "web-tls",
+			},
+			mock: func(t *testing.T, tt *LBReconcilementTestCase) {
+				tt.service.Namespace = "default"
+				oldCert := &hcloud.Certificate{
+					ID:     4,
+					Name:   "ccm-secret-uid-old",
+					Labels: map[string]string{"hcloud-ccm/secret-service-uid": "uid", "hcloud-ccm/secret-hash": "old"},
+				}
+				tt.fx.LBOps.SecretLister = newTestSecretLister(t, newTestTLSSecret("web-tls", "renewed crt"))
+				tt.fx.CertClient.
+					On("AllWithOpts", tt.fx.Ctx, hcloud.CertificateListOpts{
+						ListOpts: hcloud.ListOpts{LabelSelector: "hcloud-ccm/service-uid=uid"},
+					}).
+					Return([]*hcloud.Certificate{}, nil)
+				tt.fx.CertClient.
+					On("AllWithOpts", tt.fx.Ctx, hcloud.CertificateListOpts{
+						ListOpts: hcloud.ListOpts{LabelSelector: "hcloud-ccm/secret-service-uid=uid"},
+					}).
+					Return([]*hcloud.Certificate{oldCert}, nil)
+				tt.fx.CertClient.
+					On("CreateCertificate", tt.fx.Ctx, mock.MatchedBy(func(opts hcloud.CertificateCreateOpts) bool {
+						return opts.Certificate == "renewed crt"
+					})).
+					Return(hcloud.CertificateCreateResult{Certificate: &hcloud.Certificate{ID: 5}}, nil, nil)
+
+				opts := hcloud.LoadBalancerUpdateServiceOpts{
+					HTTP: &hcloud.LoadBalancerUpdateServiceOptsHTTP{
+						Certificates: []*hcloud.Certificate{{ID: 5}},
+					},
+				}
+				action := tt.fx.MockUpdateService(opts, tt.initialLB, 443, nil)
+				tt.fx.MockWatchProgress(action, nil)
+
+				tt.fx.CertClient.On("Delete", tt.fx.Ctx, oldCert).Return(nil, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				changed, err := tt.fx.LBOps.ReconcileHCLBServices(tt.fx.Ctx, tt.initialLB, tt.service)
+				assert.NoError(t, err)
+				assert.True(t, changed)
+			},
+		},
+		{
+			name: "reference TLS certificate by id",
+			servicePorts: []corev1.ServicePort{
+				{Port: 443, NodePort: 8443},
+			},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 10,
 			},
 			serviceAnnotations: map[annotation.Name]interface{}{
 				annotation.LBSvcHTTPCertificates: []string{"1"},
@@ -1481,15 +2321,6 @@ func TestLoadBalancerOps_ReconcileHCLBServices(t *testing.T) {
 			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
 				cert := &hcloud.Certificate{ID: 1}
 
-				tt.fx.CertClient.
-					On("CreateCertificate", mock.Anything, hcloud.CertificateCreateOpts{
-						Name:        "ccm-managed-certificate-some service uid",
-						Type:        hcloud.CertificateTypeManaged,
-						DomainNames: []string{"example.com", "*.example.com"},
-						Labels:      map[string]string{hcops.LabelServiceUID: tt.serviceUID},
-					}).
-					Return(hcloud.CertificateCreateResult{Certificate: cert}, nil, nil)
-
 				tt.fx.CertClient.
 					On(
 						"AllWithOpts",
@@ -1499,7 +2330,16 @@ func TestLoadBalancerOps_ReconcileHCLBServices(t *testing.T) {
 								LabelSelector: fmt.Sprintf("%s=%s", hcops.LabelServiceUID, tt.serviceUID),
 							},
 						}).
-					Return([]*hcloud.Certificate{cert}, nil, nil)
+					Return([]*hcloud.Certificate{}, nil, nil)
+
+				tt.fx.CertClient.
+					On("CreateCertificate", mock.Anything, hcloud.CertificateCreateOpts{
+						Name:        "ccm-managed-certificate-some service uid",
+						Type:        hcloud.CertificateTypeManaged,
+						DomainNames: []string{"example.com", "*.example.com"},
+						Labels:      map[string]string{hcops.LabelServiceUID: tt.serviceUID},
+					}).
+					Return(hcloud.CertificateCreateResult{Certificate: cert}, nil, nil)
 
 				opts := hcloud.LoadBalancerAddServiceOpts{
 					Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
@@ -1520,6 +2360,204 @@ func TestLoadBalancerOps_ReconcileHCLBServices(t *testing.T) {
 				changed, err := tt.fx.LBOps.ReconcileHCLBServices(tt.fx.Ctx, tt.initialLB, tt.service)
 				assert.NoError(t, err)
 				assert.True(t, changed)
+				assert.Equal(t,
+					"Normal ManagedCertificateCreated Created managed certificate ccm-managed-certificate-some service uid for example.com, *.example.com",
+					<-tt.fx.Recorder.Events)
+				assert.Equal(t, "Normal ServiceAdded Added service on port 443", <-tt.fx.Recorder.Events)
+			},
+		},
+		{
+			name:         "keep managed certificate of previous domains until the new one is issued",
+			servicePorts: []corev1.ServicePort{{Port: 443, NodePort: 8443}},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 12,
+				Services: []hcloud.LoadBalancerService{
+					{
+						ListenPort:      443,
+						DestinationPort: 8443,
+						Protocol:        hcloud.LoadBalancerServiceProtocolHTTPS,
+						HTTP: hcloud.LoadBalancerServiceHTTP{
+							Certificates: []*hcloud.Certificate{{ID: 1}},
+						},
+						HealthCheck: hcloud.LoadBalancerServiceHealthCheck{
+							Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+							Port:     8443,
+						},
+					},
+				},
+			},
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcProtocol:                      hcloud.LoadBalancerServiceProtocolHTTPS,
+				annotation.LBSvcHTTPCertificateType:           hcloud.CertificateTypeManaged,
+				annotation.LBSvcHTTPManagedCertificateDomains: []string{"new.example.com"},
+			},
+			serviceUID: "uid",
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				oldCert := &hcloud.Certificate{
+					ID:          1,
+					Name:        "ccm-managed-certificate-uid",
+					DomainNames: []string{"example.com"},
+				}
+				tt.fx.CertClient.
+					On("AllWithOpts", tt.fx.Ctx, hcloud.CertificateListOpts{
+						ListOpts: hcloud.ListOpts{LabelSelector: "hcloud-ccm/service-uid=uid"},
+					}).
+					Return([]*hcloud.Certificate{oldCert}, nil)
+				tt.fx.CertClient.
+					On("AllWithOpts", tt.fx.Ctx, hcloud.CertificateListOpts{
+						ListOpts: hcloud.ListOpts{LabelSelector: "hcloud-ccm/secret-service-uid=uid"},
+					}).
+					Return([]*hcloud.Certificate{}, nil)
+				tt.fx.CertClient.
+					On("CreateCertificate", tt.fx.Ctx, hcloud.CertificateCreateOpts{
+						Name:        "ccm-managed-certificate-uid-d42059e7",
+						Type:        hcloud.CertificateTypeManaged,
+						DomainNames: []string{"new.example.com"},
+						Labels:      map[string]string{hcops.LabelServiceUID: "uid"},
+					}).
+					Return(hcloud.CertificateCreateResult{Certificate: &hcloud.Certificate{
+						ID:     2,
+						Name:   "ccm-managed-certificate-uid-d42059e7",
+						Status: &hcloud.CertificateStatus{Issuance: hcloud.CertificateStatusTypePending},
+					}}, nil, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				changed, err := tt.fx.LBOps.ReconcileHCLBServices(tt.fx.Ctx, tt.initialLB, tt.service)
+				assert.ErrorIs(t, err, hcops.ErrCertificatePending)
+				assert.False(t, changed)
+				assert.Equal(t,
+					"Normal ManagedCertificateCreated Created managed certificate ccm-managed-certificate-uid-d42059e7 for new.example.com",
+					<-tt.fx.Recorder.Events)
+				assert.Equal(t,
+					"Normal ManagedCertificatePending Issuance of managed certificate ccm-managed-certificate-uid-d42059e7 is pending",
+					<-tt.fx.Recorder.Events)
+				assert.Empty(t, tt.fx.Recorder.Events)
+				tt.fx.CertClient.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+			},
+		},
+		{
+			name:         "replace managed certificate of changed domains once the new one is issued",
+			servicePorts: []corev1.ServicePort{{Port: 443, NodePort: 8443}},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 12,
+				Services: []hcloud.LoadBalancerService{
+					{
+						ListenPort:      443,
+						DestinationPort: 8443,
+						Protocol:        hcloud.LoadBalancerServiceProtocolHTTPS,
+						HTTP: hcloud.LoadBalancerServiceHTTP{
+							Certificates: []*hcloud.Certificate{{ID: 1}},
+						},
+						HealthCheck: hcloud.LoadBalancerServiceHealthCheck{
+							Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+							Port:     8443,
+						},
+					},
+				},
+			},
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcProtocol:                      hcloud.LoadBalancerServiceProtocolHTTPS,
+				annotation.LBSvcHTTPCertificateType:           hcloud.CertificateTypeManaged,
+				annotation.LBSvcHTTPManagedCertificateDomains: []string{"new.example.com"},
+			},
+			serviceUID: "uid",
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				oldCert := &hcloud.Certificate{
+					ID:          1,
+					Name:        "ccm-managed-certificate-uid",
+					DomainNames: []string{"example.com"},
+				}
+				newCert := &hcloud.Certificate{
+					ID:          2,
+					Name:        "ccm-managed-certificate-uid-d42059e7",
+					DomainNames: []string{"new.example.com"},
+					Status:      &hcloud.CertificateStatus{Issuance: hcloud.CertificateStatusTypeCompleted},
+				}
+				tt.fx.CertClient.
+					On("AllWithOpts", tt.fx.Ctx, hcloud.CertificateListOpts{
+						ListOpts: hcloud.ListOpts{LabelSelector: "hcloud-ccm/service-uid=uid"},
+					}).
+					Return([]*hcloud.Certificate{oldCert, newCert}, nil)
+				tt.fx.CertClient.
+					On("AllWithOpts", tt.fx.Ctx, hcloud.CertificateListOpts{
+						ListOpts: hcloud.ListOpts{LabelSelector: "hcloud-ccm/secret-service-uid=uid"},
+					}).
+					Return([]*hcloud.Certificate{}, nil)
+
+				opts := hcloud.LoadBalancerUpdateServiceOpts{
+					HTTP: &hcloud.LoadBalancerUpdateServiceOptsHTTP{
+						Certificates: []*hcloud.Certificate{{ID: 2}},
+					},
+				}
+				action := tt.fx.MockUpdateService(opts, tt.initialLB, 443, nil)
+				tt.fx.MockWatchProgress(action, nil)
+
+				tt.fx.CertClient.On("Delete", tt.fx.Ctx, oldCert).Return(nil, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				changed, err := tt.fx.LBOps.ReconcileHCLBServices(tt.fx.Ctx, tt.initialLB, tt.service)
+				assert.NoError(t, err)
+				assert.True(t, changed)
+				assert.Equal(t, "Normal ServiceUpdated Updated service on port 443", <-tt.fx.Recorder.Events)
+				assert.Equal(t,
+					"Normal CertificateDeleted Deleted certificate ccm-managed-certificate-uid", <-tt.fx.Recorder.Events)
+			},
+		},
+		{
+			name:         "report failed managed certificate",
+			servicePorts: []corev1.ServicePort{{Port: 443, NodePort: 8443}},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 13,
+				Services: []hcloud.LoadBalancerService{
+					{
+						ListenPort:      443,
+						DestinationPort: 8443,
+						Protocol:        hcloud.LoadBalancerServiceProtocolHTTPS,
+						HTTP: hcloud.LoadBalancerServiceHTTP{
+							Certificates: []*hcloud.Certificate{{ID: 1}},
+						},
+						HealthCheck: hcloud.LoadBalancerServiceHealthCheck{
+							Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+							Port:     8443,
+						},
+					},
+				},
+			},
+			serviceAnnotations: map[annotation.Name]interface{}{
+				annotation.LBSvcProtocol:                      hcloud.LoadBalancerServiceProtocolHTTPS,
+				annotation.LBSvcHTTPCertificateType:           hcloud.CertificateTypeManaged,
+				annotation.LBSvcHTTPManagedCertificateDomains: []string{"example.com", "*.example.com"},
+			},
+			serviceUID: "uid",
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				cert := &hcloud.Certificate{
+					ID:          1,
+					Name:        "ccm-managed-certificate-uid",
+					DomainNames: []string{"*.example.com", "example.com"},
+					Status: &hcloud.CertificateStatus{
+						Issuance: hcloud.CertificateStatusTypeFailed,
+						Error:    &hcloud.Error{Code: "dns_zone_not_found", Message: "DNS zone not found"},
+					},
+				}
+				tt.fx.CertClient.
+					On("AllWithOpts", tt.fx.Ctx, hcloud.CertificateListOpts{
+						ListOpts: hcloud.ListOpts{LabelSelector: "hcloud-ccm/service-uid=uid"},
+					}).
+					Return([]*hcloud.Certificate{cert}, nil)
+				tt.fx.CertClient.
+					On("AllWithOpts", tt.fx.Ctx, hcloud.CertificateListOpts{
+						ListOpts: hcloud.ListOpts{LabelSelector: "hcloud-ccm/secret-service-uid=uid"},
+					}).
+					Return([]*hcloud.Certificate{}, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				changed, err := tt.fx.LBOps.ReconcileHCLBServices(tt.fx.Ctx, tt.initialLB, tt.service)
+				assert.ErrorIs(t, err, hcops.ErrCertificateFailed)
+				assert.NotErrorIs(t, err, hcops.ErrCertificatePending)
+				assert.False(t, changed)
+				assert.Equal(t,
+					"Warning ManagedCertificateFailed Managed certificate ccm-managed-certificate-uid failed: DNS zone not found",
+					<-tt.fx.Recorder.Events)
 			},
 		},
 		{
@@ -1616,6 +2654,79 @@ func TestLoadBalancerOps_ReconcileHCLBServices(t *testing.T) {
 				assert.True(t, changed)
 			},
 		},
+		{
+			name: "only update differing options of hc Load Balancer services",
+			servicePorts: []corev1.ServicePort{
+				{Port: 80, NodePort: 8080},
+				{Port: 443, NodePort: 8444},
+			},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 6,
+				Services: []hcloud.LoadBalancerService{
+					{
+						Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+						ListenPort:      80,
+						DestinationPort: 8080,
+						HealthCheck: hcloud.LoadBalancerServiceHealthCheck{
+							Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+							Port:     8080,
+							Interval: 15 * time.Second,
+						},
+					},
+					{
+						Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+						ListenPort:      443,
+						DestinationPort: 8443,
+						HealthCheck: hcloud.LoadBalancerServiceHealthCheck{
+							Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+							Port:     8443,
+							Interval: 15 * time.Second,
+						},
+					},
+				},
+			},
+			mock: func(_ *testing.T, tt *LBReconcilementTestCase) {
+				// Port 80 is unchanged and must not be updated.
+				opts := hcloud.LoadBalancerUpdateServiceOpts{
+					DestinationPort: hcloud.Ptr(8444),
+					HealthCheck: &hcloud.LoadBalancerUpdateServiceOptsHealthCheck{
+						Port: hcloud.Ptr(8444),
+					},
+				}
+				action := tt.fx.MockUpdateService(opts, tt.initialLB, 443, nil)
+				tt.fx.MockWatchProgress(action, nil)
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				changed, err := tt.fx.LBOps.ReconcileHCLBServices(tt.fx.Ctx, tt.initialLB, tt.service)
+				assert.NoError(t, err)
+				assert.True(t, changed)
+				assert.Equal(t, "Normal ServiceUpdated Updated service on port 443", <-tt.fx.Recorder.Events)
+				assert.Empty(t, tt.fx.Recorder.Events)
+			},
+		},
+		{
+			name:         "don't update unchanged hc Load Balancer services",
+			servicePorts: []corev1.ServicePort{{Port: 80, NodePort: 8080}},
+			initialLB: &hcloud.LoadBalancer{
+				ID: 7,
+				Services: []hcloud.LoadBalancerService{
+					{
+						Protocol:        hcloud.LoadBalancerServiceProtocolTCP,
+						ListenPort:      80,
+						DestinationPort: 8080,
+						HealthCheck: hcloud.LoadBalancerServiceHealthCheck{
+							Protocol: hcloud.LoadBalancerServiceProtocolTCP,
+							Port:     8080,
+						},
+					},
+				},
+			},
+			perform: func(t *testing.T, tt *LBReconcilementTestCase) {
+				changed, err := tt.fx.LBOps.ReconcileHCLBServices(tt.fx.Ctx, tt.initialLB, tt.service)
+				assert.NoError(t, err)
+				assert.False(t, changed)
+			},
+		},
 	}
 
 	for _, tt := range tests {
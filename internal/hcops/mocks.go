@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/mocks"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 type MockLoadBalancerOps struct {
@@ -35,6 +36,11 @@ func (m *MockLoadBalancerOps) Delete(ctx context.Context, lb *hcloud.LoadBalance
 	return args.Error(0)
 }
 
+func (m *MockLoadBalancerOps) DeleteCertificates(ctx context.Context, uid types.UID) error {
+	args := m.Called(ctx, uid)
+	return args.Error(0)
+}
+
 func (m *MockLoadBalancerOps) ReconcileHCLB(
 	ctx context.Context, lb *hcloud.LoadBalancer, svc *corev1.Service,
 ) (bool, error) {
@@ -56,6 +62,14 @@ func (m *MockLoadBalancerOps) ReconcileHCLBServices(
 	return args.Bool(0), args.Error(1)
 }
 
+func (m *MockLoadBalancerOps) PlanHCLB(
+	ctx context.Context, lbName string, lb *hcloud.LoadBalancer, svc *corev1.Service, nodes []*corev1.Node,
+) (*LoadBalancerPlan, error) {
+	args := m.Called(ctx, lbName, lb, svc, nodes)
+	plan, _ := args.Get(0).(*LoadBalancerPlan)
+	return plan, args.Error(1)
+}
+
 func (m *MockLoadBalancerOps) GetByK8SServiceUID(ctx context.Context, svc *corev1.Service) (*hcloud.LoadBalancer, error) {
 	args := m.Called(ctx, svc)
 	return mocks.GetLoadBalancerPtr(args, 0), args.Error(1)
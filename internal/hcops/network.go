@@ -7,5 +7,6 @@ import (
 )
 
 type HCloudNetworkClient interface {
+	Get(ctx context.Context, idOrName string) (*hcloud.Network, *hcloud.Response, error)
 	GetByID(ctx context.Context, id int64) (*hcloud.Network, *hcloud.Response, error)
 }
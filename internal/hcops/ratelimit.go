@@ -1,92 +1,21 @@
 package hcops
 
 import (
-	"strings"
-	"time"
-
-	"github.com/syself/hetzner-cloud-controller-manager/internal/util"
-	"github.com/syself/hrobot-go/models"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/robot/ratelimit"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/client-go/kubernetes/scheme"
 	"k8s.io/client-go/tools/record"
 )
 
-func init() {
-	rateLimitWaitTimeRobot, err := util.GetEnvDuration("RATE_LIMIT_WAIT_TIME_ROBOT")
-	if err != nil {
-		panic(err)
-	}
-
-	if rateLimitWaitTimeRobot == 0 {
-		rateLimitWaitTimeRobot = 5 * time.Minute
-	}
-
-	handler = rateLimitHandler{
-		waitTime: rateLimitWaitTimeRobot,
-	}
-
-	eventBroadcaster := record.NewBroadcaster()
-	recorder = eventBroadcaster.NewRecorder(scheme.Scheme, corev1.EventSource{Component: "hetzner-ccm-ratelimit"})
-}
-
-var recorder record.EventRecorder
-
-var handler rateLimitHandler
-
-type rateLimitHandler struct {
-	waitTime    time.Duration
-	exceeded    bool
-	lastChecked time.Time
-}
-
-func (rl *rateLimitHandler) set() {
-	rl.exceeded = true
-	rl.lastChecked = time.Now()
-}
-
-func (rl *rateLimitHandler) isExceeded() bool {
-	if !rl.exceeded {
-		return false
-	}
-
-	if time.Now().Before(rl.lastChecked.Add(rl.waitTime)) {
-		return true
-	}
-	// Waiting time is over. Should try again
-	rl.exceeded = false
-	rl.lastChecked = time.Time{}
-	return false
-}
-
-func (rl *rateLimitHandler) timeOfNextPossibleAPICall() time.Time {
-	if rl.lastChecked.IsZero() {
-		return time.Time{}
-	}
-	return rl.lastChecked.Add(rl.waitTime)
-}
-
-// implement rate limit that is stored in memory
-
-func IsRateLimitExceeded(obj runtime.Object) bool {
-	if handler.isExceeded() {
-		recorder.Event(obj, "Warning", "RobotRateLimitExceeded", "exceeded Hetzner Robot API rate limit")
-		return true
-	}
-	return false
-}
-
-func SetRateLimit() {
-	handler.set()
-}
-
-func TimeOfNextPossibleAPICall() time.Time {
-	return handler.timeOfNextPossibleAPICall()
-}
+// EventRobotRateLimitExceeded is the reason of the event recorded on the
+// object whose reconciliation failed because of a Robot API rate limit.
+const EventRobotRateLimitExceeded = "RobotRateLimitExceeded"
 
-func HandleRateLimitExceededError(err error, obj runtime.Object) {
-	if models.IsError(err, models.ErrorCodeRateLimitExceeded) || strings.Contains(err.Error(), "server responded with status code 403") {
-		recorder.Event(obj, "Warning", "RobotRateLimitExceeded", "exceeded Hetzner Robot API rate limit")
-		SetRateLimit()
+// HandleRateLimitExceededError records a warning event on obj, if err is
+// caused by a Robot API rate limit. recorder may be nil.
+func HandleRateLimitExceededError(recorder record.EventRecorder, err error, obj runtime.Object) {
+	if recorder == nil || !ratelimit.IsExceeded(err) {
+		return
 	}
+	recorder.Event(obj, corev1.EventTypeWarning, EventRobotRateLimitExceeded, "exceeded Hetzner Robot API rate limit")
 }
@@ -1,58 +1,52 @@
 package hcops
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
-	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/assert"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/robot/ratelimit"
+	"github.com/syself/hrobot-go/models"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
 )
 
-func TestRateLimitSet(t *testing.T) {
-	rl := rateLimitHandler{}
-	now := time.Now()
-	rl.set()
-	require.Equal(t, true, rl.exceeded)
-	require.NotEqual(t, time.Time{}, rl.lastChecked)
-	require.Equal(t, true, now.Before(rl.lastChecked))
-}
-
-func TestRateLimitIsExceeded(t *testing.T) {
-	now := time.Now()
-
-	rateLimitNotExceeded := rateLimitHandler{}
-
-	require.Equal(t, false, rateLimitNotExceeded.isExceeded())
-
-	rateLimitExceeded := rateLimitHandler{
-		exceeded:    true,
-		lastChecked: now.Add(-3 * time.Minute),
-		waitTime:    5 * time.Minute,
+func TestHandleRateLimitExceededError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expEvent bool
+	}{
+		{
+			name:     "budget used up",
+			err:      fmt.Errorf("get server: %w", &ratelimit.Error{Endpoint: "server_get", RetryAt: time.Now()}),
+			expEvent: true,
+		},
+		{
+			name:     "limit exceeded",
+			err:      models.Error{Code: models.ErrorCodeRateLimitExceeded, Message: "Rate limit exceeded"},
+			expEvent: true,
+		},
+		{
+			name: "other error",
+			err:  errors.New("server responded with status code 500"),
+		},
 	}
 
-	require.Equal(t, true, rateLimitExceeded.isExceeded())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := record.NewFakeRecorder(1)
+			HandleRateLimitExceededError(recorder, tt.err, &corev1.Node{})
 
-	rateLimitWaitingTimeOver := rateLimitHandler{
-		exceeded:    true,
-		lastChecked: now.Add(-10 * time.Minute),
+			if tt.expEvent {
+				assert.Equal(t, "Warning RobotRateLimitExceeded exceeded Hetzner Robot API rate limit", <-recorder.Events)
+			}
+			assert.Empty(t, recorder.Events)
+		})
 	}
 
-	require.Equal(t, false, rateLimitWaitingTimeOver.isExceeded())
-	require.Equal(t, time.Time{}, rateLimitWaitingTimeOver.lastChecked)
-	require.Equal(t, false, rateLimitWaitingTimeOver.exceeded)
-}
-
-func TestRateLimitTimeOfNextPossibleAPICall(t *testing.T) {
-	now := time.Now()
-	lastChecked := now.Add(-3 * time.Minute)
-	rateLimitExceeded := rateLimitHandler{
-		waitTime:    5 * time.Minute,
-		exceeded:    true,
-		lastChecked: lastChecked,
-	}
-
-	require.Equal(t, lastChecked.Add(rateLimitExceeded.waitTime), rateLimitExceeded.timeOfNextPossibleAPICall())
-
-	rateLimitNotExceeded := rateLimitHandler{}
-
-	require.Equal(t, time.Time{}, rateLimitNotExceeded.timeOfNextPossibleAPICall())
+	// Without recorder no event is recorded.
+	HandleRateLimitExceededError(nil, &ratelimit.Error{}, &corev1.Node{})
 }
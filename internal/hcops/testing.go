@@ -9,16 +9,19 @@ import (
 	"github.com/hetznercloud/hcloud-go/v2/hcloud"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/mocks"
 	"github.com/syself/hrobot-go/models"
+	"k8s.io/client-go/tools/record"
 )
 
 type LoadBalancerOpsFixture struct {
 	Name          string
 	Ctx           context.Context
 	LBClient      *mocks.LoadBalancerClient
+	ServerClient  *mocks.ServerClient
 	CertClient    *mocks.CertificateClient
 	ActionClient  *mocks.ActionClient
 	NetworkClient *mocks.NetworkClient
 	RobotClient   *mocks.RobotClient
+	Recorder      *record.FakeRecorder
 
 	LBOps *LoadBalancerOps
 
@@ -30,9 +33,11 @@ func NewLoadBalancerOpsFixture(t *testing.T) *LoadBalancerOpsFixture {
 		Ctx:           context.Background(),
 		ActionClient:  &mocks.ActionClient{},
 		LBClient:      &mocks.LoadBalancerClient{},
+		ServerClient:  mocks.NewServerClient(t),
 		CertClient:    &mocks.CertificateClient{},
 		NetworkClient: &mocks.NetworkClient{},
 		RobotClient:   &mocks.RobotClient{},
+		Recorder:      record.NewFakeRecorder(100),
 		T:             t,
 	}
 
@@ -44,10 +49,12 @@ func NewLoadBalancerOpsFixture(t *testing.T) *LoadBalancerOpsFixture {
 
 	fx.LBOps = &LoadBalancerOps{
 		LBClient:      fx.LBClient,
+		ServerClient:  fx.ServerClient,
 		CertOps:       &CertificateOps{CertClient: fx.CertClient},
 		ActionClient:  fx.ActionClient,
 		NetworkClient: fx.NetworkClient,
 		RobotClient:   fx.RobotClient,
+		Recorder:      fx.Recorder,
 	}
 
 	return fx
@@ -133,6 +140,7 @@ func (fx *LoadBalancerOpsFixture) MockWatchProgress(a *hcloud.Action, err error)
 func (fx *LoadBalancerOpsFixture) AssertExpectations() {
 	fx.ActionClient.AssertExpectations(fx.T)
 	fx.LBClient.AssertExpectations(fx.T)
+	fx.ServerClient.AssertExpectations(fx.T)
 	fx.CertClient.AssertExpectations(fx.T)
 	fx.NetworkClient.AssertExpectations(fx.T)
 }
@@ -0,0 +1,58 @@
+package hcops
+
+import (
+	"net"
+	"strings"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	corev1 "k8s.io/api/core/v1"
+	cloudproviderapi "k8s.io/cloud-provider/api"
+)
+
+// VSwitchSubnets returns the IP ranges of the subnets which couple network to
+// a Robot vSwitch.
+func VSwitchSubnets(network *hcloud.Network) []*net.IPNet {
+	var subnets []*net.IPNet
+	for _, subnet := range network.Subnets {
+		if subnet.Type == hcloud.NetworkSubnetTypeVSwitch && subnet.IPRange != nil {
+			subnets = append(subnets, subnet.IPRange)
+		}
+	}
+	return subnets
+}
+
+// InSubnets reports whether ip is part of one of subnets.
+func InSubnets(ip net.IP, subnets []*net.IPNet) bool {
+	for _, subnet := range subnets {
+		if subnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// RobotVSwitchIP returns the IP of the Robot server of node in one of the
+// vSwitch subnets, or an empty string if it has none.
+//
+// The Robot API does not know which IP a server uses in a vSwitch. Instead,
+// the IP the kubelet was started with (--node-ip) is used. Once it was
+// reported, it is kept as long as it is part of a vSwitch subnet.
+func RobotVSwitchIP(node *corev1.Node, subnets []*net.IPNet) string {
+	var candidates []string
+	if v, ok := node.Annotations[cloudproviderapi.AnnotationAlphaProvidedIPAddr]; ok {
+		candidates = append(candidates, strings.Split(v, ",")...)
+	}
+	for _, address := range node.Status.Addresses {
+		if address.Type == corev1.NodeInternalIP {
+			candidates = append(candidates, address.Address)
+		}
+	}
+
+	for _, candidate := range candidates {
+		ip := net.ParseIP(strings.TrimSpace(candidate))
+		if ip != nil && InSubnets(ip, subnets) {
+			return ip.String()
+		}
+	}
+	return ""
+}
@@ -0,0 +1,69 @@
+package hcops
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRobotVSwitchIP(t *testing.T) {
+	_, vswitchSubnet, _ := net.ParseCIDR("10.0.1.0/24")
+	subnets := []*net.IPNet{vswitchSubnet}
+
+	tests := []struct {
+		name     string
+		node     *corev1.Node
+		subnets  []*net.IPNet
+		expected string
+	}{
+		{
+			name: "provided node ip in vswitch subnet",
+			node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{"alpha.kubernetes.io/provided-node-ip": "10.0.1.7"},
+			}},
+			subnets:  subnets,
+			expected: "10.0.1.7",
+		},
+		{
+			name: "dual-stack provided node ip",
+			node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{"alpha.kubernetes.io/provided-node-ip": "2a01:f48:111:4221::1,10.0.1.7"},
+			}},
+			subnets:  subnets,
+			expected: "10.0.1.7",
+		},
+		{
+			name: "provided node ip outside of vswitch subnet",
+			node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{"alpha.kubernetes.io/provided-node-ip": "123.123.123.123"},
+			}},
+			subnets:  subnets,
+			expected: "",
+		},
+		{
+			name: "existing internal ip",
+			node: &corev1.Node{Status: corev1.NodeStatus{Addresses: []corev1.NodeAddress{
+				{Type: corev1.NodeExternalIP, Address: "123.123.123.123"},
+				{Type: corev1.NodeInternalIP, Address: "10.0.1.8"},
+			}}},
+			subnets:  subnets,
+			expected: "10.0.1.8",
+		},
+		{
+			name: "no vswitch subnets",
+			node: &corev1.Node{ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{"alpha.kubernetes.io/provided-node-ip": "10.0.1.7"},
+			}},
+			expected: "",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, RobotVSwitchIP(test.node, test.subnets))
+		})
+	}
+}
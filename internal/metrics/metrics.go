@@ -39,6 +39,13 @@ func Serve(address string) {
 	klog.Info("Starting metrics server at ", address)
 
 	registry.MustRegister(OperationCalled)
+	registry.MustRegister(RobotCacheRequests)
+	registry.MustRegister(RobotCacheRefreshDuration)
+	registry.MustRegister(RobotRateLimitRemaining)
+	registry.MustRegister(RobotRateLimitThrottled)
+	registry.MustRegister(RouteConvergenceDuration)
+	registry.MustRegister(RouteGCOrphaned)
+	registry.MustRegister(RouteGCDeleted)
 
 	gatherers := prometheus.Gatherers{
 		prometheus.DefaultGatherer,
@@ -0,0 +1,31 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Possible values of the "result" label of RobotCacheRequests.
+const (
+	RobotCacheHit   = "hit"
+	RobotCacheStale = "stale"
+	RobotCacheMiss  = "miss"
+)
+
+var RobotCacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "cloud_controller_manager_robot_cache_requests_total",
+	Help: "The total number of reads from the Robot server cache by result (hit, stale, miss)",
+}, []string{"result"})
+
+var RobotCacheRefreshDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "cloud_controller_manager_robot_cache_refresh_duration_seconds",
+	Help:    "The duration of refreshes of the Robot server cache",
+	Buckets: prometheus.DefBuckets,
+}, []string{"success"})
+
+var RobotRateLimitRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "cloud_controller_manager_robot_rate_limit_remaining",
+	Help: "The number of requests left in the hourly budget of a Robot API endpoint",
+}, []string{"endpoint"})
+
+var RobotRateLimitThrottled = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "cloud_controller_manager_robot_rate_limit_throttled_total",
+	Help: "The total number of Robot API requests which were not sent, because the budget of their endpoint was used up",
+}, []string{"endpoint"})
@@ -0,0 +1,19 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var RouteConvergenceDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Name:    "cloud_controller_manager_route_convergence_duration_seconds",
+	Help:    "The duration from queueing a route change until all queued route changes were applied to the network",
+	Buckets: []float64{0.5, 1, 2.5, 5, 10, 30, 60, 120, 300},
+})
+
+var RouteGCOrphaned = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "cloud_controller_manager_route_gc_orphaned",
+	Help: "The number of orphaned routes found in the network by the last garbage collection run, by reason",
+}, []string{"reason"})
+
+var RouteGCDeleted = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "cloud_controller_manager_route_gc_deleted_total",
+	Help: "The total number of orphaned routes deleted by the garbage collection, by reason",
+}, []string{"reason"})
@@ -46,6 +46,30 @@ func getRobotServers(args mock.Arguments, i int) []models.Server {
 	return v.([]models.Server)
 }
 
+func getRobotServerPtr(args mock.Arguments, i int) *models.Server {
+	v := args.Get(i)
+	if v == nil {
+		return nil
+	}
+	return v.(*models.Server)
+}
+
+func getRobotFailoverPtr(args mock.Arguments, i int) *models.Failover {
+	v := args.Get(i)
+	if v == nil {
+		return nil
+	}
+	return v.(*models.Failover)
+}
+
+func getRobotFailovers(args mock.Arguments, i int) []models.Failover {
+	v := args.Get(i)
+	if v == nil {
+		return nil
+	}
+	return v.([]models.Failover)
+}
+
 func getNetworkPtr(args mock.Arguments, i int) *hcloud.Network {
 	v := args.Get(i)
 	if v == nil {
@@ -93,3 +117,19 @@ func getCertificateCreateResult(args mock.Arguments, i int) hcloud.CertificateCr
 	}
 	return v.(hcloud.CertificateCreateResult)
 }
+
+func getFloatingIPPtr(args mock.Arguments, i int) *hcloud.FloatingIP {
+	v := args.Get(i)
+	if v == nil {
+		return nil
+	}
+	return v.(*hcloud.FloatingIP)
+}
+
+func getFloatingIPPtrS(args mock.Arguments, i int) []*hcloud.FloatingIP {
+	v := args.Get(i)
+	if v == nil {
+		return nil
+	}
+	return v.([]*hcloud.FloatingIP)
+}
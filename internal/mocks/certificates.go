@@ -29,3 +29,8 @@ func (m *CertificateClient) CreateCertificate(
 	args := m.Called(ctx, opts)
 	return getCertificateCreateResult(args, 0), getResponsePtr(args, 1), args.Error(2)
 }
+
+func (m *CertificateClient) Delete(ctx context.Context, certificate *hcloud.Certificate) (*hcloud.Response, error) {
+	args := m.Called(ctx, certificate)
+	return getResponsePtr(args, 0), args.Error(1)
+}
@@ -0,0 +1,29 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/hetznercloud/hcloud-go/v2/hcloud"
+	"github.com/stretchr/testify/mock"
+)
+
+type FloatingIPClient struct {
+	mock.Mock
+}
+
+func (m *FloatingIPClient) Get(ctx context.Context, idOrName string) (*hcloud.FloatingIP, *hcloud.Response, error) {
+	args := m.Called(ctx, idOrName)
+	return getFloatingIPPtr(args, 0), getResponsePtr(args, 1), args.Error(2)
+}
+
+func (m *FloatingIPClient) All(ctx context.Context) ([]*hcloud.FloatingIP, error) {
+	args := m.Called(ctx)
+	return getFloatingIPPtrS(args, 0), args.Error(1)
+}
+
+func (m *FloatingIPClient) Assign(
+	ctx context.Context, floatingIP *hcloud.FloatingIP, server *hcloud.Server,
+) (*hcloud.Action, *hcloud.Response, error) {
+	args := m.Called(ctx, floatingIP, server)
+	return getActionPtr(args, 0), getResponsePtr(args, 1), args.Error(2)
+}
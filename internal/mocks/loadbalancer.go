@@ -102,6 +102,20 @@ func (m *LoadBalancerClient) RemoveIPTarget(ctx context.Context, lb *hcloud.Load
 	return getActionPtr(args, 0), getResponsePtr(args, 1), args.Error(2)
 }
 
+func (m *LoadBalancerClient) AddLabelSelectorTarget(
+	ctx context.Context, lb *hcloud.LoadBalancer, opts hcloud.LoadBalancerAddLabelSelectorTargetOpts,
+) (*hcloud.Action, *hcloud.Response, error) {
+	args := m.Called(ctx, lb, opts)
+	return getActionPtr(args, 0), getResponsePtr(args, 1), args.Error(2)
+}
+
+func (m *LoadBalancerClient) RemoveLabelSelectorTarget(
+	ctx context.Context, lb *hcloud.LoadBalancer, labelSelector string,
+) (*hcloud.Action, *hcloud.Response, error) {
+	args := m.Called(ctx, lb, labelSelector)
+	return getActionPtr(args, 0), getResponsePtr(args, 1), args.Error(2)
+}
+
 func (m *LoadBalancerClient) UpdateService(
 	ctx context.Context, lb *hcloud.LoadBalancer, listenPort int, opts hcloud.LoadBalancerUpdateServiceOpts,
 ) (*hcloud.Action, *hcloud.Response, error) {
@@ -11,6 +11,11 @@ type NetworkClient struct {
 	mock.Mock
 }
 
+func (m *NetworkClient) Get(ctx context.Context, idOrName string) (*hcloud.Network, *hcloud.Response, error) {
+	args := m.Called(ctx, idOrName)
+	return getNetworkPtr(args, 0), getResponsePtr(args, 1), args.Error(2)
+}
+
 func (m *NetworkClient) GetByID(ctx context.Context, id int64) (*hcloud.Network, *hcloud.Response, error) {
 	args := m.Called(ctx, id)
 	return getNetworkPtr(args, 0), getResponsePtr(args, 1), args.Error(2)
@@ -43,12 +43,19 @@ func (m *RobotClient) BootRescueSet(_ int, _ *models.RescueSetInput) (*models.Re
 	panic("this method should not be called")
 }
 
-func (m *RobotClient) FailoverGet(_ string) (*models.Failover, error) {
-	panic("this method should not be called")
+func (m *RobotClient) FailoverGet(ip string) (*models.Failover, error) {
+	args := m.Called(ip)
+	return getRobotFailoverPtr(args, 0), args.Error(1)
 }
 
 func (m *RobotClient) FailoverGetList() ([]models.Failover, error) {
-	panic("this method should not be called")
+	args := m.Called()
+	return getRobotFailovers(args, 0), args.Error(1)
+}
+
+func (m *RobotClient) FailoverSet(ip, activeServerIP string) (*models.Failover, error) {
+	args := m.Called(ip, activeServerIP)
+	return getRobotFailoverPtr(args, 0), args.Error(1)
 }
 
 func (m *RobotClient) GetVersion() string {
@@ -83,8 +90,9 @@ func (m *RobotClient) ResetSet(_ int, _ *models.ResetSetInput) (*models.ResetPos
 	panic("this method should not be called")
 }
 
-func (m *RobotClient) ServerGet(_ int) (*models.Server, error) {
-	panic("this method should not be called")
+func (m *RobotClient) ServerGet(id int) (*models.Server, error) {
+	args := m.Called(id)
+	return getRobotServerPtr(args, 0), args.Error(1)
 }
 
 func (m *RobotClient) ServerReverse(_ int) (*models.Cancellation, error) {
@@ -27,6 +27,41 @@ func (m *ServerClient) All(ctx context.Context) ([]*hcloud.Server, error) {
 	return serverPtrSlice(m.T, args.Get(0)), args.Error(1)
 }
 
+// GetByID registers a call to obtain a server by its ID.
+func (m *ServerClient) GetByID(ctx context.Context, id int64) (*hcloud.Server, *hcloud.Response, error) {
+	args := m.Called(ctx, id)
+	return serverPtr(m.T, args.Get(0)), getResponsePtr(args, 1), args.Error(2)
+}
+
+// AllWithOpts registers a call to obtain the servers matching opts.
+func (m *ServerClient) AllWithOpts(ctx context.Context, opts hcloud.ServerListOpts) ([]*hcloud.Server, error) {
+	args := m.Called(ctx, opts)
+	return serverPtrSlice(m.T, args.Get(0)), args.Error(1)
+}
+
+// Update registers a call to update the name or labels of a server.
+func (m *ServerClient) Update(
+	ctx context.Context, server *hcloud.Server, opts hcloud.ServerUpdateOpts,
+) (*hcloud.Server, *hcloud.Response, error) {
+	args := m.Called(ctx, server, opts)
+	return serverPtr(m.T, args.Get(0)), getResponsePtr(args, 1), args.Error(2)
+}
+
+func serverPtr(t *testing.T, v interface{}) *hcloud.Server {
+	const op = "mocks/serverPtr"
+
+	t.Helper()
+
+	if v == nil {
+		return nil
+	}
+	s, ok := v.(*hcloud.Server)
+	if !ok {
+		t.Fatalf("%s: not a *Server: %t", op, v)
+	}
+	return s
+}
+
 func serverPtrSlice(t *testing.T, v interface{}) []*hcloud.Server {
 	const op = "mocks/serverPtrSlice"
 
@@ -12,6 +12,12 @@ const (
 	// It MUST not be changed, otherwise existing nodes will not be recognized anymore.
 	prefixCloud = "hcloud://"
 
+	// prefixRobot is the prefix for Robot Server provider IDs. It is the same
+	// prefix that is used by the upstream hcloud-cloud-controller-manager.
+	//
+	// It MUST not be changed, otherwise existing nodes will not be recognized anymore.
+	prefixRobot = "hrobot://"
+
 	// prefixRobotLegacy is the prefix used by the Syself Fork for Robot Server provider IDs.
 	// This Prefix is no longer used for new nodes, instead prefix "hrobot://" should be used.
 	//
@@ -25,8 +31,9 @@ type UnkownPrefixError struct {
 
 func (e *UnkownPrefixError) Error() string {
 	return fmt.Sprintf(
-		"Provider ID does not have one of the the expected prefixes (%s, %s): %s",
+		"Provider ID does not have one of the the expected prefixes (%s, %s, %s): %s",
 		prefixCloud,
+		prefixRobot,
 		prefixRobotLegacy,
 		e.ProviderID,
 	)
@@ -40,6 +47,9 @@ func (e *UnkownPrefixError) Error() string {
 func ToServerID(providerID string) (id int64, isCloudServer bool, err error) {
 	idString := ""
 	switch {
+	case strings.HasPrefix(providerID, prefixRobot):
+		idString = strings.ReplaceAll(providerID, prefixRobot, "")
+
 	case strings.HasPrefix(providerID, prefixRobotLegacy):
 		// This case needs to be before [prefixCloud], as [prefixCloud] is a superset of [prefixRobotLegacy]
 		idString = strings.ReplaceAll(providerID, prefixRobotLegacy, "")
@@ -68,7 +78,13 @@ func FromCloudServerID(serverID int64) string {
 	return fmt.Sprintf("%s%d", prefixCloud, serverID)
 }
 
-// LegacyFromRobotServerNumber generates the canonical ProviderID for a Robot Server.
+// FromRobotServerNumber generates the canonical ProviderID for a Robot Server.
+func FromRobotServerNumber(serverNumber int) string {
+	return fmt.Sprintf("%s%d", prefixRobot, serverNumber)
+}
+
+// LegacyFromRobotServerNumber generates the ProviderID for a Robot Server in
+// the format used by the Syself Fork before "hrobot://" was introduced.
 func LegacyFromRobotServerNumber(serverNumber int) string {
 	return fmt.Sprintf("%s%d", prefixRobotLegacy, serverNumber)
 }
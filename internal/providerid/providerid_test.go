@@ -35,6 +35,28 @@ func TestFromCloudServerID(t *testing.T) {
 }
 
 func TestFromRobotServerNumber(t *testing.T) {
+	tests := []struct {
+		name         string
+		serverNumber int
+		want         string
+	}{
+		{
+			name:         "simple id",
+			serverNumber: 4321,
+			want:         "hrobot://4321",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FromRobotServerNumber(tt.serverNumber); got != tt.want {
+				t.Errorf("FromRobotServerNumber() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLegacyFromRobotServerNumber(t *testing.T) {
 	tests := []struct {
 		name         string
 		serverNumber int
@@ -50,7 +72,7 @@ func TestFromRobotServerNumber(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			if got := LegacyFromRobotServerNumber(tt.serverNumber); got != tt.want {
-				t.Errorf("FromRobotServerNumber() = %v, want %v", got, tt.want)
+				t.Errorf("LegacyFromRobotServerNumber() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -92,6 +114,27 @@ func TestToServerID(t *testing.T) {
 			wantIsCloudServer: false,
 			wantErr:           errors.New("providerID is missing a serverID: hcloud://"),
 		},
+		{
+			name:              "[robot] simple id",
+			providerID:        "hrobot://4321",
+			wantID:            4321,
+			wantIsCloudServer: false,
+			wantErr:           nil,
+		},
+		{
+			name:              "[robot] invalid id",
+			providerID:        "hrobot://my-robot",
+			wantID:            0,
+			wantIsCloudServer: false,
+			wantErr:           errors.New("unable to parse server id: hrobot://my-robot"),
+		},
+		{
+			name:              "[robot] missing id",
+			providerID:        "hrobot://",
+			wantID:            0,
+			wantIsCloudServer: false,
+			wantErr:           errors.New("providerID is missing a serverID: hrobot://"),
+		},
 		{
 			name:              "[robot-syself] simple id",
 			providerID:        "hcloud://bm-4321",
@@ -168,6 +211,24 @@ func FuzzRoundTripCloud(f *testing.F) {
 func FuzzRoundTripRobot(f *testing.F) {
 	f.Add(123123123)
 
+	f.Fuzz(func(t *testing.T, serverNumber int) {
+		providerID := FromRobotServerNumber(serverNumber)
+		id, isCloudServer, err := ToServerID(providerID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if int(id) != serverNumber {
+			t.Fatalf("expected %d, got %d", serverNumber, id)
+		}
+		if isCloudServer {
+			t.Fatalf("expected %t, got %t", false, isCloudServer)
+		}
+	})
+}
+
+func FuzzRoundTripRobotLegacy(f *testing.F) {
+	f.Add(123123123)
+
 	f.Fuzz(func(t *testing.T, serverNumber int) {
 		providerID := LegacyFromRobotServerNumber(serverNumber)
 		id, isCloudServer, err := ToServerID(providerID)
@@ -186,6 +247,7 @@ func FuzzRoundTripRobot(f *testing.F) {
 func FuzzToServerId(f *testing.F) {
 	f.Add("hcloud://123123123")
 	f.Add("hcloud://bm-123123123")
+	f.Add("hrobot://123123123")
 
 	f.Fuzz(func(t *testing.T, providerID string) {
 		_, _, err := ToServerID(providerID)
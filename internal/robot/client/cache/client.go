@@ -4,58 +4,87 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/syself/hetzner-cloud-controller-manager/internal/credentials"
+	"github.com/syself/hetzner-cloud-controller-manager/internal/metrics"
 	robotclient "github.com/syself/hetzner-cloud-controller-manager/internal/robot/client"
-	"github.com/syself/hetzner-cloud-controller-manager/internal/util"
 	hrobot "github.com/syself/hrobot-go"
 	"github.com/syself/hrobot-go/models"
+	"golang.org/x/sync/singleflight"
 	"k8s.io/klog/v2"
 )
 
 const (
 	robotUserNameENVVar = "ROBOT_USER_NAME"
 	robotPasswordENVVar = "ROBOT_PASSWORD"
-	cacheTimeoutENVVar  = "CACHE_TIMEOUT"
+
+	defaultCacheTimeout = 5 * time.Minute
+
+	defaultBaseURL = "https://robot-ws.your-server.de"
+
+	refreshKey = "servers"
 )
 
-var _ robotclient.Client = &cacheRobotClient{}
+var _ robotclient.FailoverClient = &cacheRobotClient{}
 
+// cacheRobotClient caches the server list of the Robot API. It is safe for
+// concurrent use.
+//
+// A cache older than timeout is served stale while it is refreshed in the
+// background, for at most another timeout. Callers only wait for the Robot
+// API if there is no cache yet or if it is older than twice the timeout.
+// Concurrent refreshes are deduplicated.
 type cacheRobotClient struct {
 	robotClient hrobot.RobotClient
 	timeout     time.Duration
 
-	lastUpdate time.Time
+	// httpClient and baseURL are used for the requests hrobot does not
+	// support.
+	httpClient *http.Client
+	baseURL    string
+
+	refreshGroup singleflight.Group
+
+	mu    sync.RWMutex
+	cache *serverCache
+	// generation is incremented every time the cache is invalidated. A refresh
+	// which started before the invalidation does not store its result.
+	generation uint64
+	// username and password are the credentials of the requests hrobot does
+	// not support.
+	username, password string
+}
 
-	// cache
-	l []models.Server
-	m map[int]*models.Server
+// serverCache is a snapshot of the server list. It is never modified after
+// it was created.
+type serverCache struct {
+	list       []models.Server
+	byNumber   map[int]*models.Server
+	lastUpdate time.Time
 }
 
 // NewCachedRobotClient creates a new robot client with caching enabled.
 // rootDir: root directory for reading credentials from file.
 // httpClient: http client to use for the robot client.
 // baseURL: base URL for the robot client. Optional, leave empty for default.
+// cacheTimeout: maximum age of the cached server list. Optional, zero means 5 minutes.
 // Returns nil and no error if the robot client could not be created, because
 // the credentials are optional.
-func NewCachedRobotClient(rootDir string, httpClient *http.Client, baseURL string) (robotclient.Client, error) {
+func NewCachedRobotClient(rootDir string, httpClient *http.Client, baseURL string, cacheTimeout time.Duration) (robotclient.Client, error) {
 	const op = "hcloud/newRobotClient"
 
 	if httpClient == nil {
 		return nil, fmt.Errorf("%s: httpClient is nil", op)
 	}
-	cacheTimeout, err := util.GetEnvDuration(cacheTimeoutENVVar)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
 
 	if cacheTimeout == 0 {
-		cacheTimeout = 5 * time.Minute
+		cacheTimeout = defaultCacheTimeout
 	}
 
 	credentialsDir := credentials.GetDirectory(rootDir)
-	_, err = os.Stat(credentialsDir)
+	_, err := os.Stat(credentialsDir)
 	var robotUser, robotPassword string
 	if err != nil {
 		klog.V(1).Infof("reading Hetzner Robot credentials from file failed. %q does not exist", credentialsDir)
@@ -80,35 +109,26 @@ func NewCachedRobotClient(rootDir string, httpClient *http.Client, baseURL strin
 	c := hrobot.NewBasicAuthClientWithCustomHttpClient(robotUser, robotPassword, httpClient)
 	if baseURL != "" {
 		c.SetBaseURL(baseURL)
+	} else {
+		baseURL = defaultBaseURL
 	}
 
 	handler := &cacheRobotClient{}
 	handler.timeout = cacheTimeout
 	handler.robotClient = c
+	handler.httpClient = httpClient
+	handler.baseURL = baseURL
+	handler.username, handler.password = robotUser, robotPassword
 	return handler, nil
 }
 
 func (c *cacheRobotClient) ServerGet(id int) (*models.Server, error) {
-	if c.shouldSync() {
-		list, err := c.robotClient.ServerGetList()
-		if err != nil {
-			return nil, err
-		}
-
-		// populate list
-		c.l = list
-
-		// remove all entries from map and populate it freshly
-		c.m = make(map[int]*models.Server)
-		for i, server := range list {
-			c.m[server.ServerNumber] = &list[i]
-		}
-
-		// set time of last update
-		c.lastUpdate = time.Now()
+	cache, err := c.servers()
+	if err != nil {
+		return nil, err
 	}
 
-	server, found := c.m[id]
+	server, found := cache.byNumber[id]
 	if !found {
 		// return not found error
 		return nil, models.Error{Code: models.ErrorCodeServerNotFound, Message: "server not found"}
@@ -118,38 +138,71 @@ func (c *cacheRobotClient) ServerGet(id int) (*models.Server, error) {
 }
 
 func (c *cacheRobotClient) ServerGetList() ([]models.Server, error) {
-	if c.shouldSync() {
-		list, err := c.robotClient.ServerGetList()
-		if err != nil {
-			return list, err
-		}
-
-		// populate list
-		c.l = list
+	cache, err := c.servers()
+	if err != nil {
+		return nil, err
+	}
+	return cache.list, nil
+}
 
-		// remove all entries from map and populate it freshly
-		c.m = make(map[int]*models.Server)
-		for i, server := range list {
-			c.m[server.ServerNumber] = &list[i]
+// servers returns the cached server list and refreshes it if needed.
+func (c *cacheRobotClient) servers() (*serverCache, error) {
+	c.mu.RLock()
+	cache := c.cache
+	c.mu.RUnlock()
+
+	if cache != nil {
+		age := time.Since(cache.lastUpdate)
+		if age <= c.timeout {
+			metrics.RobotCacheRequests.WithLabelValues(metrics.RobotCacheHit).Inc()
+			return cache, nil
+		}
+		if age <= 2*c.timeout {
+			metrics.RobotCacheRequests.WithLabelValues(metrics.RobotCacheStale).Inc()
+			// The result is stored in the cache, errors are logged by refresh.
+			c.refreshGroup.DoChan(refreshKey, c.refresh)
+			return cache, nil
 		}
-
-		// set time of last update
-		c.lastUpdate = time.Now()
 	}
 
-	return c.l, nil
+	metrics.RobotCacheRequests.WithLabelValues(metrics.RobotCacheMiss).Inc()
+	v, err, _ := c.refreshGroup.Do(refreshKey, c.refresh)
+	if err != nil {
+		return nil, err
+	}
+	return v.(*serverCache), nil
 }
 
-func (c *cacheRobotClient) shouldSync() bool {
-	// map is nil means we have no cached value yet
-	if c.m == nil {
-		c.m = make(map[int]*models.Server)
-		return true
+// refresh fetches the server list from the Robot API and stores it in the
+// cache. It must only be called via refreshGroup.
+func (c *cacheRobotClient) refresh() (interface{}, error) {
+	c.mu.RLock()
+	generation := c.generation
+	c.mu.RUnlock()
+
+	start := time.Now()
+	list, err := c.robotClient.ServerGetList()
+	metrics.RobotCacheRefreshDuration.WithLabelValues(fmt.Sprint(err == nil)).Observe(time.Since(start).Seconds())
+	if err != nil {
+		klog.ErrorS(err, "refreshing robot server cache failed")
+		return nil, err
+	}
+
+	cache := &serverCache{
+		list:       list,
+		byNumber:   make(map[int]*models.Server, len(list)),
+		lastUpdate: time.Now(),
 	}
-	if time.Now().After(c.lastUpdate.Add(c.timeout)) {
-		return true
+	for i, server := range list {
+		cache.byNumber[server.ServerNumber] = &list[i]
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.generation == generation {
+		c.cache = cache
 	}
-	return false
+	return cache, nil
 }
 
 func (c *cacheRobotClient) SetCredentials(username, password string) error {
@@ -158,6 +211,12 @@ func (c *cacheRobotClient) SetCredentials(username, password string) error {
 		return err
 	}
 	// The credentials have been updated, so we need to invalidate the cache.
-	c.m = nil
+	c.mu.Lock()
+	c.username, c.password = username, password
+	c.cache = nil
+	c.generation++
+	c.mu.Unlock()
+	// Callers must not wait for a refresh which still uses the old credentials.
+	c.refreshGroup.Forget(refreshKey)
 	return nil
 }
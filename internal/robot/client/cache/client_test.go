@@ -8,9 +8,12 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/syself/hetzner-cloud-controller-manager/internal/credentials"
 	"github.com/syself/hrobot-go/models"
@@ -59,7 +62,7 @@ func Test_updateRobotCredentials(t *testing.T) {
 	})
 
 	httpClient := server.Client()
-	robotClient, err := NewCachedRobotClient(rootDir, httpClient, server.URL+"/robot")
+	robotClient, err := NewCachedRobotClient(rootDir, httpClient, server.URL+"/robot", 0)
 	require.NoError(t, err)
 	require.NotNil(t, robotClient)
 	err = credentials.Watch(credentials.GetDirectory(rootDir), nil, robotClient)
@@ -111,3 +114,113 @@ func writeCredentials(rootDir, user, password string) error {
 	}
 	return nil
 }
+
+// newTestCacheClient returns a cacheRobotClient which reads from a test server.
+// The server answers every request with the servers returned by servers and
+// blocks until release returns.
+func newTestCacheClient(t *testing.T, servers func() []models.Server, release func()) (*cacheRobotClient, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robot/server", func(w http.ResponseWriter, _ *http.Request) {
+		requests.Add(1)
+		release()
+		var resp []models.ServerResponse
+		for _, s := range servers() {
+			resp = append(resp, models.ServerResponse{Server: s})
+		}
+		json.NewEncoder(w).Encode(resp)
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	t.Setenv(robotUserNameENVVar, "user")
+	t.Setenv(robotPasswordENVVar, "password")
+
+	client, err := NewCachedRobotClient(t.TempDir(), server.Client(), server.URL+"/robot", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, client)
+	return client.(*cacheRobotClient), &requests
+}
+
+func setCacheAge(c *cacheRobotClient, age time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cache := *c.cache
+	cache.lastUpdate = time.Now().Add(-age)
+	c.cache = &cache
+}
+
+func TestCacheRobotClient_ConcurrentMiss(t *testing.T) {
+	unblock := make(chan struct{})
+	c, requests := newTestCacheClient(t,
+		func() []models.Server { return []models.Server{{ServerNumber: 321}} },
+		func() { <-unblock },
+	)
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			server, err := c.ServerGet(321)
+			assert.NoError(t, err)
+			assert.Equal(t, 321, server.ServerNumber)
+		}()
+	}
+	time.Sleep(50 * time.Millisecond)
+	close(unblock)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestCacheRobotClient_StaleWhileRevalidate(t *testing.T) {
+	var name atomic.Value
+	name.Store("old")
+	unblock := make(chan struct{}, 1)
+	unblock <- struct{}{}
+	c, requests := newTestCacheClient(t,
+		func() []models.Server { return []models.Server{{ServerNumber: 321, Name: name.Load().(string)}} },
+		func() { <-unblock },
+	)
+
+	servers, err := c.ServerGetList()
+	require.NoError(t, err)
+	require.Equal(t, "old", servers[0].Name)
+
+	// Expired, but young enough to be served while it is refreshed. The
+	// refresh blocks until unblock, so the stale cache must be returned.
+	name.Store("new")
+	setCacheAge(c, c.timeout+time.Second)
+	servers, err = c.ServerGetList()
+	require.NoError(t, err)
+	require.Equal(t, "old", servers[0].Name)
+
+	unblock <- struct{}{}
+	require.Eventually(t, func() bool {
+		servers, err := c.ServerGetList()
+		return err == nil && servers[0].Name == "new"
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, int32(2), requests.Load())
+}
+
+func TestCacheRobotClient_TooStale(t *testing.T) {
+	var name atomic.Value
+	name.Store("old")
+	c, requests := newTestCacheClient(t,
+		func() []models.Server { return []models.Server{{ServerNumber: 321, Name: name.Load().(string)}} },
+		func() {},
+	)
+
+	_, err := c.ServerGetList()
+	require.NoError(t, err)
+
+	name.Store("new")
+	setCacheAge(c, 2*c.timeout+time.Second)
+	server, err := c.ServerGet(321)
+	require.NoError(t, err)
+	assert.Equal(t, "new", server.Name)
+	assert.Equal(t, int32(2), requests.Load())
+}
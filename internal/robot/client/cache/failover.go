@@ -0,0 +1,57 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/syself/hrobot-go/models"
+)
+
+// FailoverGet returns the failover IP ip. Failover IPs are not cached, their
+// routing may change at any time.
+func (c *cacheRobotClient) FailoverGet(ip string) (*models.Failover, error) {
+	return c.robotClient.FailoverGet(ip)
+}
+
+// FailoverSet routes the failover IP ip to the server with the main IP
+// activeServerIP.
+func (c *cacheRobotClient) FailoverSet(ip, activeServerIP string) (*models.Failover, error) {
+	form := url.Values{"active_server_ip": {activeServerIP}}
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/failover/"+url.PathEscape(ip), strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	c.mu.RLock()
+	req.SetBasicAuth(c.username, c.password)
+	c.mu.RUnlock()
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		// Errors are reported like hrobot does.
+		var errResp models.ErrorResponse
+		if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error.Code != "" {
+			return nil, errResp.Error
+		}
+		return nil, fmt.Errorf("server responded with status code %v", resp.StatusCode)
+	}
+
+	var failoverResp models.FailoverResponse
+	if err := json.Unmarshal(body, &failoverResp); err != nil {
+		return nil, err
+	}
+	return &failoverResp.Failover, nil
+}
@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/syself/hrobot-go/models"
+)
+
+func TestCacheRobotClient_FailoverSet(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /robot/failover/1.2.3.4", func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		assert.Equal(t, "user", user)
+		assert.Equal(t, "password", password)
+		assert.Equal(t, "123.123.123.124", r.FormValue("active_server_ip"))
+		json.NewEncoder(w).Encode(models.FailoverResponse{Failover: models.Failover{
+			IP:             "1.2.3.4",
+			ServerIP:       "123.123.123.123",
+			ActiveServerIP: r.FormValue("active_server_ip"),
+		}})
+	})
+	mux.HandleFunc("POST /robot/failover/1.2.3.5", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(models.ErrorResponse{Error: models.Error{
+			Code: models.ErrorCodeNotFound, Message: "Failover IP not found",
+		}})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	t.Setenv(robotUserNameENVVar, "user")
+	t.Setenv(robotPasswordENVVar, "password")
+
+	client, err := NewCachedRobotClient(t.TempDir(), server.Client(), server.URL+"/robot", time.Minute)
+	require.NoError(t, err)
+	c := client.(*cacheRobotClient)
+
+	failover, err := c.FailoverSet("1.2.3.4", "123.123.123.124")
+	require.NoError(t, err)
+	assert.Equal(t, "123.123.123.124", failover.ActiveServerIP)
+
+	_, err = c.FailoverSet("1.2.3.5", "123.123.123.124")
+	assert.Equal(t, models.Error{Code: models.ErrorCodeNotFound, Message: "Failover IP not found"}, err)
+}
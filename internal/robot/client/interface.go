@@ -7,3 +7,13 @@ type Client interface {
 	ServerGetList() ([]models.Server, error)
 	SetCredentials(username, password string) error
 }
+
+// FailoverClient is a Client which also routes the failover IPs of the Robot
+// account.
+type FailoverClient interface {
+	Client
+	FailoverGet(ip string) (*models.Failover, error)
+	// FailoverSet routes the failover IP ip to the server with the main IP
+	// activeServerIP.
+	FailoverSet(ip, activeServerIP string) (*models.Failover, error)
+}
@@ -0,0 +1,293 @@
+// Package ratelimit budgets the requests to the Hetzner Robot API.
+//
+// The Robot API limits the number of requests per endpoint and hour. Once
+// a limit is exceeded, all requests to the endpoint fail until the hour is
+// over. The Limiter avoids this by budgeting requests ahead of time: no more
+// than Limit requests are sent to an endpoint within any hour. It is injected
+// into the HTTP client of the Robot client via Limiter.RoundTripper.
+package ratelimit
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/syself/hetzner-cloud-controller-manager/internal/metrics"
+	"github.com/syself/hrobot-go/models"
+	"k8s.io/klog/v2"
+)
+
+// Budget is the number of requests allowed per hour for one endpoint.
+type Budget struct {
+	// Name identifies the budget in errors and metrics.
+	Name string
+	// Method is the HTTP method of the endpoint.
+	Method string
+	// Path of the endpoint relative to the base URL. A "*" matches any
+	// single path segment.
+	Path string
+	// Limit is the number of requests per hour.
+	Limit int
+}
+
+// DefaultBudgets are the documented limits of the Robot API endpoints used
+// by the cloud controller manager, see
+// https://robot.hetzner.com/doc/webapi.en.html.
+var DefaultBudgets = []Budget{
+	{Name: "server_list", Method: http.MethodGet, Path: "/server", Limit: 200},
+	{Name: "server_get", Method: http.MethodGet, Path: "/server/*", Limit: 200},
+	{Name: "failover_list", Method: http.MethodGet, Path: "/failover", Limit: 100},
+	{Name: "failover_get", Method: http.MethodGet, Path: "/failover/*", Limit: 100},
+	{Name: "failover_set", Method: http.MethodPost, Path: "/failover/*", Limit: 50},
+}
+
+const defaultFallbackWait = 5 * time.Minute
+
+// defaultBudget is used for requests that match none of the budgets.
+var defaultBudget = Budget{Name: "other", Limit: 200}
+
+// Error is returned for requests which were not sent, because the budget of
+// their endpoint is used up.
+type Error struct {
+	Endpoint string
+	RetryAt  time.Time
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("robot rate limit of endpoint %s exhausted - next try at %q", e.Endpoint, e.RetryAt.String())
+}
+
+// IsExceeded reports whether err is caused by a rate limit: either the budget
+// of the endpoint is used up, or the Robot API reported an exceeded limit.
+func IsExceeded(err error) bool {
+	var rlErr *Error
+	if errors.As(err, &rlErr) {
+		return true
+	}
+	var apiErr models.Error
+	return errors.As(err, &apiErr) && apiErr.Code == models.ErrorCodeRateLimitExceeded
+}
+
+// window counts the requests sent to one endpoint within the last hour.
+type window struct {
+	budget Budget
+	// sent holds the times of the requests sent within the last hour, in
+	// ascending order.
+	sent         []time.Time
+	blockedUntil time.Time
+}
+
+// prune forgets the requests sent more than an hour before now.
+func (w *window) prune(now time.Time) {
+	i := 0
+	for i < len(w.sent) && !w.sent[i].Add(time.Hour).After(now) {
+		i++
+	}
+	w.sent = w.sent[i:]
+}
+
+// remaining returns the number of requests which may still be sent.
+func (w *window) remaining() int {
+	return max(w.budget.Limit-len(w.sent), 0)
+}
+
+// take records a request sent at now, if fewer than Limit requests were sent
+// within the last hour. Otherwise it returns the time the next request may be
+// sent.
+func (w *window) take(now time.Time) (ok bool, retryAt time.Time) {
+	if now.Before(w.blockedUntil) {
+		return false, w.blockedUntil
+	}
+	w.prune(now)
+	if len(w.sent) >= w.budget.Limit {
+		return false, w.sent[len(w.sent)-w.budget.Limit].Add(time.Hour)
+	}
+	w.sent = append(w.sent, now)
+	return true, time.Time{}
+}
+
+// Limiter budgets the requests of one Robot client. It is safe for
+// concurrent use.
+type Limiter struct {
+	// fallbackWait is the time no requests are sent to an endpoint after
+	// its limit was exceeded, if the response has no retry hint.
+	fallbackWait time.Duration
+	now          func() time.Time
+
+	mu      sync.Mutex
+	windows []*window
+	other   *window
+}
+
+// NewLimiter creates a Limiter with a window for every budget.
+// fallbackWait is used after the Robot API reported an exceeded limit
+// without a Retry-After header. Zero means 5 minutes.
+//
+// The requests sent before a restart are unknown, so half of every budget is
+// considered used during the first hour.
+func NewLimiter(budgets []Budget, fallbackWait time.Duration) *Limiter {
+	if fallbackWait == 0 {
+		fallbackWait = defaultFallbackWait
+	}
+	l := &Limiter{
+		fallbackWait: fallbackWait,
+		now:          time.Now,
+	}
+	start := l.now()
+	for _, b := range budgets {
+		l.windows = append(l.windows, newWindow(b, start))
+	}
+	l.other = newWindow(defaultBudget, start)
+
+	for _, w := range append(l.windows, l.other) {
+		metrics.RobotRateLimitRemaining.WithLabelValues(w.budget.Name).Set(float64(w.remaining()))
+	}
+	return l
+}
+
+func newWindow(budget Budget, start time.Time) *window {
+	w := &window{budget: budget}
+	for range budget.Limit / 2 {
+		w.sent = append(w.sent, start)
+	}
+	return w
+}
+
+// RoundTripper returns a http.RoundTripper which only forwards requests to
+// next if their endpoint has budget left. Otherwise it returns an *Error.
+func (l *Limiter) RoundTripper(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &roundTripper{limiter: l, next: next}
+}
+
+// Reserve takes one request from the budget of the endpoint of req.
+func (l *Limiter) Reserve(req *http.Request) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w := l.windowFor(req)
+	ok, retryAt := w.take(l.now())
+	metrics.RobotRateLimitRemaining.WithLabelValues(w.budget.Name).Set(float64(w.remaining()))
+	if !ok {
+		metrics.RobotRateLimitThrottled.WithLabelValues(w.budget.Name).Inc()
+		return &Error{Endpoint: w.budget.Name, RetryAt: retryAt}
+	}
+	return nil
+}
+
+// Exceeded records that the Robot API rejected req, because the limit of its
+// endpoint was exceeded. No requests are sent to the endpoint until
+// retryAfter is over, or the fallback wait time if retryAfter is zero.
+func (l *Limiter) Exceeded(req *http.Request, retryAfter time.Duration) {
+	if retryAfter <= 0 {
+		retryAfter = l.fallbackWait
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w := l.windowFor(req)
+	w.blockedUntil = l.now().Add(retryAfter)
+	metrics.RobotRateLimitRemaining.WithLabelValues(w.budget.Name).Set(0)
+	klog.InfoS("Robot API rate limit exceeded", "endpoint", w.budget.Name, "retryAt", w.blockedUntil)
+}
+
+func (l *Limiter) windowFor(req *http.Request) *window {
+	for _, w := range l.windows {
+		if w.budget.Method == req.Method && matchPath(w.budget.Path, req.URL.Path) {
+			return w
+		}
+	}
+	return l.other
+}
+
+// matchPath reports whether the last segments of path match pattern. Only the
+// last segments are compared, as the base URL of the Robot API may contain a
+// path as well.
+func matchPath(pattern, path string) bool {
+	patternSegments := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathSegments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(pathSegments) < len(patternSegments) {
+		return false
+	}
+	pathSegments = pathSegments[len(pathSegments)-len(patternSegments):]
+	for i, s := range patternSegments {
+		if s != "*" && s != pathSegments[i] {
+			return false
+		}
+	}
+	return true
+}
+
+type roundTripper struct {
+	limiter *Limiter
+	next    http.RoundTripper
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := rt.limiter.Reserve(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := rt.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusForbidden {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		if resp.StatusCode == http.StatusTooManyRequests || isRateLimitExceeded(body) {
+			rt.limiter.Exceeded(req, retryAfter(resp.Header.Get("Retry-After"), rt.limiter.now()))
+		}
+		// A 429 without Robot error is reported as RATE_LIMIT_EXCEEDED, so
+		// that it can be told apart from other errors, see IsExceeded.
+		if resp.StatusCode == http.StatusTooManyRequests && !isRateLimitExceeded(body) {
+			body = rateLimitExceededBody
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			resp.ContentLength = int64(len(body))
+			resp.Header.Del("Content-Length")
+		}
+	}
+	return resp, nil
+}
+
+var rateLimitExceededBody = []byte(`{"error":{"status":429,"code":"RATE_LIMIT_EXCEEDED","message":"Rate limit exceeded"}}`)
+
+func isRateLimitExceeded(body []byte) bool {
+	var errorResponse models.ErrorResponse
+	if err := json.Unmarshal(body, &errorResponse); err != nil {
+		return false
+	}
+	return errorResponse.Error.Code == models.ErrorCodeRateLimitExceeded
+}
+
+// retryAfter parses the value of a Retry-After header, which is either a
+// number of seconds or an HTTP date. It returns zero if there is no valid
+// hint.
+func retryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return t.Sub(now)
+	}
+	return 0
+}
@@ -0,0 +1,212 @@
+package ratelimit
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	hrobot "github.com/syself/hrobot-go"
+)
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+
+func newTestLimiter(budgets []Budget) (*Limiter, *fakeClock) {
+	l := NewLimiter(budgets, 10*time.Minute)
+	clock := &fakeClock{t: time.Now()}
+	l.now = clock.now
+	for _, w := range append(l.windows, l.other) {
+		for i := range w.sent {
+			w.sent[i] = clock.t
+		}
+	}
+	return l, clock
+}
+
+func newRequest(t *testing.T, method, url string) *http.Request {
+	t.Helper()
+	req, err := http.NewRequest(method, url, nil)
+	require.NoError(t, err)
+	return req
+}
+
+func TestMatchPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{pattern: "/server", path: "/server", want: true},
+		{pattern: "/server", path: "/robot/server", want: true},
+		{pattern: "/server", path: "/server/321", want: false},
+		{pattern: "/server/*", path: "/server/321", want: true},
+		{pattern: "/server/*", path: "/robot/server/321", want: true},
+		{pattern: "/server/*", path: "/server", want: false},
+		{pattern: "/failover/*", path: "/server/321", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchPath(tt.pattern, tt.path))
+		})
+	}
+}
+
+func TestLimiter_Reserve(t *testing.T) {
+	l, clock := newTestLimiter([]Budget{
+		{Name: "server_list", Method: http.MethodGet, Path: "/server", Limit: 4},
+		{Name: "server_get", Method: http.MethodGet, Path: "/server/*", Limit: 1},
+	})
+	start := clock.t
+	list := newRequest(t, http.MethodGet, "https://robot.example.com/server")
+	get := newRequest(t, http.MethodGet, "https://robot.example.com/server/321")
+
+	// Half of the budget is considered used after a restart.
+	require.NoError(t, l.Reserve(list))
+	require.NoError(t, l.Reserve(list))
+	require.NoError(t, l.Reserve(get))
+
+	// Both budgets are used up, the endpoints are limited independently.
+	var rlErr *Error
+	require.ErrorAs(t, l.Reserve(list), &rlErr)
+	assert.Equal(t, "server_list", rlErr.Endpoint)
+	assert.Equal(t, start.Add(time.Hour), rlErr.RetryAt)
+	require.ErrorAs(t, l.Reserve(get), &rlErr)
+	assert.Equal(t, "server_get", rlErr.Endpoint)
+	assert.Equal(t, start.Add(time.Hour), rlErr.RetryAt)
+
+	// The budget is not refilled before the requests are an hour old.
+	clock.t = start.Add(59 * time.Minute)
+	require.Error(t, l.Reserve(list))
+
+	clock.t = start.Add(time.Hour)
+	require.NoError(t, l.Reserve(list))
+	require.NoError(t, l.Reserve(list))
+	clock.t = start.Add(90 * time.Minute)
+	require.NoError(t, l.Reserve(list))
+	require.NoError(t, l.Reserve(list))
+	require.ErrorAs(t, l.Reserve(list), &rlErr)
+	assert.Equal(t, start.Add(2*time.Hour), rlErr.RetryAt)
+}
+
+func TestLimiter_ReserveHourlyWindow(t *testing.T) {
+	const limit = 10
+	l, clock := newTestLimiter([]Budget{
+		{Name: "server_list", Method: http.MethodGet, Path: "/server", Limit: limit},
+	})
+	list := newRequest(t, http.MethodGet, "https://robot.example.com/server")
+
+	// Requests are attempted in bursts and at a steady rate.
+	var sent []time.Time
+	for i := 0; i < 600; i++ {
+		if i%100 < 50 || i%7 == 0 {
+			for range 3 {
+				if l.Reserve(list) == nil {
+					sent = append(sent, clock.t)
+				}
+			}
+		}
+		clock.t = clock.t.Add(30 * time.Second)
+	}
+
+	require.NotEmpty(t, sent)
+	for i, start := range sent {
+		n := 0
+		for _, t2 := range sent[i:] {
+			if t2.Before(start.Add(time.Hour)) {
+				n++
+			}
+		}
+		assert.LessOrEqual(t, n, limit, "requests in the hour after %s", start)
+	}
+}
+
+func TestLimiter_Exceeded(t *testing.T) {
+	l, clock := newTestLimiter([]Budget{
+		{Name: "server_list", Method: http.MethodGet, Path: "/server", Limit: 200},
+	})
+	list := newRequest(t, http.MethodGet, "https://robot.example.com/server")
+
+	// Without retry hint the fallback wait time is used.
+	l.Exceeded(list, 0)
+	var rlErr *Error
+	require.ErrorAs(t, l.Reserve(list), &rlErr)
+	assert.Equal(t, clock.t.Add(10*time.Minute), rlErr.RetryAt)
+
+	clock.t = clock.t.Add(10 * time.Minute)
+	require.NoError(t, l.Reserve(list))
+
+	l.Exceeded(list, time.Minute)
+	require.ErrorAs(t, l.Reserve(list), &rlErr)
+	assert.Equal(t, clock.t.Add(time.Minute), rlErr.RetryAt)
+}
+
+func TestRoundTripper(t *testing.T) {
+	var requests int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robot/server", func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		w.Header().Set("Retry-After", "120")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`{"error":{"status":403,"code":"RATE_LIMIT_EXCEEDED","message":"Rate limit exceeded"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	l, clock := newTestLimiter(DefaultBudgets)
+	httpClient := &http.Client{Transport: l.RoundTripper(server.Client().Transport)}
+	client := hrobot.NewBasicAuthClientWithCustomHttpClient("user", "password", httpClient)
+	client.SetBaseURL(server.URL + "/robot")
+
+	// The response of the Robot API is passed on unchanged.
+	_, err := client.ServerGetList()
+	require.EqualError(t, err, "Rate limit exceeded (RATE_LIMIT_EXCEEDED)")
+
+	// The retry hint is respected and no request is sent.
+	_, err = client.ServerGetList()
+	var rlErr *Error
+	require.ErrorAs(t, err, &rlErr)
+	assert.Equal(t, clock.t.Add(2*time.Minute), rlErr.RetryAt)
+	assert.Equal(t, 1, requests)
+
+	// Other endpoints are not affected.
+	_, err = client.ServerGet(321)
+	assert.False(t, errors.As(err, &rlErr))
+}
+
+func TestIsExceeded(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/robot/server", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	})
+	mux.HandleFunc("/robot/server/321", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":{"status":404,"code":"SERVER_NOT_FOUND","message":"Server not found"}}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	l, _ := newTestLimiter(DefaultBudgets)
+	httpClient := &http.Client{Transport: l.RoundTripper(server.Client().Transport)}
+	client := hrobot.NewBasicAuthClientWithCustomHttpClient("user", "password", httpClient)
+	client.SetBaseURL(server.URL + "/robot")
+
+	// A 429 without Robot error is reported as RATE_LIMIT_EXCEEDED.
+	_, err := client.ServerGetList()
+	assert.True(t, IsExceeded(err))
+	assert.True(t, IsExceeded(fmt.Errorf("wrapped: %w", err)))
+
+	// The budget of the endpoint is used up.
+	_, err = client.ServerGetList()
+	assert.True(t, IsExceeded(err))
+
+	_, err = client.ServerGet(321)
+	assert.False(t, IsExceeded(err))
+}